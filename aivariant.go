@@ -0,0 +1,220 @@
+package main
+
+import "fmt"
+
+// AIVariant holds the production weights the AI uses in each situation a city can be in,
+// so that variants can be played against each other in a tournament.
+type AIVariant struct {
+	Name                     string
+	ConqueredCoastal         []unitWeight // island conquered, city next to sea
+	ConqueredInlandManyTanks []unitWeight // island conquered, inland city, 10 or more tanks on the island
+	ConqueredInland          []unitWeight // island conquered, inland city, fewer than 10 tanks
+	CoastalManyTanks         []unitWeight // island not conquered, city next to sea, 10 or more tanks
+	Coastal                  []unitWeight // island not conquered, city next to sea, fewer than 10 tanks
+	Inland                   []unitWeight // island not conquered, inland city
+	Specialists              []unitWeight // specialists a city calling for one adds to its weights, never built when left out
+}
+
+// DefaultAIVariant is the AI's standard production strategy.
+var DefaultAIVariant = &AIVariant{
+	Name: "default",
+	Specialists: []unitWeight{
+		{Artillery, 10},
+		{Paratrooper, 5},
+		{AntiAircraft, 10},
+		{CruiseMissile, 10},
+	},
+	ConqueredCoastal: []unitWeight{
+		{Tank, 1},
+		{Fighter, 1},
+		{Bomber, 1},
+		{Transport, 1},
+		{Destroyer, 2},
+		{Submarine, 2},
+		{Carrier, 2},
+		{Battleship, 3},
+	},
+	ConqueredInlandManyTanks: []unitWeight{
+		{Tank, 1},
+		{Fighter, 1},
+		{Bomber, 2},
+	},
+	ConqueredInland: []unitWeight{
+		{Tank, 5},
+		{Fighter, 1},
+		{Bomber, 1},
+	},
+	CoastalManyTanks: []unitWeight{
+		{Tank, 1},
+		{Fighter, 2},
+		{Destroyer, 3},
+	},
+	Coastal: []unitWeight{
+		{Tank, 3},
+		{Fighter, 3},
+		{Destroyer, 3},
+	},
+	Inland: []unitWeight{
+		{Tank, 7},
+		{Fighter, 3},
+	},
+}
+
+// AIVariants lists the AI variants available to tournaments.
+var AIVariants = []*AIVariant{
+	DefaultAIVariant,
+	{
+		Name: "land",
+		Specialists: []unitWeight{
+			{Artillery, 10},
+			{Paratrooper, 5},
+			{AntiAircraft, 5},
+		},
+		ConqueredCoastal: []unitWeight{
+			{Tank, 3},
+			{Transport, 3},
+			{Destroyer, 1},
+			{Battleship, 1},
+		},
+		ConqueredInlandManyTanks: []unitWeight{
+			{Tank, 3},
+			{Fighter, 1},
+		},
+		ConqueredInland: []unitWeight{
+			{Tank, 9},
+			{Fighter, 1},
+		},
+		CoastalManyTanks: []unitWeight{
+			{Tank, 2},
+			{Transport, 2},
+			{Destroyer, 1},
+		},
+		Coastal: []unitWeight{
+			{Tank, 8},
+			{Destroyer, 1},
+		},
+		Inland: []unitWeight{
+			{Tank, 1},
+		},
+	},
+	{
+		Name: "naval",
+		Specialists: []unitWeight{
+			{AntiAircraft, 5},
+			{CruiseMissile, 10},
+		},
+		ConqueredCoastal: []unitWeight{
+			{Transport, 2},
+			{Destroyer, 3},
+			{Submarine, 3},
+			{Carrier, 1},
+			{Battleship, 4},
+		},
+		ConqueredInlandManyTanks: []unitWeight{
+			{Tank, 1},
+			{Fighter, 1},
+		},
+		ConqueredInland: []unitWeight{
+			{Tank, 5},
+			{Fighter, 1},
+		},
+		CoastalManyTanks: []unitWeight{
+			{Transport, 1},
+			{Destroyer, 3},
+			{Submarine, 2},
+		},
+		Coastal: []unitWeight{
+			{Tank, 4},
+			{Destroyer, 3},
+		},
+		Inland: []unitWeight{
+			{Tank, 7},
+			{Fighter, 1},
+		},
+	},
+	{
+		Name: "air",
+		Specialists: []unitWeight{
+			{Paratrooper, 10},
+			{AntiAircraft, 10},
+		},
+		ConqueredCoastal: []unitWeight{
+			{Fighter, 3},
+			{Bomber, 2},
+			{Carrier, 2},
+		},
+		ConqueredInlandManyTanks: []unitWeight{
+			{Fighter, 2},
+			{Bomber, 2},
+		},
+		ConqueredInland: []unitWeight{
+			{Tank, 3},
+			{Fighter, 3},
+			{Bomber, 1},
+		},
+		CoastalManyTanks: []unitWeight{
+			{Fighter, 4},
+			{Destroyer, 1},
+		},
+		Coastal: []unitWeight{
+			{Tank, 3},
+			{Fighter, 4},
+		},
+		Inland: []unitWeight{
+			{Tank, 5},
+			{Fighter, 5},
+		},
+	},
+}
+
+// Validate checks the variant builds a unit in every situation: no weight is negative, and the
+// weights of each situation add up to more than 0.
+func (v *AIVariant) Validate() error {
+	situations := []struct {
+		name    string
+		weights []unitWeight
+	}{
+		{"conquered coastal", v.ConqueredCoastal},
+		{"conquered inland with many tanks", v.ConqueredInlandManyTanks},
+		{"conquered inland", v.ConqueredInland},
+		{"coastal with many tanks", v.CoastalManyTanks},
+		{"coastal", v.Coastal},
+		{"inland", v.Inland},
+		{"specialists", v.Specialists},
+	}
+	for _, situation := range situations {
+		total := 0
+		for _, w := range situation.weights {
+			if w.weight < 0 {
+				return fmt.Errorf("AI variant %q gives unit type %d a negative %s weight", v.Name, w.unit, situation.name)
+			}
+			total += w.weight
+		}
+		// specialists are only added to the weights of another situation, so may all be left out
+		if total == 0 && situation.name != "specialists" {
+			return fmt.Errorf("AI variant %q builds nothing in a %s city", v.Name, situation.name)
+		}
+	}
+	return nil
+}
+
+// getSpecialistWeight returns the weight the variant gives the specialist unit type when a city
+// calls for it, 0 if the variant never builds it.
+func (v *AIVariant) getSpecialistWeight(unitType UnitType) int {
+	for _, w := range v.Specialists {
+		if w.unit == unitType {
+			return w.weight
+		}
+	}
+	return 0
+}
+
+// getAIVariant returns the AI variant with the given name.
+func getAIVariant(name string) (*AIVariant, error) {
+	for _, variant := range AIVariants {
+		if variant.Name == name {
+			return variant, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown AI variant %q", name)
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
-	"container/list"
+	"container/heap"
 	"fmt"
-	"math"
+	"io"
 	"math/rand"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,13 +21,29 @@ type GameBoard struct {
 	Day     int
 	Player1 *Player
 	Player2 *Player
+	Fog     PlayerFog // fog of war of each player
+	Seed    int64     // seed of the board's random number generator
+	Output  io.Writer // where game messages are written, os.Stdout when nil
+	Rules   *RuleSet  // rule set the game is played under, the classic rules when nil
+
+	MinStartDistance int // fewest moves between the starting cities, a third of the board's longer side when 0
+
+	nextUnitID int             // ID given to the next unit added to the board
+	rng        *rand.Rand      // random number generator seeded with Seed
+	source     *countingSource // source of rng, counting the numbers drawn so saves can restore it
+	listeners  []func(Event)
+
+	weather     *Weather // the weather of weatherDay on a board seeded with weatherSeed, from getWeather
+	weatherDay  int
+	weatherSeed int64
 }
 
 // Cell struct represents a cell on the game board.
 type Cell struct {
-	IsLand  bool // true for land, false for sea
-	IsFog   bool // true for fog of war, false if visible
-	HasCity bool // true if the cell has a city, false otherwise
+	IsLand  bool    // true for land, false for sea
+	IsFog   bool    // true for fog of war, false if visible
+	HasCity bool    // true if the cell has a city, false otherwise
+	Terrain Terrain // mountains or forest on land, shallows or ice at sea
 }
 
 // Coordinate struct represents an X, Y, position on the game board.
@@ -40,6 +59,12 @@ type unitWeight struct {
 
 // NewGameBoard creates a new game board with the specified number of rows and columns.
 func NewGameBoard(rows, columns int) *GameBoard {
+	return NewGameBoardWithSeed(rows, columns, time.Now().UnixNano())
+}
+
+// NewGameBoardWithSeed creates a new game board whose random events are determined by the seed,
+// so that games can be replayed.
+func NewGameBoardWithSeed(rows, columns int, seed int64) *GameBoard {
 	grid := make([][]Cell, rows)
 	for i := range grid {
 		row := make([]Cell, columns)
@@ -51,104 +76,222 @@ func NewGameBoard(rows, columns int) *GameBoard {
 		grid[i] = row
 	}
 
+	source := newCountingSource(seed)
 	return &GameBoard{
 		Rows:    rows,
 		Columns: columns,
 		Grid:    grid,
 		Day:     0,
+		Seed:    seed,
+		rng:     rand.New(source),
+		source:  source,
+	}
+}
+
+// random returns the board's random number generator.
+func (g *GameBoard) random() *rand.Rand {
+	if g.rng == nil {
+		if g.Seed == 0 {
+			g.Seed = time.Now().UnixNano()
+		}
+		g.source = newCountingSource(g.Seed)
+		g.rng = rand.New(g.source)
+	}
+	return g.rng
+}
+
+// randomDraws returns how many random numbers the board has drawn since it was seeded.
+func (g *GameBoard) randomDraws() uint64 {
+	g.random()
+	return g.source.draws
+}
+
+const (
+	// randomDrawsPerCellDay bounds the random numbers a game draws for each cell of its board on
+	// each day played, far above what play draws, and maxRandomDraws bounds them on any board, so
+	// a save or turn file cannot keep the board drawing numbers for ever.
+	randomDrawsPerCellDay = 64
+	maxRandomDraws        = 1 << 30
+)
+
+// skipRandomDraws draws and discards n random numbers. It returns an error, drawing none, if the
+// board would have drawn more numbers than a game of its size and day can.
+func (g *GameBoard) skipRandomDraws(n uint64) error {
+	g.random()
+	limit := uint64(randomDrawsPerCellDay) * uint64(g.Rows*g.Columns) * uint64(maxInt(g.Day, 0)+1)
+	if limit > maxRandomDraws {
+		limit = maxRandomDraws
+	}
+	if n > limit || g.source.draws+n > limit {
+		return fmt.Errorf("%d more random draws is more than a game on a %dx%d board can make by day %d", n, g.Rows, g.Columns, g.Day)
 	}
+	for i := uint64(0); i < n; i++ {
+		g.source.Int63()
+	}
+	return nil
+}
+
+// countingSource is a rand.Source which counts the numbers drawn from it.
+type countingSource struct {
+	source rand.Source64
+	draws  uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{source: rand.NewSource(seed).(rand.Source64)}
+}
+
+// Int63 implements rand.Source.
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.source.Int63()
+}
+
+// Uint64 implements rand.Source64.
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.source.Uint64()
+}
+
+// Seed implements rand.Source.
+func (s *countingSource) Seed(seed int64) {
+	s.source.Seed(seed)
+	s.draws = 0
+}
+
+// printf writes a game message to the board's output.
+func (g *GameBoard) printf(format string, a ...interface{}) {
+	w := g.Output
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, format, a...)
 }
 
 // GenerateRandomIslands generates random oval-shaped islands on the game board.
+// Radii are limited to a quarter of the board, so islands fit on small maps.
 func (g *GameBoard) GenerateRandomIslands(numIslands int) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-
-	for i := 0; i < numIslands; i++ {
-		centerRow := r.Intn(g.Rows)
-		centerCol := r.Intn(g.Columns)
-		radiusX := r.Intn(8) + 2 // Random oval radius between 2 and 5 cells
-		radiusY := r.Intn(6) + 2 // Random oval radius between 2 and 5 cells
-
-		for row := 0; row < g.Rows; row++ {
-			for col := 0; col < g.Columns; col++ {
-				dx := float64(col - centerCol)
-				dy := float64(row - centerRow)
-				distance := math.Pow(dx/float64(radiusX), 2) + math.Pow(dy/float64(radiusY), 2)
-
-				if distance <= 1.0 {
-					g.Grid[row][col].IsLand = true
-				}
-			}
+	elevation := g.getOvalElevation(numIslands, g.Rows/4+1, g.Columns/4+1)
+	g.IterateGrid(func(row, col int, cell *Cell) {
+		if elevation[row][col] >= 0 {
+			cell.IsLand = true
 		}
-	}
+	})
 }
 
 // AddCities randomly adds cities to land cells without neighboring cities.
-func (g *GameBoard) AddCities(numCities int) {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+// It fails if there is no such cell left for one of the cities.
+func (g *GameBoard) AddCities(numCities int) error {
+	r := g.random()
 
 	for i := 0; i < numCities; i++ {
-		for {
-			row := r.Intn(g.Rows)
-			col := r.Intn(g.Columns)
-
-			// Check if the cell is land and does not have neighboring cities
-			excludeTargetCell := false
-			if g.Grid[row][col].IsLand && !g.HasNeighboringCity(row, col, excludeTargetCell) {
-				g.Grid[row][col].HasCity = true
-				city := NewCity(row, col)
-				city.IsCityNextToSea = g.IsCityNextToSea(city.PositionX, city.PositionY)
-				g.Cities = append(g.Cities, *city)
-				break
+		var candidates []Coordinate
+		excludeTargetCell := false
+		g.IterateGrid(func(row, col int, cell *Cell) {
+			if cell.IsLand && !g.HasNeighboringCity(row, col, excludeTargetCell) {
+				candidates = append(candidates, Coordinate{row, col})
 			}
+		})
+		if len(candidates) == 0 {
+			return fmt.Errorf("no room for city %d of %d", i+1, numCities)
 		}
+		c := candidates[r.Intn(len(candidates))]
+		g.Grid[c.PositionX][c.PositionY].HasCity = true
+		city := g.newCity(c.PositionX, c.PositionY)
+		city.IsCityNextToSea = g.IsCityNextToSea(city.PositionX, city.PositionY)
+		g.Cities = append(g.Cities, *city)
 	}
+	return nil
+}
+
+// NewRandomGameBoard creates a game board with oval islands and cities, see NewGeneratedGameBoard.
+// It panics if no playable map can be generated with these settings.
+func NewRandomGameBoard(rows, columns, numIslands, numCities int, seed int64) *GameBoard {
+	board, err := NewGeneratedGameBoard(rows, columns, NewMapOptions(numIslands, numCities), seed)
+	if err != nil {
+		panic(err)
+	}
+	return board
 }
 
-// DayZero performs game logic for a new day
+// DayZero gives each player a starting city, chosen by chooseStartingCities, and asks the
+// players what it should manufacture. On a board with a single city, player 1 takes it alone.
+// When players already hold cities, as in a scenario, it only asks what those of their cities
+// which manufacture nothing should manufacture.
 func (g *GameBoard) DayZero() {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	// player 1
-	randomIndex := r.Intn(len(g.Cities))
-	city := &g.Cities[randomIndex]
-	//city.OccupyingPlayer = OccupiedByPlayer1
-	city.OccupyCity(1)
-	if g.Player1.IsAI {
-		city.SetManufacturingUnit(g.getWhichUnitToManufactureNextAI(Coordinate{city.PositionX, city.PositionY}, 1, city.IsCityNextToSea))
+	if g.isScenarioStarted() {
+		for i := range g.Cities {
+			city := &g.Cities[i]
+			if city.OccupyingPlayer != Unoccupied && city.ManufacturingUnit == Blank {
+				g.setChosenProduction(city, g.getControllerForPlayer(int(city.OccupyingPlayer)).ChooseProduction(g, city))
+			}
+		}
+		return
 	}
-	// player 2
-	randomIndex = r.Intn(len(g.Cities))
-	city = &g.Cities[randomIndex]
-	//city.OccupyingPlayer = OccupiedByPlayer2
-	city.OccupyCity(2)
-	if g.Player2.IsAI {
-		city.SetManufacturingUnit(g.getWhichUnitToManufactureNextAI(Coordinate{city.PositionX, city.PositionY}, 2, city.IsCityNextToSea))
+	if len(g.Cities) < 2 {
+		if len(g.Cities) == 1 {
+			city := &g.Cities[0]
+			city.OccupyCity(1)
+			g.setChosenProduction(city, g.getControllerForPlayer(1).ChooseProduction(g, city))
+		}
+		return
+	}
+	first, second := g.chooseStartingCities()
+	for player, index := range []int{first, second} {
+		city := &g.Cities[index]
+		city.OccupyCity(player + 1)
+		g.setChosenProduction(city, g.getControllerForPlayer(player+1).ChooseProduction(g, city))
 	}
 }
 
 // NextDay performs game logic for a new day
 func (g *GameBoard) NextDay() {
 	g.Day++
+	g.emit(EventDayStarted, 0, Blank, Coordinate{})
 	for i := range g.Units {
 		unit := &g.Units[i] // Get a pointer to the current unit
-		unit.MovesLeftThisDay = GetMovesPerDay(unit.Type)
+		unit.MovesLeftThisDay = g.getMovesPerDay(unit)
+	}
+	if g.rules().Weather {
+		g.groundAircraft()
+	}
+	if g.rules().Repair {
+		g.repairUnits()
+	}
+	if g.rules().Supply {
+		g.applyAttrition()
 	}
+	g.recoverCities()
 	for i := range g.Cities {
 		city := &g.Cities[i] // Get a pointer to the current city
+		if city.OccupyingPlayer != Unoccupied {
+			city.HeldDays++
+		}
 		unitReady := city.ManufactureUnit()
 		if unitReady {
 			player := 1
 			if city.OccupyingPlayer == OccupiedByPlayer2 {
 				player = 2
 			}
-			newUnit := NewUnit(city.PositionX, city.PositionY, city.ManufacturingUnit, player)
-			g.Units = append(g.Units, *newUnit)
-			// Reset DaysUntilUnitReady to the production time when the unit is manufactured
-			city.DaysUntilUnitReady = GetDaysToProduceUnit(city.ManufacturingUnit)
+			newUnit := g.newUnit(city.PositionX, city.PositionY, city.ManufacturingUnit, player)
+			if city.RallyPoint != nil {
+				rallyPoint := *city.RallyPoint
+				newUnit.Destination = &rallyPoint
+			}
+			g.addUnit(newUnit)
+			g.emit(EventUnitProduced, player, newUnit.Type, Coordinate{city.PositionX, city.PositionY})
+			city.startNextUnit(g.unitRules())
 		}
 	}
 }
 
+// addUnit adds a unit to the board, giving it a unique ID.
+func (g *GameBoard) addUnit(unit *Unit) {
+	g.nextUnitID++
+	unit.ID = g.nextUnitID
+	g.Units = append(g.Units, *unit)
+}
+
 // hasNeighboringCity checks if a cell has neighboring cities.
 func (g *GameBoard) HasNeighboringCity(row, col int, excludeTargetCell bool) bool {
 	for i := row - 1; i <= row+1; i++ {
@@ -165,11 +308,11 @@ func (g *GameBoard) HasNeighboringCity(row, col int, excludeTargetCell bool) boo
 	return false
 }
 
-// isCityNextToSea checks if a city is next to the sea.
+// isCityNextToSea checks if a city is next to the sea, other than ice which ships cannot cross.
 func (g *GameBoard) IsCityNextToSea(row, col int) bool {
 	for i := row - 1; i <= row+1; i++ {
 		for j := col - 1; j <= col+1; j++ {
-			if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns && !g.Grid[i][j].HasCity && !g.Grid[i][j].IsLand {
+			if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns && !g.Grid[i][j].HasCity && !g.Grid[i][j].IsLand && g.Grid[i][j].Terrain != TerrainIce {
 				return true
 			}
 		}
@@ -201,10 +344,8 @@ func (g *GameBoard) Print(showFogOfWar bool) {
 			} else {
 				if g.Grid[i][j].HasCity {
 					fmt.Print("C ")
-				} else if g.Grid[i][j].IsLand {
-					fmt.Print("L ")
 				} else {
-					fmt.Print("S ")
+					fmt.Printf("%c ", terrainToSymbol(g.Grid[i][j]))
 				}
 			}
 		}
@@ -213,26 +354,88 @@ func (g *GameBoard) Print(showFogOfWar bool) {
 }
 
 func (g *GameBoard) printGridWithUnits(showFogOfWar bool) {
-	grid := g.printToSlice(showFogOfWar)
+	g.fprintGridWithUnits(os.Stdout, 0, showFogOfWar)
+}
+
+// fprintGridWithUnits writes the game board, including units, as seen by the player to w.
+// The player's own units are upper case and enemy units lower case; without a player (0),
+// player 1's units are upper case. A cell with more than one unit is marked '*'.
+func (g *GameBoard) fprintGridWithUnits(w io.Writer, player int, showFogOfWar bool) {
+	grid := g.printToSliceForPlayer(player, showFogOfWar)
+	unitsAt := make(map[Coordinate]int)
+	var ranked []Unit // veteran and elite units shown, listed under the grid
 	for _, unit := range g.Units {
-		if !showFogOfWar || !g.Grid[unit.PositionX][unit.PositionY].IsFog {
-			grid[unit.PositionX][unit.PositionY] = unit.Symbol()
+		if !showFogOfWar || g.isUnitShownToPlayer(player, &unit) {
+			coordinate := Coordinate{unit.PositionX, unit.PositionY}
+			unitsAt[coordinate]++
+			if unitsAt[coordinate] > 1 {
+				grid[unit.PositionX][unit.PositionY] = "*"
+			} else {
+				grid[unit.PositionX][unit.PositionY] = g.unitSymbolForPlayer(&unit, player)
+			}
+			if unit.Rank() > RankRecruit {
+				ranked = append(ranked, unit)
+			}
 		}
 	}
-	g.printSlice(grid)
+	g.fprintSlice(w, grid)
+	for _, unit := range ranked {
+		fmt.Fprintf(w, "%s %d at (%d, %d), player %d, %s\n", g.unitTypeToString(unit.Type), unit.ID, unit.PositionX, unit.PositionY, unit.Player, rankToString(unit.Rank()))
+	}
+}
+
+// isFogShownToPlayer checks if the cell is under the player's fog of war, or, without a player
+// (0), under the fog of the board.
+func (g *GameBoard) isFogShownToPlayer(player, row, col int) bool {
+	if player == 0 {
+		return g.Grid[row][col].IsFog
+	}
+	return g.isFogForPlayer(player, row, col)
+}
+
+// isUnitShownToPlayer checks if the player sees the unit through their fog of war: their own
+// units, and enemy units in sight. Without a player (0), units outside the board's fog are shown.
+func (g *GameBoard) isUnitShownToPlayer(player int, unit *Unit) bool {
+	if g.isFogShownToPlayer(player, unit.PositionX, unit.PositionY) {
+		return false
+	}
+	return player == 0 || unit.Player == player || g.isUnitInSightOfPlayer(player, unit)
+}
+
+// unitSymbolForPlayer returns the unit's symbol, upper case for the player's own units and
+// lower case for enemy units.
+func (g *GameBoard) unitSymbolForPlayer(unit *Unit, player int) string {
+	if player == 0 {
+		player = 1
+	}
+	if unit.Player == player {
+		return g.unitRules().symbol(unit.Type)
+	}
+	return strings.ToLower(g.unitRules().symbol(unit.Type))
 }
 
 func (g *GameBoard) printSlice(grid [][]string) {
+	g.fprintSlice(os.Stdout, grid)
+}
+
+func (g *GameBoard) fprintSlice(w io.Writer, grid [][]string) {
 	for i := 0; i < g.Rows; i++ {
 		for j := 0; j < g.Columns; j++ {
 			//fmt.Print(grid[i][j] + " ")
-			fmt.Print(grid[i][j])
+			fmt.Fprint(w, grid[i][j])
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
 	}
 }
 
 func (g *GameBoard) printToSlice(showFogOfWar bool) [][]string {
+	return g.printToSliceForPlayer(0, showFogOfWar)
+}
+
+// printToSliceForPlayer renders the board as seen by the player, one string per cell:
+// '?' fog, 'L' land, 'S' sea, and for cities 'C' neutral, 'O' own and 'E' enemy.
+// Without a player (0), occupied cities are shown as '1' or '2' for their owner.
+func (g *GameBoard) printToSliceForPlayer(player int, showFogOfWar bool) [][]string {
 	grid := make([][]string, g.Rows)
 	for i := range grid {
 		row := make([]string, g.Columns)
@@ -241,15 +444,13 @@ func (g *GameBoard) printToSlice(showFogOfWar bool) [][]string {
 	// Print the game board with land/sea and fog of war
 	for i := 0; i < g.Rows; i++ {
 		for j := 0; j < g.Columns; j++ {
-			if showFogOfWar && g.Grid[i][j].IsFog {
+			if showFogOfWar && g.isFogShownToPlayer(player, i, j) {
 				grid[i][j] = "?"
 			} else {
 				if g.Grid[i][j].HasCity {
-					grid[i][j] = "C"
-				} else if g.Grid[i][j].IsLand {
-					grid[i][j] = "L"
+					grid[i][j] = g.citySymbolForPlayer(Coordinate{i, j}, player)
 				} else {
-					grid[i][j] = "S"
+					grid[i][j] = string(terrainToSymbol(g.Grid[i][j]))
 				}
 			}
 		}
@@ -257,52 +458,217 @@ func (g *GameBoard) printToSlice(showFogOfWar bool) [][]string {
 	return grid
 }
 
+// citySymbolForPlayer returns the symbol of the city at the coordinate as seen by the player.
+func (g *GameBoard) citySymbolForPlayer(coordinate Coordinate, player int) string {
+	city := g.getCityAtCoordinates(coordinate)
+	switch {
+	case city == nil || city.OccupyingPlayer == Unoccupied:
+		return "C"
+	case player == 0:
+		return strconv.Itoa(int(city.OccupyingPlayer))
+	case int(city.OccupyingPlayer) == player:
+		return "O"
+	default:
+		return "E"
+	}
+}
+
+// CellDetails lists everything at a coordinate of the board.
+type CellDetails struct {
+	Coordinate Coordinate
+	IsLand     bool
+	Terrain    Terrain
+	IsFog      bool
+	City       *City  // nil when there is no city
+	Units      []Unit // every unit in the cell, whatever its owner
+}
+
+// GetCellDetails returns everything at the coordinate, or false if it is off the board.
+func (g *GameBoard) GetCellDetails(coordinate Coordinate) (CellDetails, bool) {
+	if coordinate.PositionX < 0 || coordinate.PositionX >= g.Rows || coordinate.PositionY < 0 || coordinate.PositionY >= g.Columns {
+		return CellDetails{}, false
+	}
+	cell := g.Grid[coordinate.PositionX][coordinate.PositionY]
+	details := CellDetails{
+		Coordinate: coordinate,
+		IsLand:     cell.IsLand,
+		Terrain:    cell.Terrain,
+		IsFog:      cell.IsFog,
+		City:       g.getCityAtCoordinates(coordinate),
+	}
+	for _, unit := range g.Units {
+		if unit.PositionX == coordinate.PositionX && unit.PositionY == coordinate.PositionY {
+			details.Units = append(details.Units, unit)
+		}
+	}
+	return details, true
+}
+
+// fprintCellDetails writes everything the player can see at the coordinate to w.
+// Without a player (0), everything is shown.
+func (g *GameBoard) fprintCellDetails(w io.Writer, coordinate Coordinate, player int) {
+	details, ok := g.GetCellDetails(coordinate)
+	if !ok {
+		fmt.Fprintf(w, "(%d, %d) is off the board\n", coordinate.PositionX, coordinate.PositionY)
+		return
+	}
+	if player != 0 && g.isFogForPlayer(player, coordinate.PositionX, coordinate.PositionY) {
+		fmt.Fprintf(w, "(%d, %d) unexplored\n", coordinate.PositionX, coordinate.PositionY)
+		return
+	}
+	terrain := terrainToString(details.IsLand, details.Terrain)
+	fmt.Fprintf(w, "(%d, %d) %s\n", coordinate.PositionX, coordinate.PositionY, terrain)
+	if city := details.City; city != nil {
+		owner := "neutral"
+		if city.OccupyingPlayer != Unoccupied {
+			owner = fmt.Sprintf("player %d", city.OccupyingPlayer)
+		}
+		fmt.Fprintf(w, "  city, %s, strength %d", owner, city.Strength)
+		if city.OccupyingPlayer != Unoccupied && (player == 0 || int(city.OccupyingPlayer) == player) {
+			fmt.Fprintf(w, ", manufacturing %s, ready in %d days", g.unitTypeToString(city.ManufacturingUnit), city.DaysUntilUnitReady)
+		}
+		fmt.Fprintln(w)
+	}
+	for _, unit := range g.visibleUnitsAt(player, coordinate) {
+		fmt.Fprintf(w, "  %s %d, player %d, strength %d, moves left %d", g.unitTypeToString(unit.Type), unit.ID, unit.Player, unit.Strength, unit.MovesLeftThisDay)
+		if unit.Rank() > RankRecruit {
+			fmt.Fprintf(w, ", %s", rankToString(unit.Rank()))
+		}
+		if unit.CanFly {
+			fmt.Fprintf(w, ", fuel %d", unit.Fuel)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
 func (g *GameBoard) printCitiesForPlayer(playerID int) {
 	//fmt.Printf("Cities for Player %d:\n", playerID)
 	for _, city := range g.Cities {
 		if city.OccupyingPlayer == OccupiedByPlayer1 && playerID == 1 {
-			manufacturingUnit := unitTypeToString(city.ManufacturingUnit)
+			manufacturingUnit := g.unitTypeToString(city.ManufacturingUnit)
 			fmt.Printf("City at (%d, %d) is manufacturing: %s, DaysUntilUnitReady: %d\n", city.PositionX, city.PositionY, manufacturingUnit, city.DaysUntilUnitReady)
 		}
 		if city.OccupyingPlayer == OccupiedByPlayer2 && playerID == 2 {
-			manufacturingUnit := unitTypeToString(city.ManufacturingUnit)
+			manufacturingUnit := g.unitTypeToString(city.ManufacturingUnit)
 			fmt.Printf("City at (%d, %d) is manufacturing: %s, DaysUntilUnitReady: %d\n", city.PositionX, city.PositionY, manufacturingUnit, city.DaysUntilUnitReady)
 		}
 	}
 }
 
+// PlayGame plays days until a player wins, or until maxDays have passed if maxDays is positive.
+// A player whose controller fails forfeits. It returns the winning player, or 0 for a draw.
+func (g *GameBoard) PlayGame(maxDays int) int {
+	for maxDays <= 0 || g.Day < maxDays {
+		g.NextDay()
+		for player := 1; player <= 2; player++ {
+			opponent := 3 - player
+			if err := g.DoPlayerTurn(player); err != nil {
+				g.printf("player %d forfeits: %v\n", player, err)
+				return opponent
+			}
+			if g.hasPlayerWon(player) {
+				return player
+			}
+		}
+	}
+	return 0
+}
+
+// DoPlayerTurnAI runs the turn of the specified player with the AI, whoever controls the player.
 func (g *GameBoard) DoPlayerTurnAI(player int) {
-	var activeUnit *Unit
-	//showFogOfWar := true
-	//coordinate := Coordinate{}
+	g.doPlayerTurn(player, &AIController{})
+}
+
+// DoPlayerTurn runs the turn of the specified player, asking the player's
+// controller what each unit should do until no unit has moves left.
+func (g *GameBoard) DoPlayerTurn(player int) error {
+	return g.doPlayerTurn(player, g.getControllerForPlayer(player))
+}
+
+// doPlayerTurn runs the turn of the specified player with the given controller.
+func (g *GameBoard) doPlayerTurn(player int, controller Controller) error {
+	if err := controller.BeginTurn(g, player); err != nil {
+		return err
+	}
+	g.chooseIdleProduction(player, controller)
 	for {
-		activeUnit = g.getActiveUnitForPlayer(player)
+		activeUnit := g.getActiveUnitForPlayer(player)
 		if activeUnit == nil {
 			break // No more active units for the player
 		}
-		//coordinate = Coordinate{activeUnit.PositionX, activeUnit.PositionY}
-
-		g.runUnitAI(activeUnit)
+		if move, ok := g.getRallyMove(activeUnit); ok {
+			g.attemptMoveTo(move, activeUnit)
+			continue
+		}
+		move, ok, err := controller.ChooseMove(g, activeUnit)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			activeUnit.MovesLeftThisDay = 0 // the unit stays put for the rest of the day
+			continue
+		}
+		if !g.isLegalMove(move, activeUnit) {
+			// an illegal move uses up one of the unit's moves so the turn always ends
+			activeUnit.MovesLeftThisDay--
+			continue
+		}
+		g.attemptMoveTo(move, activeUnit)
 
 		if g.hasPlayerWon(player) {
-			fmt.Printf("\nDay: %d\n", g.Day)
-			fmt.Printf("\nPlayer %d has won\n", player)
+			g.printf("\nDay: %d\n", g.Day)
+			g.printf("\nPlayer %d has won\n", player)
 			break // the player has won
 		}
 	}
-	/*
-		islandMap := g.getIslandMap(coordinate)
-		isConquered := g.isIslandConquered(islandMap, player)
-		tankCount := g.getUnitCount(Tank, islandMap, player)
-		transportCount := g.getUnitCount(Transport, islandMap, player)
+	g.emit(EventTurnEnded, player, Blank, Coordinate{})
+	return nil
+}
 
-		fmt.Printf("\nDay %d, Player %d:, hasConqueredIsland:%t \n", g.Day, player, isConquered)
-		fmt.Printf("\nTanks:%d, Transports:%d:\n", tankCount, transportCount)
-		g.printCitiesForPlayer(player)
-		g.printGridWithUnits(showFogOfWar)
-		time.Sleep(20 * time.Millisecond)
-		//clearScreen()
-	*/
+// getControllerForPlayer returns the controller deciding the specified player's actions.
+// Players without a controller are run by the AI.
+func (g *GameBoard) getControllerForPlayer(player int) Controller {
+	p := g.Player1
+	if player == 2 {
+		p = g.Player2
+	}
+	if p == nil || p.Controller == nil {
+		return &AIController{}
+	}
+	return p.Controller
+}
+
+// isAdjacentMove checks if the coordinate is on the board and next to the unit.
+func (g *GameBoard) isAdjacentMove(coordinate Coordinate, unit *Unit) bool {
+	if coordinate.PositionX < 0 || coordinate.PositionX >= g.Rows || coordinate.PositionY < 0 || coordinate.PositionY >= g.Columns {
+		return false
+	}
+	dx := coordinate.PositionX - unit.PositionX
+	dy := coordinate.PositionY - unit.PositionY
+	return (dx != 0 || dy != 0) && dx >= -1 && dx <= 1 && dy >= -1 && dy <= 1
+}
+
+// isLegalMove checks if the unit can move to, or attack, the coordinate next to it, fire on an
+// enemy unit in range, or be dropped there by a bomber.
+func (g *GameBoard) isLegalMove(coordinate Coordinate, unit *Unit) bool {
+	if g.isRangedAttack(coordinate, unit) || g.isParadrop(coordinate, unit) {
+		return true
+	}
+	return g.isAdjacentMove(coordinate, unit) && g.determineAction(coordinate, unit) != ActionIllegalMove
+}
+
+// getLegalMoves returns every cell next to the unit it can move to or attack.
+func (g *GameBoard) getLegalMoves(unit *Unit) []Coordinate {
+	var moves []Coordinate
+	for i := unit.PositionX - 1; i <= unit.PositionX+1; i++ {
+		for j := unit.PositionY - 1; j <= unit.PositionY+1; j++ {
+			move := Coordinate{i, j}
+			if g.isLegalMove(move, unit) {
+				moves = append(moves, move)
+			}
+		}
+	}
+	return moves
 }
 
 // getActiveUnitForPlayer returns an active unit for the specified player with MovesLeftThisDay > 0.
@@ -315,15 +681,6 @@ func (g *GameBoard) getActiveUnitForPlayer(player int) *Unit {
 	return nil
 }
 
-// runUnitAI implements the AI logic for the a unit.
-func (g *GameBoard) runUnitAI(unit *Unit) {
-	possibleMoves := g.getPossibleMoves(unit)
-	if len(possibleMoves) > 0 {
-		move := possibleMoves[rand.Intn(len(possibleMoves))]
-		g.attemptMoveTo(move, unit)
-	}
-}
-
 /*
 // getPossibleMoves returns possible moves for the given unit.
 //
@@ -404,6 +761,9 @@ func (g *GameBoard) getPossibleMoves(unit *Unit) []Coordinate {
 // getPossibleMoves returns possible moves for the given unit.
 func (g *GameBoard) getPossibleMoves(unit *Unit) []Coordinate {
 	var moves []Coordinate
+	if retreat, ok := g.getVeteranRetreatMoves(unit); ok {
+		return retreat
+	}
 
 	enemyUnits := g.getEnemyUnitsCoordinates(unit)
 	enemyCities := g.getEnemyCitiesCoordinates(unit)
@@ -411,8 +771,17 @@ func (g *GameBoard) getPossibleMoves(unit *Unit) []Coordinate {
 	fogOfWar := g.getFogOfWarCoordinates(unit)
 	stagingPoint := g.getStagingPoint(unit)
 	randomMoves := g.getRandomMoves(unit)
-
-	if len(fogOfWar) > 0 {
+	rangedTargets := g.getRangedTargets(unit)
+	dropTargets := g.getParadropTargets(unit)
+	bombingRun, isBombingRun := g.getBombingRunMove(unit)
+
+	if len(rangedTargets) > 0 {
+		moves = append(moves, rangedTargets[0])
+	} else if len(dropTargets) > 0 {
+		moves = append(moves, dropTargets[0])
+	} else if isBombingRun {
+		moves = append(moves, bombingRun)
+	} else if len(fogOfWar) > 0 {
 		moves = append(moves, fogOfWar[0])
 	} else if len(enemyUnits) > 0 {
 		moves = append(moves, enemyUnits[0])
@@ -583,9 +952,9 @@ func (g *GameBoard) getRandomMoves(unit *Unit) []Coordinate {
 			if newRow >= 0 && newRow < g.Rows && newCol >= 0 && newCol < g.Columns {
 				if unit.CanFly {
 					moves = append(moves, Coordinate{newRow, newCol})
-				} else if g.Grid[newRow][newCol].IsLand && unit.CanMoveOnLand {
+				} else if g.Grid[newRow][newCol].IsLand && unit.CanMoveOnLand && g.canEnterTerrain(Coordinate{newRow, newCol}, unit) {
 					moves = append(moves, Coordinate{newRow, newCol})
-				} else if !g.Grid[newRow][newCol].IsLand && unit.CanMoveOnWater {
+				} else if !g.Grid[newRow][newCol].IsLand && unit.CanMoveOnWater && g.canEnterTerrain(Coordinate{newRow, newCol}, unit) {
 					moves = append(moves, Coordinate{newRow, newCol})
 				}
 			}
@@ -637,19 +1006,41 @@ const (
 	ActionCityAttack
 	// ActionIllegalMove represents an illegal move action
 	ActionIllegalMove
+	// ActionRangedAttack represents an attack on a unit beyond the next cell
+	ActionRangedAttack
+	// ActionParadrop represents a bomber dropping a unit beyond the next cell
+	ActionParadrop
+	// ActionBombingRun represents a bomber bombing everything round its target
+	ActionBombingRun
 )
 
 // determineAction determines the action to be performed based on the destination coordinate and unit's properties
 func (g *GameBoard) determineAction(destinationCoordinate Coordinate, unit *Unit) ActionType {
+	if !g.isAdjacentMove(destinationCoordinate, unit) {
+		if g.isRangedAttack(destinationCoordinate, unit) {
+			return ActionRangedAttack
+		}
+		if g.isParadrop(destinationCoordinate, unit) {
+			return ActionParadrop
+		}
+	}
+	if g.isWorthBombing(destinationCoordinate, unit) {
+		return ActionBombingRun
+	}
 	defender := g.getUnitAtCoordinates(destinationCoordinate, unit.Player)
 	if defender != nil {
 		return ActionUnitAttack
 	} else if g.Grid[destinationCoordinate.PositionX][destinationCoordinate.PositionY].HasCity && unit.CanMoveOnLand {
+		if city := g.getCityAtCoordinates(destinationCoordinate); city != nil && int(city.OccupyingPlayer) == unit.Player {
+			return ActionMove // units garrison their own cities
+		}
 		return ActionCityAttack
 	} else if unit.CanFly {
 		return ActionMove
 		//} else if g.getCityAtCoordinates(destinationCoordinate) !=nil { // any unit can move into a city by water
 		//	return ActionMove
+	} else if !g.canEnterTerrain(destinationCoordinate, unit) {
+		return ActionIllegalMove
 	} else if g.Grid[destinationCoordinate.PositionX][destinationCoordinate.PositionY].IsLand && unit.CanCaptureCity {
 		return ActionMove
 	} else if !g.Grid[destinationCoordinate.PositionX][destinationCoordinate.PositionY].IsLand && unit.CanMoveOnWater {
@@ -663,14 +1054,29 @@ func (g *GameBoard) performAction(actionType ActionType, destinationCoordinate C
 	switch actionType {
 	case ActionMove:
 		unit.MoveTo(destinationCoordinate)
+		// rough terrain takes the moves it costs beyond the first, or all the moves left
+		unit.MovesLeftThisDay = maxInt(0, unit.MovesLeftThisDay-(g.getMoveCost(destinationCoordinate, unit)-1))
 	case ActionUnitAttack:
+		defender := g.getGarrisonDefender(destinationCoordinate, unit.Player)
+		if defender == nil {
+			defender = g.getUnitAtCoordinates(destinationCoordinate, unit.Player)
+		}
+		g.resolveUnitAttack(unit, defender, g.getCombatOutcome(unit, destinationCoordinate))
+		g.spendSingleUseUnit(unit)
+	case ActionRangedAttack:
 		defender := g.getUnitAtCoordinates(destinationCoordinate, unit.Player)
-		g.resolveUnitAttack(unit, defender, g.getAttackOutcome())
+		g.resolveRangedAttack(unit, defender, g.getCombatOutcome(unit, destinationCoordinate))
+		g.spendSingleUseUnit(unit)
+	case ActionParadrop:
+		g.paradrop(destinationCoordinate, unit)
+	case ActionBombingRun:
+		g.resolveBombingRun(unit, destinationCoordinate)
 	case ActionCityAttack:
 		defender := g.getCityAtCoordinates(destinationCoordinate)
-		g.resolveCityAttack(unit, defender, g.getAttackOutcome())
+		g.resolveCityAttack(unit, defender, g.getCombatOutcome(unit, destinationCoordinate))
+		g.spendSingleUseUnit(unit)
 	case ActionIllegalMove:
-		fmt.Println("Illegal move!")
+		g.printf("Illegal move!\n")
 	}
 }
 
@@ -679,14 +1085,14 @@ func (g *GameBoard) attemptMoveTo(destinationCoordinate Coordinate, unit *Unit)
 	//fmt.Printf("unit at %d, %d, AttemptMoveTo() %d, %d\n", unit.PositionX, unit.PositionY, destinationCoordinate.PositionX, destinationCoordinate.PositionY)
 	radius := 1
 	g.clearFogOfWarAroundCoordinate(destinationCoordinate, radius)
+	g.clearFogOfWarForPlayer(unit.Player, destinationCoordinate, radius)
 	actionType := g.determineAction(destinationCoordinate, unit)
 	g.performAction(actionType, destinationCoordinate, unit)
 }
 
 // getAttackOutcome decides an attack outcome based on chance
 func (g *GameBoard) getAttackOutcome() bool {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	return r.Intn(2) == 0 // 50% probability.
+	return g.random().Intn(2) == 0 // 50% probability.
 }
 
 // clearFogOfWarAroundCoordinate clears the fog of war around the specified coordinates within a given radius.
@@ -721,59 +1127,61 @@ func (g *GameBoard) getCityAtCoordinates(coordinate Coordinate) *City {
 }
 
 // resolveCityAttack determines the outcome of an attack between an attacking unit and a defending city.
+// The units garrisoning the city defend it first. Once they are gone, each successful attack takes
+// a point of the city's strength, and a city left without strength falls to the next land unit
+// able to capture it which enters it, rather than to an aircraft or a ship.
 func (g *GameBoard) resolveCityAttack(attacker *Unit, defender *City, attackOutcome bool) {
-	fmt.Printf("resolveCityAttack defender %d, %d\n", defender.PositionX, defender.PositionY)
+	coordinate := Coordinate{defender.PositionX, defender.PositionY}
+	if garrison := g.getGarrisonDefender(coordinate, attacker.Player); garrison != nil {
+		g.resolveUnitAttack(attacker, garrison, attackOutcome)
+		return
+	}
+	g.printf("resolveCityAttack defender %d, %d\n", defender.PositionX, defender.PositionY)
 	attacker.MovesLeftThisDay--
 	if attacker.CanFly {
 		attacker.Fuel--
 	}
-	//if attackOutcome && attacker.Strength >= defender.Strength {
-	if attackOutcome {
-		// Apply damage to the defender's strength
-		defender.Strength--
-		// Check if the defender is destroyed
-		if defender.Strength <= 0 {
-			// Defender is conquered, change OccupyingPlayer
-			fmt.Println("Defender [City] is conquered")
-			if attacker.Player == 1 {
-				defender.OccupyingPlayer = OccupiedByPlayer1
-			} else {
-				defender.OccupyingPlayer = OccupiedByPlayer2
+	if defender.Strength > 0 {
+		if !attackOutcome {
+			// Apply damage to the attacker's strength
+			attacker.Strength--
+			// Check if the attacker is destroyed
+			if attacker.Strength <= 0 {
+				// Attacker is destroyed, remove it from the game board
+				g.printf("Attacker is destroyed\n")
+				g.emit(EventUnitDestroyed, attacker.Player, attacker.Type, Coordinate{attacker.PositionX, attacker.PositionY})
+				g.removeUnit(attacker)
 			}
-			defender.Strength = NewCityStrength
-			//defender.ManufacturingUnit = Blank // TODO: if player is computer, decide what to manufacture
-			defender.ManufacturingUnit = g.getWhichUnitToManufactureNextAI(Coordinate{defender.PositionX, defender.PositionY}, attacker.Player, defender.IsCityNextToSea)
-			defender.DaysUntilUnitReady = GetDaysToProduceUnit(defender.ManufacturingUnit)
-			// Attacker is destroyed when it conquers a city
-			g.removeUnit(attacker)
-		}
-	} else {
-		// Apply damage to the attacker's strength
-		attacker.Strength--
-		// Check if the attacker is destroyed
-		if attacker.Strength <= 0 {
-			// Attacker is destroyed, remove it from the game board
-			fmt.Println("Attacker is destroyed")
-			g.removeUnit(attacker)
+			return
 		}
+		// Apply damage to the defender's strength
+		defender.Strength--
+	}
+	if defender.Strength <= 0 && attacker.CanCaptureCity {
+		g.captureCity(attacker, defender)
 	}
 }
 
 // resolveUnitAttack determines the outcome of an attack between an attacking unit and a defending unit.
 func (g *GameBoard) resolveUnitAttack(attacker, defender *Unit, attackOutcome bool) {
-	fmt.Printf("resolveUnitAttack defender %d, %d\n", defender.PositionX, defender.PositionY)
+	g.printf("resolveUnitAttack defender %d, %d\n", defender.PositionX, defender.PositionY)
 	attacker.MovesLeftThisDay--
 	if attacker.CanFly {
 		attacker.Fuel--
 	}
-	if attackOutcome && attacker.Strength >= defender.Strength {
+	if attackOutcome && (attacker.Strength >= defender.Strength || g.rules().Combat == CombatStrength) {
 		// Apply damage to the defender's strength
-		defender.Strength--
+		defender.Strength -= g.getAttackDamage(attacker)
+		// the survivors gain experience before a destroyed unit is removed, which moves the others
+		g.recordBattle(attacker, defender.Strength <= 0)
 		// Check if the defender is destroyed
 		if defender.Strength <= 0 {
 			// Defender is destroyed, remove it from the game board
-			fmt.Println("Defender is destroyed")
+			g.printf("Defender is destroyed\n")
+			g.emit(EventUnitDestroyed, defender.Player, defender.Type, Coordinate{defender.PositionX, defender.PositionY})
 			g.removeUnit(defender)
+		} else {
+			g.recordBattle(defender, false)
 		}
 		// attacker does not move to defenders coordinates
 		//attacker.PositionX = defender.PositionX
@@ -781,29 +1189,51 @@ func (g *GameBoard) resolveUnitAttack(attacker, defender *Unit, attackOutcome bo
 	} else {
 		// Apply damage to the attacker's strength
 		attacker.Strength--
+		g.recordBattle(defender, attacker.Strength <= 0)
 		// Check if the attacker is destroyed
 		if attacker.Strength <= 0 {
 			// Attacker is destroyed, remove it from the game board
-			fmt.Println("Attacker is destroyed")
+			g.printf("Attacker is destroyed\n")
+			g.emit(EventUnitDestroyed, attacker.Player, attacker.Type, Coordinate{attacker.PositionX, attacker.PositionY})
 			g.removeUnit(attacker)
+		} else {
+			g.recordBattle(attacker, false)
 		}
 	}
 }
 
-// removeUnit removes a unit from the game board's Units slice.
+// removeUnit removes the unit with the ID of the given unit from the game board's Units slice.
 func (g *GameBoard) removeUnit(unitToRemove *Unit) {
 	var updatedUnits []Unit
 	for _, unit := range g.Units {
-		if unit != *unitToRemove {
+		if unit.ID != unitToRemove.ID {
 			updatedUnits = append(updatedUnits, unit)
 		}
 	}
 	g.Units = updatedUnits
 }
 
+// damageUnits takes a point of strength from each unit with one of the IDs, reporting it with the
+// message, and removes the units left with none. IDs of units no longer on the board are skipped.
+func (g *GameBoard) damageUnits(ids []int, message string) {
+	for _, id := range ids {
+		// look each unit up again, as destroyed units are removed from the board
+		unit := g.getUnitByID(id)
+		if unit == nil {
+			continue
+		}
+		unit.Strength--
+		g.printf("%s %d %s\n", g.unitTypeToString(unit.Type), unit.ID, message)
+		if unit.Strength <= 0 {
+			g.emit(EventUnitDestroyed, unit.Player, unit.Type, Coordinate{unit.PositionX, unit.PositionY})
+			g.removeUnit(unit)
+		}
+	}
+}
+
 // getIslandMap returns a slice of coordinates representing the island connected to the given coordinate.
 func (g *GameBoard) getIslandMap(coordinate Coordinate) []Coordinate {
-	visited := make(map[Coordinate]bool)
+	visited := g.newVisitedGrid()
 	islandMap := make([]Coordinate, 0)
 
 	// Define a recursive flood fill function to explore land cells
@@ -812,7 +1242,7 @@ func (g *GameBoard) getIslandMap(coordinate Coordinate) []Coordinate {
 		// Check if the cell is within the grid boundaries and is a land cell
 		if x >= 0 && x < g.Rows && y >= 0 && y < g.Columns && g.Grid[x][y].IsLand {
 			// Mark the cell as visited
-			visited[Coordinate{PositionX: x, PositionY: y}] = true
+			visited[x][y] = true
 			// Add the coordinate to the island map
 			islandMap = append(islandMap, Coordinate{PositionX: x, PositionY: y})
 
@@ -821,8 +1251,7 @@ func (g *GameBoard) getIslandMap(coordinate Coordinate) []Coordinate {
 				for j := -1; j <= 1; j++ {
 					if i != 0 || j != 0 {
 						neighborX, neighborY := x+i, y+j
-						neighborCoord := Coordinate{PositionX: neighborX, PositionY: neighborY}
-						if !visited[neighborCoord] {
+						if neighborX >= 0 && neighborX < g.Rows && neighborY >= 0 && neighborY < g.Columns && !visited[neighborX][neighborY] {
 							floodFill(neighborX, neighborY) // Recur for neighboring cell
 						}
 					}
@@ -837,6 +1266,15 @@ func (g *GameBoard) getIslandMap(coordinate Coordinate) []Coordinate {
 	return islandMap
 }
 
+// newVisitedGrid returns a grid of flags, one per cell, for searches to mark the cells they have visited.
+func (g *GameBoard) newVisitedGrid() [][]bool {
+	visited := make([][]bool, g.Rows)
+	for i := range visited {
+		visited[i] = make([]bool, g.Columns)
+	}
+	return visited
+}
+
 // isIslandConquered checks if all cities on the island represented by coordinates are occupied by the same player.
 func (g *GameBoard) isIslandConquered(islandMap []Coordinate, playerID int) bool {
 	for _, coord := range islandMap {
@@ -873,7 +1311,8 @@ func (g *GameBoard) getIsIslandFogOfWar(islandMap []Coordinate) *Coordinate {
 	for _, coord := range islandMap {
 		if coord.PositionX >= 0 && coord.PositionX < g.Rows &&
 			coord.PositionY >= 0 && coord.PositionY < g.Columns &&
-			g.Grid[coord.PositionX][coord.PositionY].IsFog {
+			g.Grid[coord.PositionX][coord.PositionY].IsFog &&
+			(g.Grid[coord.PositionX][coord.PositionY].Terrain != TerrainMountains || g.Grid[coord.PositionX][coord.PositionY].HasCity) {
 			return &Coordinate{PositionX: coord.PositionX, PositionY: coord.PositionY}
 		}
 	}
@@ -891,26 +1330,44 @@ func (g *GameBoard) getIsIslandCityNextToSea(islandMap []Coordinate) *Coordinate
 	return nil // city next to sea not found on island
 }
 
-// FindPath finds a path for the unit to reach the target coordinate on the grid.
+// FindPath finds the cheapest path for the unit to reach the target coordinate on the grid,
+// going round terrain it cannot cross and counting the moves rough terrain costs.
 func (g *GameBoard) FindPath(target Coordinate, unit *Unit) []Coordinate {
 	// Define possible moves: up, down, left, right, ...
 	moves := []Coordinate{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {1, 1}, {0, 0}}
 
-	// Initialize visited map to keep track of visited cells
-	visited := make(map[Coordinate]bool)
-	visited[Coordinate{unit.PositionX, unit.PositionY}] = true
+	// cost[x][y] is the cheapest known cost of reaching a cell, -1 until it is reached;
+	// previous[x][y] is the cell it is reached from
+	start := Coordinate{unit.PositionX, unit.PositionY}
+	cost := make([][]int, g.Rows)
+	previous := make([][]Coordinate, g.Rows)
+	for i := range cost {
+		cost[i] = make([]int, g.Columns)
+		previous[i] = make([]Coordinate, g.Columns)
+		for j := range cost[i] {
+			cost[i][j] = -1
+		}
+	}
+	cost[start.PositionX][start.PositionY] = 0
 
-	// Initialize queue for BFS
-	queue := list.New()
-	queue.PushBack([]Coordinate{{unit.PositionX, unit.PositionY}})
+	queue := &pathQueue{}
+	heap.Push(queue, pathStep{coordinate: start})
+	order := 0
 
 	for queue.Len() > 0 {
-		// Dequeue the path from the queue
-		path := queue.Remove(queue.Front()).([]Coordinate)
-		lastPos := path[len(path)-1]
+		step := heap.Pop(queue).(pathStep)
+		lastPos := step.coordinate
+		if step.cost > cost[lastPos.PositionX][lastPos.PositionY] {
+			continue // a cheaper way to this cell was found after this one was queued
+		}
 
 		// If the last position is the target, return the path
 		if lastPos == target {
+			path := []Coordinate{lastPos}
+			for lastPos != start {
+				lastPos = previous[lastPos.PositionX][lastPos.PositionY]
+				path = append([]Coordinate{lastPos}, path...)
+			}
 			return path
 		}
 
@@ -919,21 +1376,16 @@ func (g *GameBoard) FindPath(target Coordinate, unit *Unit) []Coordinate {
 			newX, newY := lastPos.PositionX+move.PositionX, lastPos.PositionY+move.PositionY
 			newPos := Coordinate{newX, newY}
 
-			// Check if the new position is within the grid boundaries and not visited
-			if newX >= 0 && newX < g.Rows && newY >= 0 && newY < g.Columns &&
-				!visited[newPos] &&
-				(unit.CanFly ||
-					(unit.CanMoveOnLand && g.Grid[newX][newY].IsLand) ||
-					(unit.CanMoveOnWater && !g.Grid[newX][newY].IsLand)) {
-
-				// Mark the new position as visited
-				visited[newPos] = true
-
-				// Enqueue the new path
-				newPath := make([]Coordinate, len(path))
-				copy(newPath, path)
-				newPath = append(newPath, newPos)
-				queue.PushBack(newPath)
+			// Check if the new position is within the grid boundaries and the unit can cross it
+			if newX < 0 || newX >= g.Rows || newY < 0 || newY >= g.Columns || !g.canEnterTerrain(newPos, unit) {
+				continue
+			}
+			newCost := step.cost + g.getMoveCost(newPos, unit)
+			if cost[newX][newY] < 0 || newCost < cost[newX][newY] {
+				cost[newX][newY] = newCost
+				previous[newX][newY] = lastPos
+				order++
+				heap.Push(queue, pathStep{coordinate: newPos, cost: newCost, order: order})
 			}
 		}
 	}
@@ -943,55 +1395,35 @@ func (g *GameBoard) FindPath(target Coordinate, unit *Unit) []Coordinate {
 }
 
 // getWhichUnitToManufactureNextAI determine which unit type a city should manufacture next AI
-func (g *GameBoard) getWhichUnitToManufactureNextAI(coordinate Coordinate, player int, isCityNextToSea bool) UnitType {
+func (g *GameBoard) getWhichUnitToManufactureNextAI(coordinate Coordinate, player int, isCityNextToSea bool, variant *AIVariant) UnitType {
+	if variant == nil {
+		variant = DefaultAIVariant
+	}
 	islandMap := g.getIslandMap(coordinate)
 	isConquered := g.isIslandConquered(islandMap, player)
 	tankCount := g.getUnitCount(Tank, islandMap, player)
 	var weights []unitWeight
 	switch {
 	case isConquered && isCityNextToSea:
-		weights = []unitWeight{
-			{Tank, 1},
-			{Fighter, 1},
-			{Bomber, 1},
-			{Transport, 1},
-			{Destroyer, 2},
-			{Submarine, 2},
-			{Carrier, 2},
-			{Battleship, 3},
-		}
+		weights = variant.ConqueredCoastal
 	case isConquered && !isCityNextToSea && tankCount >= 10:
-		weights = []unitWeight{
-			{Tank, 1},
-			{Fighter, 1},
-			{Bomber, 2},
-		}
+		weights = variant.ConqueredInlandManyTanks
 	case isConquered && !isCityNextToSea && tankCount < 10:
-		weights = []unitWeight{
-			{Tank, 5},
-			{Fighter, 1},
-			{Bomber, 1},
-		}
+		weights = variant.ConqueredInland
 	case !isConquered && isCityNextToSea && tankCount >= 10:
-		weights = []unitWeight{
-			{Tank, 1},
-			{Fighter, 2},
-			{Destroyer, 3},
-		}
+		weights = variant.CoastalManyTanks
 	case !isConquered && isCityNextToSea && tankCount < 10:
-		weights = []unitWeight{
-			{Tank, 3},
-			{Fighter, 3},
-			{Destroyer, 3},
-		}
+		weights = variant.Coastal
 	default:
-		weights = []unitWeight{
-			{Tank, 7},
-			{Fighter, 3},
+		weights = variant.Inland
+	}
+	if unitType, ok := g.getSpecialistUnitAI(coordinate, player, isCityNextToSea); ok {
+		if weight := variant.getSpecialistWeight(unitType); weight > 0 {
+			weights = append(append([]unitWeight(nil), weights...), unitWeight{unitType, weight})
 		}
 	}
 
-	return getRandomUnit(weights)
+	return getRandomUnit(g.random(), weights)
 }
 
 // getUnitCount return a count of units of a given type within a islandMap for a player
@@ -1008,12 +1440,15 @@ func (g *GameBoard) getUnitCount(unitType UnitType, islandMap []Coordinate, play
 }
 
 // getRandomUnit calculates the total weight and selects a unit type based on these weights
-func getRandomUnit(weights []unitWeight) UnitType {
+func getRandomUnit(r *rand.Rand, weights []unitWeight) UnitType {
 	totalWeight := 0
 	for _, w := range weights {
 		totalWeight += w.weight
 	}
-	randomNum := rand.Intn(totalWeight) + 1
+	if totalWeight == 0 {
+		return Tank
+	}
+	randomNum := r.Intn(totalWeight) + 1
 	currentWeight := 0
 	for _, w := range weights {
 		currentWeight += w.weight
@@ -1024,16 +1459,14 @@ func getRandomUnit(weights []unitWeight) UnitType {
 	return Tank // Default to Tank if weights are not configured correctly
 }
 
-// hasPlayerWon checks if the specified player has won the game.
+// hasPlayerWon checks if the specified player has won the game, by the victory condition of the
+// rules in play.
 func (g *GameBoard) hasPlayerWon(playerID int) bool {
-	// The game is won when one player controls all of the opponent's cities.
-	// Check if any city is occupied by a different player
-	for _, city := range g.Cities {
-		if city.OccupyingPlayer == OccupiedByPlayer1 && playerID != 1 {
-			return false // Player has not won if any city is occupied by another player
-		} else if city.OccupyingPlayer == OccupiedByPlayer2 && playerID != 2 {
-			return false // Player has not won if any city is occupied by another player
-		}
+	switch g.rules().Victory {
+	case VictoryAnnihilation:
+		return g.hasPlayerConquered(playerID) && !g.hasOpponentUnits(playerID)
+	case VictoryMajority:
+		return g.hasPlayerConquered(playerID) || g.hasMajorityOfCities(playerID)
 	}
-	return true
+	return g.hasPlayerConquered(playerID)
 }
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -40,11 +42,11 @@ func TestNextDay(t *testing.T) {
 	gameBoard := NewGameBoard(rows, columns)
 	city := NewCity(1, 1)
 	city.OccupyCity(1)
-	city.SetManufacturingUnit(Tank)
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
 	gameBoard.Cities = append(gameBoard.Cities, *city)
 	city = NewCity(2, 2)
 	city.OccupyCity(2)
-	city.SetManufacturingUnit(Fighter)
+	city.SetManufacturingUnit(defaultRules.unitRules, Fighter)
 	gameBoard.Cities = append(gameBoard.Cities, *city)
 
 	gameBoard.NextDay()
@@ -233,24 +235,24 @@ func TestIsCityNextToSea(t *testing.T) {
 }
 
 func TestRemoveUnit(t *testing.T) {
-	// Create a GameBoard with some initial units
+	// Create a GameBoard with some initial units, two of them alike but for their IDs
 	initialUnits := []Unit{
-		{PositionX: 1, PositionY: 1},
-		{PositionX: 2, PositionY: 2},
-		{PositionX: 3, PositionY: 3},
+		{ID: 1, PositionX: 1, PositionY: 1},
+		{ID: 2, PositionX: 2, PositionY: 2},
+		{ID: 3, PositionX: 2, PositionY: 2},
 	}
 	gameBoard := &GameBoard{Units: initialUnits}
 
-	// Define the unit to be removed
-	unitToRemove := &Unit{PositionX: 2, PositionY: 2}
+	// Define the unit to be removed, out of date since it moved
+	unitToRemove := &Unit{ID: 2, PositionX: 1, PositionY: 2}
 
 	// Call the removeUnit function
 	gameBoard.removeUnit(unitToRemove)
 
 	// Define the expected units after removal
 	expectedUnits := []Unit{
-		{PositionX: 1, PositionY: 1},
-		{PositionX: 3, PositionY: 3},
+		{ID: 1, PositionX: 1, PositionY: 1},
+		{ID: 3, PositionX: 2, PositionY: 2},
 	}
 
 	// Check if the game board's Units slice matches the expected units
@@ -259,6 +261,19 @@ func TestRemoveUnit(t *testing.T) {
 	}
 }
 
+func TestDamageUnits(t *testing.T) {
+	board := newLandBoard(2, 2)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(1, 1, Tank, 2))
+	board.Units[0].Strength = 1
+
+	// the first tank is destroyed before its ID comes up again, and no unit has ID 9
+	board.damageUnits([]int{1, 2, 1, 9}, "is hit")
+	if len(board.Units) != 1 || board.Units[0].ID != 2 || board.Units[0].Strength != GetNewUnitStrength(Tank)-1 {
+		t.Errorf("damageUnits() left %+v; want only tank 2, a point weaker", board.Units)
+	}
+}
+
 /*
 // Test case 1: There are enemy units, move towards the first enemy unit
 func TestGetPossibleMovesTestCase1(t *testing.T) {
@@ -636,3 +651,129 @@ func TestHasPlayerWon(t *testing.T) {
 		t.Errorf("Test case 2 failed: Player 2 should not have won")
 	}
 }
+
+func TestPrintToSliceForPlayer(t *testing.T) {
+	board := newLandBoard(2, 3)
+	neutral := NewCity(0, 0)
+	board.Grid[0][0].HasCity = true
+	board.Cities = append(board.Cities, *neutral)
+	board.clearFogOfWarForPlayer(1, Coordinate{0, 0}, 2)
+	board.clearFogOfWarForPlayer(2, Coordinate{0, 0}, 2)
+
+	type test struct {
+		name   string
+		player int
+		want   [][]string
+	}
+	tests := []test{
+		{name: "player 1", player: 1, want: [][]string{{"C", "L", "O"}, {"L", "L", "E"}}},
+		{name: "player 2", player: 2, want: [][]string{{"C", "L", "E"}, {"L", "L", "O"}}},
+		{name: "no player", player: 0, want: [][]string{{"C", "L", "1"}, {"L", "L", "2"}}},
+	}
+	for _, tc := range tests {
+		got := board.printToSliceForPlayer(tc.player, true)
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("printToSliceForPlayer(), name:%s, got %v; want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFprintGridWithUnits(t *testing.T) {
+	board := newLandBoard(2, 3)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(0, 1, Tank, 2))
+	board.addUnit(NewUnit(1, 0, Tank, 1))
+	board.addUnit(NewUnit(1, 0, Fighter, 1))
+	board.Units[1].Kills = veteranKills
+	board.updateFogOfWarForPlayer(2)
+
+	var buf bytes.Buffer
+	board.fprintGridWithUnits(&buf, 2, true)
+	want := "tTE\n*LO\nTank 2 at (0, 1), player 2, veteran\n"
+	if buf.String() != want {
+		t.Errorf("fprintGridWithUnits() = %q; want %q", buf.String(), want)
+	}
+}
+
+func TestFprintGridWithUnitsPlayerFog(t *testing.T) {
+	board := newLandBoard(3, 6)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(2, 3, Tank, 1)) // explored by player 2, but out of their sight
+	board.addUnit(NewUnit(2, 0, Tank, 2))
+	board.updateFogOfWarForPlayer(1)
+	board.updateFogOfWarForPlayer(2)
+	board.clearFogOfWarForPlayer(2, Coordinate{2, 3}, 0)
+
+	type test struct {
+		name   string
+		player int
+		want   string
+	}
+	tests := []test{
+		{name: "player 1", player: 1, want: "TL??LO\nLLLLLL\n??LTL?\n"},
+		{name: "player 2", player: 2, want: "??????\nLL??LL\nTL?LLO\n"},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		board.fprintGridWithUnits(&buf, tc.player, true)
+		if buf.String() != tc.want {
+			t.Errorf("fprintGridWithUnits(), name:%s, got %q; want %q", tc.name, buf.String(), tc.want)
+		}
+	}
+
+	// the details of a cell explored by the player list no enemy units out of their sight
+	var buf bytes.Buffer
+	board.fprintCellDetails(&buf, Coordinate{2, 3}, 2)
+	if strings.Contains(buf.String(), "Tank") {
+		t.Errorf("fprintCellDetails() = %q; want no enemy unit out of sight", buf.String())
+	}
+}
+
+func TestFprintCellDetails(t *testing.T) {
+	board := newLandBoard(2, 3)
+	board.Cities[1].SetManufacturingUnit(defaultRules.unitRules, Fighter)
+	board.addUnit(NewUnit(1, 2, Tank, 2))
+	board.addUnit(NewUnit(1, 2, Fighter, 2))
+	board.Units[1].Battles = eliteBattles
+	board.Grid[0][0].IsFog = true
+	board.updateFogOfWarForPlayer(1)
+	board.updateFogOfWarForPlayer(2)
+
+	details, ok := board.GetCellDetails(Coordinate{1, 2})
+	if !ok || details.City == nil || len(details.Units) != 2 {
+		t.Fatalf("GetCellDetails() = %+v, %t; want a city and 2 units", details, ok)
+	}
+	if _, ok := board.GetCellDetails(Coordinate{2, 0}); ok {
+		t.Errorf("GetCellDetails() off the board got ok")
+	}
+
+	type test struct {
+		name       string
+		coordinate Coordinate
+		player     int
+		want       []string
+		notWant    []string
+	}
+	tests := []test{
+		{name: "own city and stack", coordinate: Coordinate{1, 2}, player: 2,
+			want: []string{"city, player 2", "manufacturing Fighter", "Tank 1, player 2", "Fighter 2, player 2", "elite", "fuel"}},
+		{name: "enemy city", coordinate: Coordinate{1, 2}, player: 1,
+			want: []string{"city, player 2", "Tank 1"}, notWant: []string{"manufacturing"}},
+		{name: "fog", coordinate: Coordinate{0, 0}, player: 1, want: []string{"unexplored"}},
+		{name: "off the board", coordinate: Coordinate{5, 5}, player: 1, want: []string{"off the board"}},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		board.fprintCellDetails(&buf, tc.coordinate, tc.player)
+		for _, want := range tc.want {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("fprintCellDetails(), name:%s, got %q; want it to contain %q", tc.name, buf.String(), want)
+			}
+		}
+		for _, notWant := range tc.notWant {
+			if strings.Contains(buf.String(), notWant) {
+				t.Errorf("fprintCellDetails(), name:%s, got %q; want it not to contain %q", tc.name, buf.String(), notWant)
+			}
+		}
+	}
+}
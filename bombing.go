@@ -0,0 +1,122 @@
+package main
+
+// bombingCityValue is what the AI reckons hitting an enemy city is worth, in days of production,
+// on top of the production the hit throws away.
+const bombingCityValue = 10
+
+// isBombingRun checks if the unit makes a bombing run by moving to the coordinate: it has a blast
+// radius, and an enemy unit or a city its player does not hold is there.
+func (g *GameBoard) isBombingRun(coordinate Coordinate, unit *Unit) bool {
+	if g.unitDefinitionOf(unit.Type).BlastRadius <= 0 {
+		return false
+	}
+	if g.getUnitAtCoordinates(coordinate, unit.Player) != nil {
+		return true
+	}
+	city := g.getCityAtCoordinates(coordinate)
+	return city != nil && int(city.OccupyingPlayer) != unit.Player
+}
+
+// isWorthBombing checks if moving the unit to the coordinate makes a bombing run, which is
+// only when the run is worth more to its player than the unit it spends. Any other move onto
+// an enemy is an ordinary attack.
+func (g *GameBoard) isWorthBombing(coordinate Coordinate, unit *Unit) bool {
+	return g.isBombingRun(coordinate, unit) && g.getBombingValue(coordinate, unit) > g.unitDefinitionOf(unit.Type).DaysToProduce
+}
+
+// getBlastArea returns the cells on the board within the unit's blast radius of the target.
+func (g *GameBoard) getBlastArea(target Coordinate, unit *Unit) []Coordinate {
+	radius := g.unitDefinitionOf(unit.Type).BlastRadius
+	var area []Coordinate
+	for i := target.PositionX - radius; i <= target.PositionX+radius; i++ {
+		for j := target.PositionY - radius; j <= target.PositionY+radius; j++ {
+			if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns {
+				area = append(area, Coordinate{i, j})
+			}
+		}
+	}
+	return area
+}
+
+// resolveBombingRun bombs the target: every enemy unit within the blast loses a point of strength,
+// and every city in it which the bomber's player does not hold loses a point of strength, down
+// to 1, and starts its production again. The bomber is spent.
+func (g *GameBoard) resolveBombingRun(bomber *Unit, target Coordinate) {
+	g.printf("resolveBombingRun target %d, %d\n", target.PositionX, target.PositionY)
+	var hit []int // IDs of the enemy units in the blast
+	for _, coordinate := range g.getBlastArea(target, bomber) {
+		for _, unit := range g.Units {
+			if unit.Player != bomber.Player && unit.PositionX == coordinate.PositionX && unit.PositionY == coordinate.PositionY {
+				hit = append(hit, unit.ID)
+			}
+		}
+		if city := g.getCityAtCoordinates(coordinate); city != nil && int(city.OccupyingPlayer) != bomber.Player {
+			city.Strength = maxInt(1, city.Strength-1)
+			if city.ManufacturingUnit != Blank {
+				city.DaysUntilUnitReady = city.GetDaysToProduce(g.unitRules(), city.ManufacturingUnit)
+			}
+		}
+	}
+	g.damageUnits(hit, "is bombed")
+	if spent := g.getUnitByID(bomber.ID); spent != nil {
+		g.printf("%s %d is spent\n", g.unitTypeToString(spent.Type), spent.ID)
+		g.removeUnit(spent)
+	}
+}
+
+// getBombingValue returns what a bombing run by the unit on the target is worth to the AI, in
+// days of the enemy's production lost: the enemy units in the blast which the player can see,
+// and the enemy cities in it with the production they would throw away.
+func (g *GameBoard) getBombingValue(target Coordinate, unit *Unit) int {
+	value := 0
+	for _, coordinate := range g.getBlastArea(target, unit) {
+		for _, enemy := range g.visibleUnitsAt(unit.Player, coordinate) {
+			if enemy.Player != unit.Player {
+				value += g.unitDefinitionOf(enemy.Type).DaysToProduce
+			}
+		}
+		city := g.getCityAtCoordinates(coordinate)
+		if city != nil && city.OccupyingPlayer != Unoccupied && int(city.OccupyingPlayer) != unit.Player {
+			value += bombingCityValue
+			if city.ManufacturingUnit != Blank {
+				value += city.GetDaysToProduce(g.unitRules(), city.ManufacturingUnit) - city.DaysUntilUnitReady
+			}
+		}
+	}
+	return value
+}
+
+// getBombingRunMove returns the AI's next move towards the most valuable bombing run the unit can
+// reach on the fuel it has left, only taking a target worth more than the unit itself. It returns
+// false if there is no such target.
+func (g *GameBoard) getBombingRunMove(unit *Unit) (Coordinate, bool) {
+	if g.unitDefinitionOf(unit.Type).BlastRadius <= 0 {
+		return Coordinate{}, false
+	}
+	reach := unit.Fuel
+	var best Coordinate
+	bestValue := g.unitDefinitionOf(unit.Type).DaysToProduce
+	found := false
+	for i := maxInt(0, unit.PositionX-reach); i <= unit.PositionX+reach && i < g.Rows; i++ {
+		for j := maxInt(0, unit.PositionY-reach); j <= unit.PositionY+reach && j < g.Columns; j++ {
+			target := Coordinate{i, j}
+			if !g.isBombingRun(target, unit) || g.isFogForPlayer(unit.Player, i, j) {
+				continue
+			}
+			if value := g.getBombingValue(target, unit); value > bestValue {
+				best, bestValue, found = target, value, true
+			}
+		}
+	}
+	if !found {
+		return Coordinate{}, false
+	}
+	if g.isAdjacentMove(best, unit) {
+		return best, true
+	}
+	path := g.FindPath(best, unit)
+	if step := getSecondCoordinate(path); step != nil {
+		return *step, true
+	}
+	return Coordinate{}, false
+}
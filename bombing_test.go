@@ -0,0 +1,136 @@
+package main
+
+import "testing"
+
+func TestBombingRun(t *testing.T) {
+	board := newTerrainBoard(
+		"LLLLL",
+		"LLLLL",
+		"LLLLL",
+	)
+	city := addCity(board, Coordinate{1, 2}, 2)
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
+	city.DaysUntilUnitReady = 1
+	board.addUnit(NewUnit(1, 1, Bomber, 1))
+	board.addUnit(NewUnit(1, 2, Tank, 2))       // at the target
+	board.addUnit(NewUnit(0, 3, Carrier, 2))    // in the blast
+	board.addUnit(NewUnit(2, 1, Tank, 1))       // friendly, in the blast
+	board.addUnit(NewUnit(1, 4, Destroyer, 2))  // outside the blast
+	board.addUnit(NewUnit(0, 2, Battleship, 2)) // in the blast, making the run worth the bomber
+	board.Units[1].Strength = 1
+	board.updateFogOfWarForPlayer(1)
+
+	if got := board.determineAction(Coordinate{1, 2}, &board.Units[0]); got != ActionBombingRun {
+		t.Fatalf("determineAction() of a bomber onto an enemy city = %d; want ActionBombingRun", got)
+	}
+	board.attemptMoveTo(Coordinate{1, 2}, &board.Units[0])
+
+	strengths := map[UnitType]int{}
+	for _, unit := range board.Units {
+		strengths[unit.Type] = unit.Strength
+	}
+	if _, ok := strengths[Bomber]; ok {
+		t.Errorf("bombing run left the bomber; want it spent")
+	}
+	type test struct {
+		name     string
+		unitType UnitType
+		want     int // strength after the run, 0 for destroyed
+	}
+	tests := []test{
+		{name: "tank at the target", unitType: Tank, want: GetNewUnitStrength(Tank)}, // destroyed, leaving the friendly tank
+		{name: "carrier in the blast", unitType: Carrier, want: GetNewUnitStrength(Carrier) - 1},
+		{name: "destroyer outside the blast", unitType: Destroyer, want: GetNewUnitStrength(Destroyer)},
+		{name: "battleship in the blast", unitType: Battleship, want: GetNewUnitStrength(Battleship) - 1},
+	}
+	for _, tc := range tests {
+		if got := strengths[tc.unitType]; got != tc.want {
+			t.Errorf("bombing run, name:%s, got strength %d; want %d", tc.name, got, tc.want)
+		}
+	}
+	if len(board.Units) != 4 {
+		t.Errorf("bombing run left %d units; want 4", len(board.Units))
+	}
+	city = &board.Cities[0]
+	if city.Strength != NewCityStrength-1 || city.DaysUntilUnitReady != GetDaysToProduceUnit(Tank) {
+		t.Errorf("bombing run left the city at strength %d, %d days from a tank; want %d and %d",
+			city.Strength, city.DaysUntilUnitReady, NewCityStrength-1, GetDaysToProduceUnit(Tank))
+	}
+	// a city is never bombed below strength 1
+	board.addUnit(NewUnit(1, 1, Bomber, 1))
+	board.updateFogOfWarForPlayer(1)
+	board.attemptMoveTo(Coordinate{1, 2}, &board.Units[len(board.Units)-1])
+	if city.Strength != 1 || city.OccupyingPlayer != OccupiedByPlayer2 {
+		t.Errorf("second bombing run left the city at strength %d held by %d; want 1 held by player 2", city.Strength, city.OccupyingPlayer)
+	}
+}
+
+func TestBomberAttack(t *testing.T) {
+	type test struct {
+		name  string
+		enemy UnitType // unit of player 2 at (0, 1), Blank for a city
+		want  ActionType
+	}
+	tests := []test{
+		{name: "a tank is not worth a bombing run", enemy: Tank, want: ActionUnitAttack},
+		{name: "an empty city is not worth a bombing run", enemy: Blank, want: ActionCityAttack},
+		{name: "a battleship and a carrier are", enemy: Battleship, want: ActionBombingRun},
+	}
+	for _, tc := range tests {
+		board := newTerrainBoard("LL", "LL")
+		board.addUnit(NewUnit(0, 0, Bomber, 1))
+		if tc.enemy == Blank {
+			addCity(board, Coordinate{0, 1}, 2)
+		} else {
+			board.addUnit(NewUnit(0, 1, tc.enemy, 2))
+		}
+		if tc.enemy == Battleship {
+			board.addUnit(NewUnit(1, 0, Carrier, 2))
+		}
+		board.updateFogOfWarForPlayer(1)
+		if got := board.determineAction(Coordinate{0, 1}, &board.Units[0]); got != tc.want {
+			t.Errorf("determineAction(), name:%s, got %d; want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestBomberLandsInOwnCity(t *testing.T) {
+	board := newTerrainBoard("LL")
+	addCity(board, Coordinate{0, 1}, 1)
+	bomber := NewUnit(0, 0, Bomber, 1)
+	if board.isBombingRun(Coordinate{0, 1}, bomber) {
+		t.Errorf("isBombingRun() onto the bomber's own city = true; want false")
+	}
+	if board.isBombingRun(Coordinate{0, 1}, NewUnit(0, 0, Fighter, 2)) {
+		t.Errorf("isBombingRun() of a fighter = true; want false")
+	}
+}
+
+func TestGetBombingRunMove(t *testing.T) {
+	type test struct {
+		name   string
+		enemy  []UnitType // units of player 2 at (0, 5)
+		wantOK bool
+	}
+	tests := []test{
+		{name: "nothing in sight", wantOK: false},
+		{name: "a tank is not worth a bomber", enemy: []UnitType{Tank}, wantOK: false},
+		{name: "a battleship and a carrier are", enemy: []UnitType{Battleship, Carrier}, wantOK: true},
+	}
+	for _, tc := range tests {
+		board := newTerrainBoard(
+			"SSSSSS",
+			"SSSSSS",
+		)
+		board.addUnit(NewUnit(0, 0, Bomber, 1))
+		for _, unitType := range tc.enemy {
+			board.addUnit(NewUnit(0, 5, unitType, 2))
+		}
+		board.addUnit(NewUnit(0, 4, Destroyer, 1)) // keeps the target in sight
+		board.updateFogOfWarForPlayer(1)
+		move, ok := board.getBombingRunMove(&board.Units[0])
+		if ok != tc.wantOK || (ok && move.PositionY != 1) {
+			t.Errorf("getBombingRunMove(), name:%s, got %v, %t; want %t heading east", tc.name, move, ok, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+)
+
+// DefaultBotTimeLimit is how long a bot may take to answer a turn.
+var DefaultBotTimeLimit = 2 * time.Second
+
+// botTurnRequest is the line of JSON sent to a bot at the start of each turn.
+type botTurnRequest struct {
+	Type   string `json:"type"`             // "turn", or "end" when the game is over
+	Winner int    `json:"winner,omitempty"` // set on "end", 0 for a draw
+	PlayerView
+}
+
+// botTurnResponse is the line of JSON a bot answers a turn with.
+type botTurnResponse struct {
+	Actions    []botAction     `json:"actions"`
+	Production []botProduction `json:"production"`
+}
+
+// botAction lists the moves a bot wants one of its units to make this turn.
+type botAction struct {
+	Unit  int          `json:"unit"`
+	Moves []Coordinate `json:"moves"`
+}
+
+// botProduction sets what one of the bot's cities should manufacture.
+type botProduction struct {
+	PositionX int    `json:"x"`
+	PositionY int    `json:"y"`
+	Unit      string `json:"unit"`
+}
+
+// BotController is a Controller played by an external program, or a client connected over the network.
+// Each turn the bot is sent its PlayerView as a line of JSON on stdin and must answer,
+// within the time limit, with a line of JSON on stdout listing its actions.
+// A bot that is slow, or answers with anything invalid, forfeits.
+type BotController struct {
+	TimeLimit time.Duration
+	Winner    int // sent to the bot when it is closed
+
+	cmd     *exec.Cmd // nil when the bot is not a local program
+	stdin   io.WriteCloser
+	lines   chan string          // lines read from the bot's stdout
+	pending map[int][]Coordinate // moves left to make this turn, keyed by unit ID
+}
+
+// NewBotController starts the bot program and returns a controller talking to it.
+func NewBotController(cmd *exec.Cmd, timeLimit time.Duration) (*BotController, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := newStreamBotController(stdout, stdin, timeLimit)
+	c.cmd = cmd
+	return c, nil
+}
+
+// newStreamBotController returns a controller for a bot reading its turns from w and answering on r.
+func newStreamBotController(r io.Reader, w io.WriteCloser, timeLimit time.Duration) *BotController {
+	c := &BotController{
+		TimeLimit: timeLimit,
+		stdin:     w,
+		lines:     make(chan string),
+	}
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for scanner.Scan() {
+			c.lines <- scanner.Text()
+		}
+		close(c.lines)
+	}()
+	return c
+}
+
+// BeginTurn implements Controller.
+// It sends the bot its view of the game, then reads and checks its orders.
+func (c *BotController) BeginTurn(g *GameBoard, player int) error {
+	c.pending = make(map[int][]Coordinate)
+	// drop an answer to an earlier turn which came after its time limit, or it would be taken as
+	// the answer to this one
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-c.lines:
+			if !ok {
+				return fmt.Errorf("bot exited")
+			}
+		default:
+			drained = true
+		}
+	}
+	request := botTurnRequest{Type: "turn", PlayerView: g.getPlayerView(player)}
+	if err := json.NewEncoder(c.stdin).Encode(request); err != nil {
+		return fmt.Errorf("sending turn to bot: %v", err)
+	}
+
+	var line string
+	select {
+	case l, ok := <-c.lines:
+		if !ok {
+			return fmt.Errorf("bot exited")
+		}
+		line = l
+	case <-time.After(c.TimeLimit):
+		return fmt.Errorf("bot did not answer within %v", c.TimeLimit)
+	}
+
+	var response botTurnResponse
+	if err := json.Unmarshal([]byte(line), &response); err != nil {
+		return fmt.Errorf("bot sent invalid JSON: %v", err)
+	}
+	for _, production := range response.Production {
+		city := g.getCityAtCoordinates(Coordinate{production.PositionX, production.PositionY})
+		if city == nil || int(city.OccupyingPlayer) != player {
+			return fmt.Errorf("bot set production of a city it does not occupy at (%d, %d)", production.PositionX, production.PositionY)
+		}
+		unitType, ok := g.unitRules().typeFromString(production.Unit)
+		if !ok || unitType == Blank {
+			return fmt.Errorf("bot set production to unknown unit type %q", production.Unit)
+		}
+		if !g.canCityBuild(city, unitType) {
+			return fmt.Errorf("bot set production of the city at (%d, %d) to %s, which it cannot build", production.PositionX, production.PositionY, production.Unit)
+		}
+		if unitType != city.ManufacturingUnit {
+			city.SetManufacturingUnit(g.unitRules(), unitType)
+		}
+	}
+	for _, action := range response.Actions {
+		unit := g.getUnitByID(action.Unit)
+		if unit == nil || unit.Player != player {
+			return fmt.Errorf("bot gave orders to unit %d which it does not own", action.Unit)
+		}
+		c.pending[action.Unit] = action.Moves
+	}
+	return nil
+}
+
+// ChooseProduction implements Controller.
+// The bot chooses production in its turn response, so a newly occupied city starts idle.
+func (c *BotController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	return Blank
+}
+
+// ChooseMove implements Controller.
+func (c *BotController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	moves := c.pending[unit.ID]
+	if len(moves) == 0 {
+		return Coordinate{}, false, nil
+	}
+	move := moves[0]
+	c.pending[unit.ID] = moves[1:]
+	if !g.isLegalMove(move, unit) {
+		return Coordinate{}, false, fmt.Errorf("bot made an illegal move with unit %d to (%d, %d)", unit.ID, move.PositionX, move.PositionY)
+	}
+	return move, true, nil
+}
+
+// Close tells the bot the game is over, giving it the time limit to exit before it is killed.
+func (c *BotController) Close() error {
+	json.NewEncoder(c.stdin).Encode(botTurnRequest{Type: "end", Winner: c.Winner})
+	c.stdin.Close()
+	if c.cmd == nil {
+		return nil
+	}
+	exited := make(chan error, 1)
+	go func() {
+		for range c.lines {
+			// discard anything else the bot writes until it exits
+		}
+		exited <- c.cmd.Wait()
+	}()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(c.TimeLimit):
+		c.cmd.Process.Kill()
+		<-exited
+		return nil
+	}
+}
+
+// getUnitByID returns the unit with the given ID, or nil if there is none.
+func (g *GameBoard) getUnitByID(id int) *Unit {
+	for i := range g.Units {
+		if g.Units[i].ID == id {
+			return &g.Units[i]
+		}
+	}
+	return nil
+}
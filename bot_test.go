@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBotHelperProcess is not a real test: it is run as the external bot by the tests below.
+// GO_BOT_HELPER selects how the bot behaves.
+func TestBotHelperProcess(t *testing.T) {
+	mode := os.Getenv("GO_BOT_HELPER")
+	if mode == "" {
+		return
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var request botTurnRequest
+		json.Unmarshal(scanner.Bytes(), &request)
+		if request.Type == "end" {
+			break
+		}
+		response := botTurnResponse{}
+		switch mode {
+		case "east":
+			// move every unit one cell east and build fighters everywhere
+			for _, unit := range request.Units {
+				response.Actions = append(response.Actions, botAction{Unit: unit.ID, Moves: []Coordinate{{unit.PositionX, unit.PositionY + 1}}})
+			}
+			for _, city := range request.Cities {
+				if city.Owner == request.Player {
+					response.Production = append(response.Production, botProduction{PositionX: city.PositionX, PositionY: city.PositionY, Unit: "Fighter"})
+				}
+			}
+		case "teleport":
+			for _, unit := range request.Units {
+				response.Actions = append(response.Actions, botAction{Unit: unit.ID, Moves: []Coordinate{{unit.PositionX + 2, unit.PositionY}}})
+			}
+		case "slow":
+			time.Sleep(time.Second)
+		case "garbage":
+			fmt.Println("not json")
+			continue
+		}
+		json.NewEncoder(os.Stdout).Encode(response)
+	}
+	os.Exit(0)
+}
+
+func newHelperBot(t *testing.T, mode string) *BotController {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=TestBotHelperProcess")
+	cmd.Env = append(os.Environ(), "GO_BOT_HELPER="+mode)
+	bot, err := NewBotController(cmd, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewBotController() error = %v", err)
+	}
+	return bot
+}
+
+func TestBotController(t *testing.T) {
+	type test struct {
+		name    string
+		mode    string
+		wantErr string
+	}
+	tests := []test{
+		{name: "valid orders", mode: "east", wantErr: ""},
+		{name: "illegal move", mode: "teleport", wantErr: "illegal move"},
+		{name: "too slow", mode: "slow", wantErr: "did not answer"},
+		{name: "invalid response", mode: "garbage", wantErr: "invalid JSON"},
+	}
+	for _, tc := range tests {
+		board := newLandBoard(4, 4)
+		board.addUnit(NewUnit(1, 1, Tank, 1))
+		bot := newHelperBot(t, tc.mode)
+		board.Player1 = &Player{Name: "bot", IsAI: true, Controller: bot}
+
+		err := board.DoPlayerTurn(1)
+		bot.Close()
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Fatalf("DoPlayerTurn(), name:%s, error = %v", tc.name, err)
+			}
+			if got := board.Units[0].PositionY; got != 2 {
+				t.Errorf("DoPlayerTurn(), name:%s, unit column = %d; want 2", tc.name, got)
+			}
+			if got := board.Cities[0].ManufacturingUnit; got != Fighter {
+				t.Errorf("DoPlayerTurn(), name:%s, production = %s; want Fighter", tc.name, unitTypeToString(got))
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("DoPlayerTurn(), name:%s, error = %v; want %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestBotLateAnswer(t *testing.T) {
+	requests, requestWriter := io.Pipe()
+	answerReader, answers := io.Pipe()
+	bot := newStreamBotController(answerReader, requestWriter, 50*time.Millisecond)
+	go func() {
+		scanner := bufio.NewScanner(requests)
+		scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+		for turn := 0; scanner.Scan(); turn++ {
+			if turn == 0 {
+				// answer the first turn too late, with orders for a unit the bot does not own
+				time.Sleep(100 * time.Millisecond)
+				fmt.Fprintln(answers, `{"actions":[{"unit":99}]}`)
+				continue
+			}
+			fmt.Fprintln(answers, `{}`)
+		}
+	}()
+	board := newLandBoard(4, 4)
+	if err := bot.BeginTurn(board, 1); err == nil || !strings.Contains(err.Error(), "did not answer") {
+		t.Fatalf("BeginTurn() of a late answer error = %v; want it too slow", err)
+	}
+	time.Sleep(200 * time.Millisecond) // the late answer arrives
+	if err := bot.BeginTurn(board, 1); err != nil {
+		t.Errorf("BeginTurn() after a late answer error = %v; want the answer to this turn", err)
+	}
+}
+
+func TestStartBotEmptyCommandLine(t *testing.T) {
+	for _, commandLine := range []string{"", "   \t"} {
+		if bot, err := startBot(commandLine, time.Second); err == nil {
+			bot.Close()
+			t.Errorf("startBot(%q) error = nil; want an error", commandLine)
+		}
+	}
+}
@@ -9,7 +9,8 @@ const (
 	OccupiedByPlayer2
 )
 
-var NewCityStrength int = 2
+// NewCityStrength is the strength of a new city under the classic rules.
+const NewCityStrength = 2
 
 // City struct represents a city in the game.
 type City struct {
@@ -20,8 +21,24 @@ type City struct {
 	ManufacturingUnit  UnitType
 	DaysUntilUnitReady int
 	IsCityNextToSea    bool
+	Queue              []UnitType  // units to manufacture after ManufacturingUnit, in order
+	HeldDays           int         // days the occupying player has held the city
+	BuildOnce          bool        // the city stops once its queue is done, rather than repeating the last unit
+	RallyPoint         *Coordinate // where the units the city produces head, nil to stay in the city
 }
 
+const (
+	// cityLevelDays is how many days a player holds a city for it to rise a level.
+	cityLevelDays = 20
+	// maxCityLevel is the highest level a city rises to.
+	maxCityLevel = 3
+	// cityLevelBonus is the percentage each level of a city takes off the days to produce a unit.
+	cityLevelBonus = 10
+	// productionRetention is the percentage of the days spent on a unit which count towards the
+	// unit a city switches its production to.
+	productionRetention = 50
+)
+
 // NewCity creates a new City with the given parameters.
 func NewCity(positionX, positionY int) *City {
 	return &City{
@@ -34,17 +51,92 @@ func NewCity(positionX, positionY int) *City {
 	}
 }
 
+// newCity creates a new city with the city strength of the board's rules.
+func (g *GameBoard) newCity(positionX, positionY int) *City {
+	city := NewCity(positionX, positionY)
+	city.Strength = g.rules().CityStrength
+	return city
+}
+
 // OccupyCity occupies the city by a player.
 func (c *City) OccupyCity(player int) {
 	c.OccupyingPlayer = CityState(player)
 	c.ManufacturingUnit = Blank
 	c.DaysUntilUnitReady = 0
+	c.Queue = nil
+	c.HeldDays = 0
+	c.BuildOnce = false
+	c.RallyPoint = nil
+}
+
+// Level returns the level of the city, which rises every cityLevelDays days its player holds it,
+// up to maxCityLevel.
+func (c *City) Level() int {
+	if c.HeldDays/cityLevelDays > maxCityLevel {
+		return maxCityLevel
+	}
+	return c.HeldDays / cityLevelDays
+}
+
+// GetDaysToProduce gets the number of days the city takes to produce a unit of the rules, which
+// is cityLevelBonus percent fewer per level of the city, rounded, and never less than a day.
+func (c *City) GetDaysToProduce(units *UnitRules, unitType UnitType) int {
+	days := units.definitionOf(unitType).DaysToProduce
+	return maxInt(1, (days*(100-cityLevelBonus*c.Level())+50)/100)
 }
 
-// SetManufacturingUnit sets the unitTye that the city should manufacture.
-func (c *City) SetManufacturingUnit(unit UnitType) {
+// SetManufacturingUnit sets the unitTye that the city should manufacture. Switching from another
+// unit keeps productionRetention percent of the days already spent on it.
+func (c *City) SetManufacturingUnit(units *UnitRules, unit UnitType) {
+	if unit == c.ManufacturingUnit {
+		return
+	}
+	progress := 0
+	if c.ManufacturingUnit != Blank {
+		progress = c.GetDaysToProduce(units, c.ManufacturingUnit) - c.DaysUntilUnitReady
+	}
 	c.ManufacturingUnit = unit
-	c.DaysUntilUnitReady = GetDaysToProduceUnit(unit)
+	if unit == Blank {
+		c.DaysUntilUnitReady = 0
+		return
+	}
+	c.DaysUntilUnitReady = maxInt(1, c.GetDaysToProduce(units, unit)-progress*productionRetention/100)
+}
+
+// QueueUnit adds a unit for the city to manufacture after the units already queued.
+func (c *City) QueueUnit(unit UnitType) {
+	c.Queue = append(c.Queue, unit)
+}
+
+// startNextUnit starts manufacturing the first unit in the queue once the city has produced a
+// unit. When the queue is empty, it manufactures another of the same unit, or nothing if it
+// builds once.
+func (c *City) startNextUnit(units *UnitRules) {
+	switch {
+	case len(c.Queue) > 0:
+		c.ManufacturingUnit = c.Queue[0]
+		c.Queue = append([]UnitType(nil), c.Queue[1:]...)
+	case c.BuildOnce:
+		c.ManufacturingUnit = Blank
+		c.DaysUntilUnitReady = 0
+		return
+	}
+	c.DaysUntilUnitReady = c.GetDaysToProduce(units, c.ManufacturingUnit)
+}
+
+// getProductionETAs returns how many days from now the city produces its current unit and each
+// unit in its queue, at its level today.
+func (c *City) getProductionETAs(units *UnitRules) []int {
+	if c.ManufacturingUnit == Blank {
+		return nil
+	}
+	etas := []int{c.DaysUntilUnitReady}
+	days := c.DaysUntilUnitReady
+	for _, unit := range c.Queue {
+		days += c.GetDaysToProduce(units, unit)
+		etas = append(etas, days)
+	}
+	return etas
 }
 
 // ManufactureUnit updates the days until the unit is ready and returns true if the unit is ready.
@@ -63,3 +155,92 @@ func (c *City) ManufactureUnit() bool {
 	}
 	return false
 }
+
+const (
+	// capturedCityStrength is the strength a city has when it has just been captured.
+	capturedCityStrength = 1
+	// cityRecoveryDays is how often a city below the city strength of the rules recovers a point of strength.
+	cityRecoveryDays = 3
+)
+
+// getGarrisonDefender returns the strongest enemy unit garrisoning the city at the coordinate,
+// which defends it before the city itself, or nil if there is no city or no garrison.
+func (g *GameBoard) getGarrisonDefender(coordinate Coordinate, attackingPlayer int) *Unit {
+	if g.getCityAtCoordinates(coordinate) == nil {
+		return nil
+	}
+	var defender *Unit
+	for i := range g.Units {
+		unit := &g.Units[i]
+		if unit.Player != attackingPlayer && unit.PositionX == coordinate.PositionX && unit.PositionY == coordinate.PositionY &&
+			(defender == nil || unit.Strength > defender.Strength) {
+			defender = unit
+		}
+	}
+	return defender
+}
+
+// captureCity hands the city to the player of the unit, which enters it. Enemy units still in the
+// city are expelled, or destroyed if they cannot leave, and the city starts at capturedCityStrength.
+func (g *GameBoard) captureCity(attacker *Unit, city *City) {
+	coordinate := Coordinate{city.PositionX, city.PositionY}
+	g.printf("Defender [City] is conquered\n")
+	g.emit(EventCityCaptured, attacker.Player, attacker.Type, coordinate)
+	city.OccupyCity(attacker.Player)
+	city.Strength = capturedCityStrength
+	// the attacker moves in before the units are expelled, as removing units moves the others
+	attacker.PositionX, attacker.PositionY = coordinate.PositionX, coordinate.PositionY
+	g.expelUnits(coordinate, attacker.Player)
+	g.setChosenProduction(city, g.getControllerForPlayer(attacker.Player).ChooseProduction(g, city))
+}
+
+// expelUnits moves the units of the player's opponent out of the city at the coordinate: aircraft
+// fly to the first free cell next to it, and units which cannot leave are destroyed.
+func (g *GameBoard) expelUnits(coordinate Coordinate, player int) {
+	for {
+		unit := g.getUnitAtCoordinates(coordinate, player)
+		if unit == nil {
+			return
+		}
+		if exit, ok := g.getExpulsionCell(coordinate, unit); ok {
+			g.printf("%s %d is expelled to %d, %d\n", g.unitTypeToString(unit.Type), unit.ID, exit.PositionX, exit.PositionY)
+			unit.PositionX, unit.PositionY = exit.PositionX, exit.PositionY
+			continue
+		}
+		g.printf("%s %d is destroyed in the city\n", g.unitTypeToString(unit.Type), unit.ID)
+		g.emit(EventUnitDestroyed, unit.Player, unit.Type, coordinate)
+		g.removeUnit(unit)
+	}
+}
+
+// getExpulsionCell returns the first cell next to the city at the coordinate an expelled aircraft
+// can fly to, without a city or enemy units. Other units cannot leave.
+func (g *GameBoard) getExpulsionCell(coordinate Coordinate, unit *Unit) (Coordinate, bool) {
+	if !unit.CanFly {
+		return Coordinate{}, false
+	}
+	for i := coordinate.PositionX - 1; i <= coordinate.PositionX+1; i++ {
+		for j := coordinate.PositionY - 1; j <= coordinate.PositionY+1; j++ {
+			if i < 0 || i >= g.Rows || j < 0 || j >= g.Columns || g.Grid[i][j].HasCity {
+				continue
+			}
+			if g.getUnitAtCoordinates(Coordinate{i, j}, unit.Player) == nil {
+				return Coordinate{i, j}, true
+			}
+		}
+	}
+	return Coordinate{}, false
+}
+
+// recoverCities gives every city below the city strength of the rules a point of strength back,
+// every cityRecoveryDays days.
+func (g *GameBoard) recoverCities() {
+	if g.Day%cityRecoveryDays != 0 {
+		return
+	}
+	for i := range g.Cities {
+		if g.Cities[i].Strength < g.rules().CityStrength {
+			g.Cities[i].Strength++
+		}
+	}
+}
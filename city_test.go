@@ -1,6 +1,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -18,7 +19,7 @@ func TestNewCity(t *testing.T) {
 func TestManufactureUnit(t *testing.T) {
 	city := NewCity(0, 0)
 	city.OccupyCity(1) // Occupied by player 1
-	city.SetManufacturingUnit(Tank)
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
 	day := 0
 	if city.ManufacturingUnit != Tank {
 		t.Errorf("day %d, occupied city, value of ManufacturingUnit = %d; want %d", day, city.ManufacturingUnit, Tank)
@@ -75,3 +76,174 @@ func TestManufactureUnit(t *testing.T) {
 		t.Errorf("day %d, occupied city, value of DaysUntilUnitReady = %d; want %d", day, city.DaysUntilUnitReady, 4)
 	}
 }
+
+func TestCityCapture(t *testing.T) {
+	type test struct {
+		name         string
+		attacker     UnitType
+		strength     int  // strength of the city before the attack
+		outcome      bool // outcome of the attack roll
+		wantCaptured bool
+		wantStrength int
+	}
+	tests := []test{
+		{name: "tank takes the last point", attacker: Tank, strength: 1, outcome: true, wantCaptured: true, wantStrength: capturedCityStrength},
+		{name: "tank repelled", attacker: Tank, strength: 1, outcome: false, wantCaptured: false, wantStrength: 1},
+		{name: "tank weakens the city", attacker: Tank, strength: 2, outcome: true, wantCaptured: false, wantStrength: 1},
+		{name: "fighter cannot capture", attacker: Fighter, strength: 1, outcome: true, wantCaptured: false, wantStrength: 0},
+		{name: "tank walks into a city without strength", attacker: Tank, strength: 0, outcome: false, wantCaptured: true, wantStrength: capturedCityStrength},
+	}
+	for _, tc := range tests {
+		board := newIslandBoard(1, 2)
+		city := addCity(board, Coordinate{0, 1}, 2)
+		city.Strength = tc.strength
+		board.addUnit(NewUnit(0, 0, tc.attacker, 1))
+		board.resolveCityAttack(&board.Units[0], city, tc.outcome)
+		captured := city.OccupyingPlayer == OccupiedByPlayer1
+		if captured != tc.wantCaptured || city.Strength != tc.wantStrength {
+			t.Errorf("resolveCityAttack(), name:%s, got captured %t with strength %d; want %t with %d",
+				tc.name, captured, city.Strength, tc.wantCaptured, tc.wantStrength)
+		}
+		if len(board.Units) != 1 {
+			t.Fatalf("resolveCityAttack(), name:%s, left %d units; want the attacker to survive", tc.name, len(board.Units))
+		}
+		if inCity := board.Units[0].PositionY == 1; inCity != tc.wantCaptured {
+			t.Errorf("resolveCityAttack(), name:%s, attacker in the city %t; want %t", tc.name, inCity, tc.wantCaptured)
+		}
+	}
+}
+
+func TestCityGarrison(t *testing.T) {
+	board := newIslandBoard(1, 2)
+	city := addCity(board, Coordinate{0, 1}, 2)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(0, 1, Fighter, 2))
+	board.addUnit(NewUnit(0, 1, Tank, 2))
+	attacker := &board.Units[0]
+
+	if got := board.getGarrisonDefender(Coordinate{0, 1}, 1); got == nil || got.Type != Tank {
+		t.Errorf("getGarrisonDefender() = %v; want the stronger tank", got)
+	}
+	board.resolveCityAttack(attacker, city, true)
+	if city.Strength != NewCityStrength || city.OccupyingPlayer != OccupiedByPlayer2 {
+		t.Errorf("resolveCityAttack() of a garrisoned city hit the city, strength %d; want the garrison hit first", city.Strength)
+	}
+	if got := board.getUnitByID(3).Strength; got != GetNewUnitStrength(Tank)-1 {
+		t.Errorf("resolveCityAttack() of a garrisoned city left the garrison tank at strength %d; want %d", got, GetNewUnitStrength(Tank)-1)
+	}
+
+	// units enter their own cities to garrison them
+	if got := board.determineAction(Coordinate{0, 1}, NewUnit(0, 0, Tank, 2)); got != ActionMove {
+		t.Errorf("determineAction() of a tank into its own city = %d; want ActionMove", got)
+	}
+}
+
+func TestCaptureExpelsUnits(t *testing.T) {
+	board := newIslandBoard(2, 2)
+	city := addCity(board, Coordinate{0, 0}, 2)
+	board.addUnit(NewUnit(0, 1, Tank, 1))
+	board.addUnit(NewUnit(0, 0, Fighter, 2))
+	board.addUnit(NewUnit(0, 0, Tank, 2))
+	board.captureCity(&board.Units[0], city)
+
+	if city.OccupyingPlayer != OccupiedByPlayer1 || board.Units[0].PositionY != 0 {
+		t.Errorf("captureCity() left the city with %d and the tank at column %d; want it taken by the tank", city.OccupyingPlayer, board.Units[0].PositionY)
+	}
+	fighter := board.getUnitByID(2)
+	if fighter == nil || (fighter.PositionX == 0 && fighter.PositionY == 0) {
+		t.Errorf("captureCity() left the enemy fighter %v; want it expelled from the city", fighter)
+	}
+	if board.getUnitByID(3) != nil {
+		t.Errorf("captureCity() left the enemy tank in the city; want it destroyed")
+	}
+}
+
+func TestRecoverCities(t *testing.T) {
+	board := newIslandBoard(1, 2)
+	city := addCity(board, Coordinate{0, 1}, 1)
+	city.Strength = capturedCityStrength
+	for day := 1; day <= cityRecoveryDays*NewCityStrength; day++ {
+		board.NextDay()
+		want := capturedCityStrength + day/cityRecoveryDays
+		if want > NewCityStrength {
+			want = NewCityStrength
+		}
+		if city.Strength != want {
+			t.Errorf("NextDay(), day %d, city strength %d; want %d", day, city.Strength, want)
+		}
+	}
+}
+
+func TestCityLevel(t *testing.T) {
+	type test struct {
+		name     string
+		heldDays int
+		want     int // days to produce a battleship
+	}
+	days := GetDaysToProduceUnit(Battleship)
+	tests := []test{
+		{name: "just taken", heldDays: 0, want: days},
+		{name: "level 1", heldDays: cityLevelDays, want: (days*90 + 50) / 100},
+		{name: "level 3", heldDays: cityLevelDays * maxCityLevel, want: (days*70 + 50) / 100},
+		{name: "beyond the highest level", heldDays: cityLevelDays * 10, want: (days*70 + 50) / 100},
+	}
+	for _, tc := range tests {
+		city := NewCity(0, 0)
+		city.OccupyCity(1)
+		city.HeldDays = tc.heldDays
+		if got := city.GetDaysToProduce(defaultRules.unitRules, Battleship); got != tc.want {
+			t.Errorf("GetDaysToProduce(), name:%s, got %d; want %d", tc.name, got, tc.want)
+		}
+	}
+	city := NewCity(0, 0)
+	city.OccupyCity(1)
+	city.HeldDays = cityLevelDays * maxCityLevel
+	if got := city.GetDaysToProduce(defaultRules.unitRules, Tank); got < 1 {
+		t.Errorf("GetDaysToProduce() of a tank at the highest level = %d; want at least a day", got)
+	}
+	city.OccupyCity(2)
+	if city.Level() != 0 {
+		t.Errorf("OccupyCity() left the city at level %d; want 0", city.Level())
+	}
+}
+
+func TestSetManufacturingUnitKeepsProgress(t *testing.T) {
+	city := NewCity(0, 0)
+	city.OccupyCity(1)
+	city.SetManufacturingUnit(defaultRules.unitRules, Battleship)
+	city.DaysUntilUnitReady -= 6
+	city.SetManufacturingUnit(defaultRules.unitRules, Battleship)
+	if want := GetDaysToProduceUnit(Battleship) - 6; city.DaysUntilUnitReady != want {
+		t.Errorf("SetManufacturingUnit() of the same unit left %d days; want %d", city.DaysUntilUnitReady, want)
+	}
+	city.SetManufacturingUnit(defaultRules.unitRules, Carrier)
+	if want := GetDaysToProduceUnit(Carrier) - 6*productionRetention/100; city.DaysUntilUnitReady != want {
+		t.Errorf("SetManufacturingUnit() of another unit left %d days; want %d", city.DaysUntilUnitReady, want)
+	}
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
+	if city.DaysUntilUnitReady < 1 {
+		t.Errorf("SetManufacturingUnit() left %d days; want at least a day", city.DaysUntilUnitReady)
+	}
+}
+
+func TestProductionQueue(t *testing.T) {
+	board := newIslandBoard(1, 2)
+	city := addCity(board, Coordinate{0, 0}, 1)
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
+	city.DaysUntilUnitReady = 1
+	city.QueueUnit(Fighter)
+	city.QueueUnit(Artillery)
+
+	if got, want := city.getProductionETAs(defaultRules.unitRules), []int{1, 1 + GetDaysToProduceUnit(Fighter), 1 + GetDaysToProduceUnit(Fighter) + GetDaysToProduceUnit(Artillery)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("getProductionETAs() = %v; want %v", got, want)
+	}
+	board.NextDay()
+	if len(board.Units) != 1 || board.Units[0].Type != Tank {
+		t.Fatalf("NextDay() produced %v; want a tank", board.Units)
+	}
+	if city.ManufacturingUnit != Fighter || city.DaysUntilUnitReady != GetDaysToProduceUnit(Fighter) || !reflect.DeepEqual(city.Queue, []UnitType{Artillery}) {
+		t.Errorf("NextDay() left the city making %s in %d days, queue %v; want the fighter next",
+			unitTypeToString(city.ManufacturingUnit), city.DaysUntilUnitReady, city.Queue)
+	}
+
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// runJoinCommand runs the "join" command with its command line arguments.
+func runJoinCommand(args []string) error {
+	flags := flag.NewFlagSet("join", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:7777", "address of the game server, or its ws:// URL")
+	name := flags.String("name", "player", "name to join the game with")
+	useAI := flags.Bool("ai", false, "let the AI play instead of asking at the terminal")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var conn net.Conn
+	var err error
+	if strings.HasPrefix(*addr, "ws://") {
+		conn, err = dialWebSocket(*addr)
+	} else {
+		conn, err = net.Dial("tcp", *addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	winner, err := runClient(conn, *name, *useAI, os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("GAME OVER, winner: %d\n", winner)
+	return nil
+}
+
+// runClient joins the game on conn and plays it, by asking the person at in and out or with the AI.
+// It returns the winner once the server ends the game.
+func runClient(conn io.ReadWriter, name string, useAI bool, in io.Reader, out io.Writer) (int, error) {
+	encoder := json.NewEncoder(conn)
+	decoder := json.NewDecoder(conn)
+	if err := encoder.Encode(joinRequest{Type: "join", Name: name}); err != nil {
+		return 0, err
+	}
+	var joined joinResponse
+	if err := decoder.Decode(&joined); err != nil {
+		return 0, err
+	}
+	fmt.Fprintf(out, "joined as player %d\n", joined.Player)
+
+	input := bufio.NewReader(in)
+	for {
+		var request botTurnRequest
+		if err := decoder.Decode(&request); err != nil {
+			return 0, err
+		}
+		if request.Type == "end" {
+			return request.Winner, nil
+		}
+		var response botTurnResponse
+		if useAI {
+			response = planTurnAI(request.PlayerView)
+		} else {
+			response = askTurn(request.PlayerView, input, out)
+		}
+		if err := encoder.Encode(response); err != nil {
+			return 0, err
+		}
+	}
+}
+
+// newBoardFromView rebuilds a game board from what a player can see, treating fog as unexplored sea.
+func newBoardFromView(view PlayerView) *GameBoard {
+	board := NewGameBoardWithSeed(view.Rows, view.Columns, time.Now().UnixNano())
+	board.Output = io.Discard
+	board.Day = view.Day
+	// the player's fog of war and weather, so the board shows what the server showed them
+	fog := make([][]bool, view.Rows)
+	for i := range fog {
+		fog[i] = make([]bool, view.Columns)
+	}
+	if view.Player == 1 || view.Player == 2 {
+		board.Fog[view.Player-1] = fog
+	}
+	if view.Weather != nil {
+		board.weather, board.weatherDay, board.weatherSeed = view.Weather, board.Day, board.Seed
+	}
+	for i, row := range view.Grid {
+		for j := range row {
+			cell := &board.Grid[i][j]
+			cell.IsFog = row[j] == '?'
+			fog[i][j] = cell.IsFog
+			cell.IsLand, cell.Terrain, _ = terrainFromSymbol(rune(row[j]))
+			cell.IsLand = cell.IsLand || row[j] == 'C'
+			cell.HasCity = row[j] == 'C'
+		}
+	}
+	for _, c := range view.Cities {
+		city := board.newCity(c.PositionX, c.PositionY)
+		city.OccupyingPlayer = CityState(c.Owner)
+		city.IsCityNextToSea = board.IsCityNextToSea(c.PositionX, c.PositionY)
+		if unitType, ok := board.unitRules().typeFromString(c.Production); ok {
+			city.ManufacturingUnit = unitType
+		}
+		board.Cities = append(board.Cities, *city)
+	}
+	opponent := 3 - view.Player
+	for _, u := range view.Units {
+		board.Units = append(board.Units, board.newUnitFromRemote(u, view.Player))
+	}
+	for _, u := range view.Enemies {
+		board.Units = append(board.Units, board.newUnitFromRemote(u, opponent))
+	}
+	return board
+}
+
+// newUnitFromRemote recreates a unit described to a remote player.
+func (g *GameBoard) newUnitFromRemote(u remoteUnit, player int) Unit {
+	unitType, _ := g.unitRules().typeFromString(u.Type)
+	unit := g.newUnit(u.PositionX, u.PositionY, unitType, player)
+	unit.ID = u.ID
+	unit.Strength = u.Strength
+	unit.MovesLeftThisDay = u.MovesLeft
+	unit.Fuel = u.Fuel
+	return *unit
+}
+
+// planTurnAI decides a turn's orders with the AI, planning on a board rebuilt from the view.
+// Moves are planned until a unit attacks, as the outcome of the attack is not known yet.
+func planTurnAI(view PlayerView) botTurnResponse {
+	board := newBoardFromView(view)
+	ai := &AIController{}
+	response := botTurnResponse{}
+	for i := range board.Cities {
+		city := &board.Cities[i]
+		if int(city.OccupyingPlayer) == view.Player && city.ManufacturingUnit == Blank {
+			unitType := ai.ChooseProduction(board, city)
+			response.Production = append(response.Production, botProduction{PositionX: city.PositionX, PositionY: city.PositionY, Unit: board.unitTypeToString(unitType)})
+		}
+	}
+	for _, u := range view.Units {
+		unit := board.getUnitByID(u.ID)
+		action := botAction{Unit: u.ID}
+		for unit.MovesLeftThisDay > 0 {
+			move, ok, _ := ai.ChooseMove(board, unit)
+			if !ok || !isSafeOrder(board, unit, move) {
+				break
+			}
+			action.Moves = append(action.Moves, move)
+			if board.determineAction(move, unit) != ActionMove {
+				break
+			}
+			unit.MoveTo(move)
+		}
+		if len(action.Moves) > 0 {
+			response.Actions = append(response.Actions, action)
+		}
+	}
+	return response
+}
+
+// isSafeOrder checks if a move planned on a board rebuilt from a view will still be legal when
+// the server plays it: the destination must be known, and a unit may only attack where it could
+// also move, in case an earlier attack has already cleared the destination.
+func isSafeOrder(board *GameBoard, unit *Unit, move Coordinate) bool {
+	if !board.isLegalMove(move, unit) {
+		return false
+	}
+	cell := board.Grid[move.PositionX][move.PositionY]
+	if unit.CanFly {
+		return true
+	}
+	if cell.IsFog {
+		return false
+	}
+	return board.canEnterTerrain(move, unit) && ((cell.IsLand && unit.CanMoveOnLand) || (!cell.IsLand && unit.CanMoveOnWater))
+}
+
+// askTurn shows the player's view at the terminal and asks for the turn's orders.
+func askTurn(view PlayerView, in *bufio.Reader, out io.Writer) botTurnResponse {
+	board := newBoardFromView(view)
+	fmt.Fprintf(out, "\nDay %d, player %d\n", view.Day, view.Player)
+	board.fprintGridWithUnits(out, view.Player, true)
+	human := &HumanController{in: in, out: out}
+
+	response := botTurnResponse{}
+	for i := range board.Cities {
+		city := &board.Cities[i]
+		if int(city.OccupyingPlayer) == view.Player && city.ManufacturingUnit == Blank {
+			if unitType := human.ChooseProduction(board, city); unitType != Blank {
+				response.Production = append(response.Production, botProduction{PositionX: city.PositionX, PositionY: city.PositionY, Unit: board.unitTypeToString(unitType)})
+			}
+		}
+	}
+	for _, u := range view.Units {
+		fmt.Fprintf(out, "%s %d at (%d, %d), moves left %d, moves [qweadzxc, empty to hold]: ",
+			u.Type, u.ID, u.PositionX, u.PositionY, u.MovesLeft)
+		line, err := human.readLine()
+		if err != nil {
+			break
+		}
+		action := botAction{Unit: u.ID}
+		unit := board.getUnitByID(u.ID)
+		for _, key := range strings.Split(line, "") {
+			direction, ok := humanDirections[key]
+			if !ok || unit.MovesLeftThisDay == 0 {
+				continue
+			}
+			move := Coordinate{unit.PositionX + direction.PositionX, unit.PositionY + direction.PositionY}
+			if !isSafeOrder(board, unit, move) {
+				fmt.Fprintf(out, "illegal move to (%d, %d), ignoring the rest of the moves\n", move.PositionX, move.PositionY)
+				break
+			}
+			action.Moves = append(action.Moves, move)
+			if board.determineAction(move, unit) != ActionMove {
+				break // the outcome of an attack is not known until the turn is played
+			}
+			unit.MoveTo(move)
+		}
+		if len(action.Moves) > 0 {
+			response.Actions = append(response.Actions, action)
+		}
+	}
+	return response
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Controller decides the production and unit actions of a player.
+// The game loop asks the controller of the player whose turn it is, so the AI,
+// a human at the terminal, a test script or a remote client are interchangeable.
+type Controller interface {
+	// BeginTurn is called once at the start of the player's turn.
+	BeginTurn(g *GameBoard, player int) error
+	// ChooseProduction returns the unit type the city should manufacture next.
+	ChooseProduction(g *GameBoard, city *City) UnitType
+	// ChooseMove returns the coordinate the unit should move to, or ok false
+	// if the unit should stay put for the rest of the day.
+	ChooseMove(g *GameBoard, unit *Unit) (move Coordinate, ok bool, err error)
+}
+
+// AIController is a Controller run by the computer.
+type AIController struct {
+	Variant *AIVariant // production strategy, DefaultAIVariant when nil
+}
+
+// BeginTurn implements Controller.
+func (c *AIController) BeginTurn(g *GameBoard, player int) error {
+	return nil
+}
+
+// ChooseProduction implements Controller.
+func (c *AIController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	player := 1
+	if city.OccupyingPlayer == OccupiedByPlayer2 {
+		player = 2
+	}
+	return g.getWhichUnitToManufactureNextAI(Coordinate{city.PositionX, city.PositionY}, player, city.IsCityNextToSea, c.Variant)
+}
+
+// ChooseMove implements Controller.
+func (c *AIController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	possibleMoves := g.getPossibleMoves(unit)
+	if len(possibleMoves) == 0 {
+		return Coordinate{}, false, nil
+	}
+	return possibleMoves[g.random().Intn(len(possibleMoves))], true, nil
+}
+
+// ScriptedController is a Controller which replays a fixed list of decisions, for tests.
+type ScriptedController struct {
+	Moves      map[int][]Coordinate // moves to make, keyed by unit ID
+	Production []UnitType           // production choices, used in order
+}
+
+// BeginTurn implements Controller.
+func (c *ScriptedController) BeginTurn(g *GameBoard, player int) error {
+	return nil
+}
+
+// ChooseProduction implements Controller.
+func (c *ScriptedController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	if len(c.Production) == 0 {
+		return Blank
+	}
+	unitType := c.Production[0]
+	c.Production = c.Production[1:]
+	return unitType
+}
+
+// ChooseMove implements Controller.
+func (c *ScriptedController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	moves := c.Moves[unit.ID]
+	if len(moves) == 0 {
+		return Coordinate{}, false, nil
+	}
+	c.Moves[unit.ID] = moves[1:]
+	return moves[0], true, nil
+}
+
+// HumanController is a Controller driven by a person at the terminal.
+type HumanController struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewHumanController creates a HumanController reading commands from in and writing prompts to out.
+func NewHumanController(in io.Reader, out io.Writer) *HumanController {
+	return &HumanController{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// humanDirections maps keys to moves, laid out like the keyboard around 's'.
+var humanDirections = map[string]Coordinate{
+	"q": {-1, -1}, "w": {-1, 0}, "e": {-1, 1},
+	"a": {0, -1}, "d": {0, 1},
+	"z": {1, -1}, "x": {1, 0}, "c": {1, 1},
+}
+
+// BeginTurn implements Controller.
+func (c *HumanController) BeginTurn(g *GameBoard, player int) error {
+	g.updateFogOfWarForPlayer(player)
+	fmt.Fprintf(c.out, "\nDay %d, player %d\n", g.Day, player)
+	if g.rules().Weather {
+		fmt.Fprintf(c.out, "weather: %s\n", g.getWeather().describe())
+	}
+	g.fprintGridWithUnits(c.out, player, true)
+	fmt.Fprintln(c.out, "cities: O own, E enemy, C neutral; units: upper case own, lower case enemy, * several units")
+	return nil
+}
+
+// ChooseProduction implements Controller.
+func (c *HumanController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	for {
+		fmt.Fprintf(c.out, "City at (%d, %d) should manufacture:", city.PositionX, city.PositionY)
+		for _, unitType := range g.getProductionChoices(city) {
+			fmt.Fprintf(c.out, " %d=%s", unitType, g.unitTypeToString(unitType))
+		}
+		fmt.Fprintln(c.out)
+		line, err := c.readLine()
+		if err != nil {
+			return Blank
+		}
+		unitType, ok := g.unitRules().typeFromString(line)
+		if n, err := strconv.Atoi(line); !ok && err == nil && g.unitRules().definition(UnitType(n)) != nil {
+			unitType, ok = UnitType(n), true
+		}
+		if ok && g.canCityBuild(city, unitType) {
+			return unitType
+		}
+		if ok {
+			fmt.Fprintln(c.out, "the city cannot build that unit")
+			continue
+		}
+		fmt.Fprintln(c.out, "unknown unit type")
+	}
+}
+
+// ChooseMove implements Controller.
+// A move is a direction key (q w e a d z x c), 's' to skip the unit or "x,y" coordinates.
+// "?x,y" lists everything at the coordinates without moving, "p x,y" changes what the city at
+// the coordinates manufactures and "r x,y" sets or clears its rally point.
+func (c *HumanController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	for {
+		fmt.Fprintf(c.out, "%s at (%d, %d), moves left %d, move [qweadzxc, x,y, s=skip, ?x,y=details, p x,y=production, r x,y=rally point]: ",
+			g.unitTypeToString(unit.Type), unit.PositionX, unit.PositionY, unit.MovesLeftThisDay)
+		line, err := c.readLine()
+		if err != nil {
+			return Coordinate{}, false, err
+		}
+		if line == "s" {
+			return Coordinate{}, false, nil
+		}
+		if strings.HasPrefix(line, "?") {
+			if coordinate, ok := parseHumanMove(strings.TrimPrefix(line, "?"), unit); ok {
+				g.fprintCellDetails(c.out, coordinate, unit.Player)
+				continue
+			}
+		}
+		if command, argument, found := strings.Cut(line, " "); found && (command == "p" || command == "r") {
+			if coordinate, ok := parseCoordinate(argument); ok {
+				if command == "p" {
+					err = c.changeProduction(g, unit.Player, coordinate)
+				} else {
+					err = c.changeRallyPoint(g, unit.Player, coordinate)
+				}
+				if err != nil {
+					fmt.Fprintln(c.out, err)
+				}
+				continue
+			}
+		}
+		move, ok := parseHumanMove(line, unit)
+		if ok && g.isLegalMove(move, unit) {
+			return move, true, nil
+		}
+		fmt.Fprintln(c.out, "illegal move")
+	}
+}
+
+// changeProduction asks what the player's city at the coordinate should manufacture, and
+// whether once or over and over.
+func (c *HumanController) changeProduction(g *GameBoard, player int, coordinate Coordinate) error {
+	city, err := g.getPlayersCity(player, coordinate)
+	if err != nil {
+		return err
+	}
+	unitType := c.ChooseProduction(g, city)
+	fmt.Fprintln(c.out, "build once? [y/N]")
+	line, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	return g.SetCityProduction(player, coordinate, unitType, line == "y")
+}
+
+// changeRallyPoint asks where the units produced by the player's city at the coordinate should
+// head, clearing the rally point on an empty line.
+func (c *HumanController) changeRallyPoint(g *GameBoard, player int, coordinate Coordinate) error {
+	if _, err := g.getPlayersCity(player, coordinate); err != nil {
+		return err
+	}
+	for {
+		fmt.Fprintln(c.out, "rally point x,y, or nothing to clear it:")
+		line, err := c.readLine()
+		if err != nil {
+			return err
+		}
+		if line == "" {
+			return g.SetRallyPoint(player, coordinate, nil)
+		}
+		if rallyPoint, ok := parseCoordinate(line); ok {
+			return g.SetRallyPoint(player, coordinate, &rallyPoint)
+		}
+		fmt.Fprintln(c.out, "not x,y")
+	}
+}
+
+// readLine reads a trimmed line of input.
+func (c *HumanController) readLine() (string, error) {
+	line, err := c.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// parseHumanMove parses a direction key or "x,y" coordinates into a destination.
+func parseHumanMove(line string, unit *Unit) (Coordinate, bool) {
+	if direction, ok := humanDirections[line]; ok {
+		return Coordinate{unit.PositionX + direction.PositionX, unit.PositionY + direction.PositionY}, true
+	}
+	return parseCoordinate(line)
+}
+
+// parseCoordinate parses "x,y" coordinates.
+func parseCoordinate(line string) (Coordinate, bool) {
+	parts := strings.Split(line, ",")
+	if len(parts) != 2 {
+		return Coordinate{}, false
+	}
+	x, errX := strconv.Atoi(strings.TrimSpace(parts[0]))
+	y, errY := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if errX != nil || errY != nil {
+		return Coordinate{}, false
+	}
+	return Coordinate{x, y}, true
+}
+
+// remoteRequest is a message sent to a remote controller.
+type remoteRequest struct {
+	Type   string      `json:"type"` // "turn", "production" or "move"
+	Day    int         `json:"day"`
+	Player int         `json:"player"`
+	City   *Coordinate `json:"city,omitempty"`
+	Unit   *remoteUnit `json:"unit,omitempty"`
+}
+
+// remoteUnit describes a unit to a remote controller.
+type remoteUnit struct {
+	ID        int    `json:"id"`
+	Player    int    `json:"player"`
+	Type      string `json:"type"`
+	PositionX int    `json:"x"`
+	PositionY int    `json:"y"`
+	Strength  int    `json:"strength"`
+	MovesLeft int    `json:"movesLeft"`
+	Fuel      int    `json:"fuel"`
+	Rank      string `json:"rank"` // "recruit", "veteran" or "elite"
+}
+
+// remoteResponse is a message received from a remote controller.
+type remoteResponse struct {
+	Unit string      `json:"unit,omitempty"` // answer to a "production" request
+	Move *Coordinate `json:"move,omitempty"` // answer to a "move" request, absent to skip the unit
+}
+
+// errRemoteClosed is returned when a remote controller stops responding.
+var errRemoteClosed = errors.New("remote controller closed the connection")
+
+// RemoteController is a Controller on the other end of a connection.
+// Each request is written as a line of JSON and answered with a line of JSON.
+type RemoteController struct {
+	decoder *json.Decoder
+	encoder *json.Encoder
+	player  int
+}
+
+// NewRemoteController creates a RemoteController reading responses from r and writing requests to w.
+func NewRemoteController(r io.Reader, w io.Writer) *RemoteController {
+	return &RemoteController{
+		decoder: json.NewDecoder(r),
+		encoder: json.NewEncoder(w),
+	}
+}
+
+// BeginTurn implements Controller.
+func (c *RemoteController) BeginTurn(g *GameBoard, player int) error {
+	c.player = player
+	return c.encoder.Encode(remoteRequest{Type: "turn", Day: g.Day, Player: player})
+}
+
+// ChooseProduction implements Controller.
+func (c *RemoteController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	request := remoteRequest{Type: "production", Day: g.Day, Player: c.player, City: &Coordinate{city.PositionX, city.PositionY}}
+	response, err := c.exchange(request)
+	if err != nil {
+		return Blank
+	}
+	unitType, _ := g.unitRules().typeFromString(response.Unit)
+	return unitType
+}
+
+// ChooseMove implements Controller.
+func (c *RemoteController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	request := remoteRequest{Type: "move", Day: g.Day, Player: unit.Player, Unit: g.newRemoteUnit(unit)}
+	response, err := c.exchange(request)
+	if err != nil {
+		return Coordinate{}, false, err
+	}
+	if response.Move == nil {
+		return Coordinate{}, false, nil
+	}
+	return *response.Move, true, nil
+}
+
+// exchange sends a request and waits for its response.
+func (c *RemoteController) exchange(request remoteRequest) (remoteResponse, error) {
+	var response remoteResponse
+	if err := c.encoder.Encode(request); err != nil {
+		return response, err
+	}
+	if err := c.decoder.Decode(&response); err != nil {
+		if err == io.EOF {
+			return response, errRemoteClosed
+		}
+		return response, err
+	}
+	return response, nil
+}
+
+// newRemoteUnit describes a unit for a remote controller.
+func (g *GameBoard) newRemoteUnit(unit *Unit) *remoteUnit {
+	return &remoteUnit{
+		ID:        unit.ID,
+		Player:    unit.Player,
+		Type:      g.unitTypeToString(unit.Type),
+		PositionX: unit.PositionX,
+		PositionY: unit.PositionY,
+		Strength:  unit.Strength,
+		MovesLeft: unit.MovesLeftThisDay,
+		Fuel:      unit.Fuel,
+		Rank:      rankToString(unit.Rank()),
+	}
+}
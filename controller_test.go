@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// newLandBoard returns a board where every cell is visible land,
+// with a city for each player so that neither has already won.
+func newLandBoard(rows, columns int) *GameBoard {
+	board := NewGameBoard(rows, columns)
+	board.IterateGrid(func(row, col int, cell *Cell) {
+		cell.IsLand = true
+		cell.IsFog = false
+	})
+	for player, coordinate := range []Coordinate{{0, columns - 1}, {rows - 1, columns - 1}} {
+		city := NewCity(coordinate.PositionX, coordinate.PositionY)
+		city.OccupyCity(player + 1)
+		board.Grid[city.PositionX][city.PositionY].HasCity = true
+		board.Cities = append(board.Cities, *city)
+	}
+	return board
+}
+
+func TestDoPlayerTurnScripted(t *testing.T) {
+	board := newLandBoard(4, 4)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	controller := &ScriptedController{
+		Moves: map[int][]Coordinate{
+			1: {{1, 1}, {2, 2}},
+		},
+	}
+	board.Player1 = &Player{Name: "player 1", Controller: controller}
+
+	if err := board.DoPlayerTurn(1); err != nil {
+		t.Fatalf("DoPlayerTurn() error = %v", err)
+	}
+	unit := board.Units[0]
+	if unit.PositionX != 2 || unit.PositionY != 2 {
+		t.Errorf("DoPlayerTurn() unit at (%d, %d); want (2, 2)", unit.PositionX, unit.PositionY)
+	}
+	if unit.MovesLeftThisDay != 0 {
+		t.Errorf("DoPlayerTurn() MovesLeftThisDay = %d; want 0", unit.MovesLeftThisDay)
+	}
+}
+
+func TestDoPlayerTurnIllegalMove(t *testing.T) {
+	board := newLandBoard(4, 4)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	controller := &ScriptedController{
+		Moves: map[int][]Coordinate{
+			1: {{3, 3}, {-1, 0}},
+		},
+	}
+	board.Player1 = &Player{Name: "player 1", Controller: controller}
+
+	if err := board.DoPlayerTurn(1); err != nil {
+		t.Fatalf("DoPlayerTurn() error = %v", err)
+	}
+	unit := board.Units[0]
+	if unit.PositionX != 0 || unit.PositionY != 0 {
+		t.Errorf("DoPlayerTurn() unit at (%d, %d); want (0, 0)", unit.PositionX, unit.PositionY)
+	}
+}
+
+func TestHumanControllerChooseMove(t *testing.T) {
+	board := newLandBoard(4, 4)
+	unit := NewUnit(1, 1, Tank, 1)
+	var out bytes.Buffer
+	controller := NewHumanController(strings.NewReader("nonsense\nc\n2,1\ns\n"), &out)
+
+	tests := []struct {
+		name   string
+		want   Coordinate
+		wantOk bool
+	}{
+		{name: "direction key", want: Coordinate{2, 2}, wantOk: true},
+		{name: "coordinates", want: Coordinate{2, 1}, wantOk: true},
+		{name: "skip", want: Coordinate{}, wantOk: false},
+	}
+	for _, tc := range tests {
+		got, ok, err := controller.ChooseMove(board, unit)
+		if err != nil {
+			t.Fatalf("ChooseMove(), name:%s, error = %v", tc.name, err)
+		}
+		if got != tc.want || ok != tc.wantOk {
+			t.Errorf("ChooseMove(), name:%s, got %v %t; want %v %t", tc.name, got, ok, tc.want, tc.wantOk)
+		}
+	}
+	if !strings.Contains(out.String(), "illegal move") {
+		t.Errorf("ChooseMove() did not report the illegal move")
+	}
+	if _, _, err := controller.ChooseMove(board, unit); err == nil {
+		t.Errorf("ChooseMove() at end of input, want error")
+	}
+}
+
+func TestRemoteController(t *testing.T) {
+	board := newLandBoard(4, 4)
+	unit := NewUnit(1, 1, Tank, 1)
+	unit.ID = 7
+	city := NewCity(0, 0)
+	city.OccupyCity(1)
+
+	responses := strings.NewReader(`{"unit":"Fighter"}` + "\n" + `{"move":{"PositionX":2,"PositionY":1}}` + "\n" + `{}` + "\n")
+	var requests bytes.Buffer
+	controller := NewRemoteController(responses, &requests)
+
+	if err := controller.BeginTurn(board, 1); err != nil {
+		t.Fatalf("BeginTurn() error = %v", err)
+	}
+	if got := controller.ChooseProduction(board, city); got != Fighter {
+		t.Errorf("ChooseProduction() = %s; want Fighter", unitTypeToString(got))
+	}
+	move, ok, err := controller.ChooseMove(board, unit)
+	if err != nil || !ok || move != (Coordinate{2, 1}) {
+		t.Errorf("ChooseMove() = %v %t %v; want {2 1} true <nil>", move, ok, err)
+	}
+	if _, ok, err = controller.ChooseMove(board, unit); ok || err != nil {
+		t.Errorf("ChooseMove() = %t %v; want false <nil>", ok, err)
+	}
+	if _, _, err = controller.ChooseMove(board, unit); err != errRemoteClosed {
+		t.Errorf("ChooseMove() error = %v; want %v", err, errRemoteClosed)
+	}
+
+	decoder := json.NewDecoder(&requests)
+	wantTypes := []string{"turn", "production", "move", "move", "move"}
+	for _, wantType := range wantTypes {
+		var request remoteRequest
+		if err := decoder.Decode(&request); err != nil {
+			t.Fatalf("decoding request: %v", err)
+		}
+		if request.Type != wantType {
+			t.Errorf("request type = %s; want %s", request.Type, wantType)
+		}
+		if wantType == "move" && (request.Unit == nil || request.Unit.ID != 7) {
+			t.Errorf("move request unit = %+v; want unit 7", request.Unit)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Editor edits the map and starting position of a scenario in the terminal UI.
+type Editor struct {
+	UI          *TUI
+	Board       *GameBoard
+	Path        string   // the scenario file saved to
+	CitySpacing bool     // cities may not be placed next to other cities, as on generated maps
+	UnitType    UnitType // the unit placed next
+	UnitPlayer  int      // the player owning units placed next
+
+	changed bool // there are changes which have not been saved
+}
+
+// editorHelp explains the editor's keys in the sidebar.
+var editorHelp = []string{
+	"arrows/hjkl move cursor",
+	"# land, . sea",
+	"m mountains, f forest",
+	"w shallows, i ice",
+	"c add/remove city",
+	"o city owner, p production",
+	"t unit type, 1 2 unit player",
+	"u place unit, x remove units",
+	"s save, Q quit",
+}
+
+// runEditCommand runs the "edit" command with its command line arguments.
+func runEditCommand(args []string) error {
+	flags := flag.NewFlagSet("edit", flag.ContinueOnError)
+	path := flags.String("scenario", "scenario.json", "scenario file to edit, created if it does not exist")
+	rows := flags.Int("rows", 10, "rows of a new map")
+	columns := flags.Int("columns", 20, "columns of a new map")
+	spacing := flags.Bool("spacing", true, "keep cities from being placed next to other cities")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	var board *GameBoard
+	if f, err := os.Open(*path); err == nil {
+		board, err = LoadScenario(f, 0)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	} else if errors.Is(err, os.ErrNotExist) {
+		if *rows <= 0 || *columns <= 0 {
+			return fmt.Errorf("a map of %dx%d cells is too small", *rows, *columns)
+		}
+		board = NewGameBoardWithSeed(*rows, *columns, 0)
+	} else {
+		return err
+	}
+
+	ui := NewTUI(board, os.Stdin, os.Stdout)
+	editor := NewEditor(ui, *path)
+	editor.CitySpacing = *spacing
+	ui.Start()
+	defer ui.Stop()
+	return editor.Run()
+}
+
+// NewEditor creates an editor of the UI's board, saving to path.
+func NewEditor(ui *TUI, path string) *Editor {
+	ui.Player = 0
+	ui.Help = editorHelp
+	return &Editor{UI: ui, Board: ui.Board, Path: path, CitySpacing: true, UnitType: Tank, UnitPlayer: 1}
+}
+
+// Run handles keys until the editor is quit.
+func (e *Editor) Run() error {
+	quitting := false
+	for {
+		e.UI.Prompt = fmt.Sprintf("%s, placing %s of player %d", e.Path, e.Board.unitTypeToString(e.UnitType), e.UnitPlayer)
+		if e.changed {
+			e.UI.Prompt += ", not saved"
+		}
+		e.UI.Render()
+		key, err := e.UI.readKey()
+		if err != nil {
+			return err
+		}
+		if key == "Q" {
+			if !e.changed || quitting {
+				return nil
+			}
+			fmt.Fprintln(e.UI, "there are unsaved changes, press Q again to quit without saving")
+			quitting = true
+			continue
+		}
+		quitting = false
+		if err := e.HandleKey(key); err != nil {
+			fmt.Fprintln(e.UI, err)
+		}
+	}
+}
+
+// HandleKey performs the editing command of a key on the cell under the cursor.
+func (e *Editor) HandleKey(key string) error {
+	cursor := e.UI.Cursor
+	if direction, ok := tuiLookKeys[key]; ok {
+		e.UI.moveCursor(direction)
+		return nil
+	}
+	var err error
+	switch key {
+	case "#":
+		e.setTerrain(cursor, true, TerrainPlain)
+	case ".":
+		e.setTerrain(cursor, false, TerrainPlain)
+	case "m":
+		e.setTerrain(cursor, true, TerrainMountains)
+	case "f":
+		e.setTerrain(cursor, true, TerrainForest)
+	case "w":
+		e.setTerrain(cursor, false, TerrainShallows)
+	case "i":
+		e.setTerrain(cursor, false, TerrainIce)
+	case "c":
+		err = e.toggleCity(cursor)
+	case "o":
+		err = e.cycleOwner(cursor)
+	case "p":
+		err = e.cycleProduction(cursor)
+	case "t":
+		e.UnitType = e.Board.unitRules().next(e.UnitType)
+		return nil
+	case "1", "2":
+		e.UnitPlayer = int(key[0] - '0')
+		return nil
+	case "u":
+		err = e.placeUnit(cursor)
+	case "x":
+		e.removeUnits(cursor)
+	case "s":
+		if err := e.Save(); err != nil {
+			return err
+		}
+		fmt.Fprintf(e.UI, "saved %s\n", e.Path)
+		return nil
+	default:
+		return nil
+	}
+	if err == nil {
+		e.changed = true
+		e.Board.updateCitiesNextToSea()
+	}
+	return err
+}
+
+// setTerrain paints the cell as land or sea of the terrain. A city on a cell turned to sea is
+// removed.
+func (e *Editor) setTerrain(coordinate Coordinate, isLand bool, terrain Terrain) {
+	g := e.Board
+	g.Grid[coordinate.PositionX][coordinate.PositionY].IsLand = isLand
+	g.Grid[coordinate.PositionX][coordinate.PositionY].Terrain = terrain
+	if !isLand && g.Grid[coordinate.PositionX][coordinate.PositionY].HasCity {
+		e.removeCity(coordinate)
+	}
+	e.removeStrandedUnits(coordinate)
+}
+
+// removeStrandedUnits removes the units on the cell which can no longer be there, such as
+// ships in a city which was removed.
+func (e *Editor) removeStrandedUnits(coordinate Coordinate) {
+	g := e.Board
+	units := g.Units[:0]
+	for _, unit := range g.Units {
+		if unit.PositionX != coordinate.PositionX || unit.PositionY != coordinate.PositionY || g.canBePlaced(&unit, coordinate) {
+			units = append(units, unit)
+		}
+	}
+	g.Units = units
+}
+
+// toggleCity removes the city on the cell, or adds a neutral city to a cell of land.
+func (e *Editor) toggleCity(coordinate Coordinate) error {
+	g := e.Board
+	x, y := coordinate.PositionX, coordinate.PositionY
+	if g.Grid[x][y].HasCity {
+		e.removeCity(coordinate)
+		e.removeStrandedUnits(coordinate)
+		return nil
+	}
+	if !g.Grid[x][y].IsLand {
+		return fmt.Errorf("a city cannot be built at sea")
+	}
+	excludeTargetCell := false
+	if e.CitySpacing && g.HasNeighboringCity(x, y, excludeTargetCell) {
+		return fmt.Errorf("(%d, %d) is next to another city", x, y)
+	}
+	g.Grid[x][y].HasCity = true
+	g.Cities = append(g.Cities, *g.newCity(x, y))
+	return nil
+}
+
+// removeCity removes the city on the cell.
+func (e *Editor) removeCity(coordinate Coordinate) {
+	g := e.Board
+	g.Grid[coordinate.PositionX][coordinate.PositionY].HasCity = false
+	for i, city := range g.Cities {
+		if city.PositionX == coordinate.PositionX && city.PositionY == coordinate.PositionY {
+			g.Cities = append(g.Cities[:i], g.Cities[i+1:]...)
+			return
+		}
+	}
+}
+
+// cycleOwner hands the city on the cell on: from neutral to player 1, to player 2, to neutral.
+func (e *Editor) cycleOwner(coordinate Coordinate) error {
+	city := e.Board.getCityAtCoordinates(coordinate)
+	if city == nil {
+		return fmt.Errorf("there is no city at (%d, %d)", coordinate.PositionX, coordinate.PositionY)
+	}
+	switch city.OccupyingPlayer {
+	case Unoccupied:
+		city.OccupyCity(1)
+	case OccupiedByPlayer1:
+		city.OccupyCity(2)
+	default:
+		city.OccupyCity(int(Unoccupied))
+	}
+	return nil
+}
+
+// cycleProduction changes what the player's city on the cell manufactures to the next unit type.
+func (e *Editor) cycleProduction(coordinate Coordinate) error {
+	city := e.Board.getCityAtCoordinates(coordinate)
+	if city == nil || city.OccupyingPlayer == Unoccupied {
+		return fmt.Errorf("there is no player's city at (%d, %d)", coordinate.PositionX, coordinate.PositionY)
+	}
+	city.SetManufacturingUnit(e.Board.unitRules(), e.Board.unitRules().next(city.ManufacturingUnit))
+	return nil
+}
+
+// placeUnit places a unit of the selected type and player on the cell.
+func (e *Editor) placeUnit(coordinate Coordinate) error {
+	unit := e.Board.newUnit(coordinate.PositionX, coordinate.PositionY, e.UnitType, e.UnitPlayer)
+	if !e.Board.canBePlaced(unit, coordinate) {
+		return fmt.Errorf("a %s cannot be placed at (%d, %d)", e.Board.unitTypeToString(unit.Type), coordinate.PositionX, coordinate.PositionY)
+	}
+	e.Board.addUnit(unit)
+	return nil
+}
+
+// removeUnits removes every unit on the cell.
+func (e *Editor) removeUnits(coordinate Coordinate) {
+	g := e.Board
+	units := g.Units[:0]
+	for _, unit := range g.Units {
+		if unit.PositionX != coordinate.PositionX || unit.PositionY != coordinate.PositionY {
+			units = append(units, unit)
+		}
+	}
+	g.Units = units
+}
+
+// Save writes the scenario file.
+func (e *Editor) Save() error {
+	e.Board.updateCitiesNextToSea()
+	if err := writeFile(e.Path, e.Board.WriteScenario); err != nil {
+		return err
+	}
+	e.changed = false
+	return nil
+}
+
+// canBePlaced checks if the unit can stand on the cell: any unit in a city, as ships are built
+// there, and elsewhere only where the terrain lets the unit in.
+func (g *GameBoard) canBePlaced(unit *Unit, coordinate Coordinate) bool {
+	if g.Grid[coordinate.PositionX][coordinate.PositionY].HasCity {
+		return true
+	}
+	return g.canEnterTerrain(coordinate, unit)
+}
+
+// updateCitiesNextToSea works out again which cities are next to the sea, after the map changed.
+func (g *GameBoard) updateCitiesNextToSea() {
+	for i := range g.Cities {
+		g.Cities[i].IsCityNextToSea = g.IsCityNextToSea(g.Cities[i].PositionX, g.Cities[i].PositionY)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newIslandBoard returns a board of land without cities.
+func newIslandBoard(rows, columns int) *GameBoard {
+	board := NewGameBoard(rows, columns)
+	board.IterateGrid(func(row, col int, cell *Cell) {
+		cell.IsLand = true
+	})
+	return board
+}
+
+func TestEditorRun(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	board := NewGameBoardWithSeed(3, 4, 1)
+	// land with a city of player 1 at (0, 0), a neutral city at (0, 2) and player 1's tank at (0, 1)
+	ui := NewTUI(board, strings.NewReader("#col#ul#cs"+"Q"), io.Discard)
+	editor := NewEditor(ui, path)
+	if err := editor.Run(); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Run() did not save the scenario: %v", err)
+	}
+	defer f.Close()
+	scenario, err := ReadScenario(f)
+	if err != nil {
+		t.Fatalf("ReadScenario() of the saved scenario error = %v", err)
+	}
+	if want := []string{"1LCS", "SSSS", "SSSS"}; strings.Join(scenario.Map, ",") != strings.Join(want, ",") {
+		t.Errorf("Run() saved map %v; want %v", scenario.Map, want)
+	}
+	if len(scenario.Units) != 1 || scenario.Units[0] != (ScenarioUnit{PositionX: 0, PositionY: 1, Player: 1, Type: "Tank"}) {
+		t.Errorf("Run() saved units %+v; want player 1's tank at (0, 1)", scenario.Units)
+	}
+}
+
+func TestEditorQuitWithoutSaving(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	ui := NewTUI(NewGameBoardWithSeed(3, 4, 1), strings.NewReader("#Q"), io.Discard)
+	if err := NewEditor(ui, path).Run(); err != io.EOF {
+		t.Errorf("Run() quit with unsaved changes after one Q, error = %v; want io.EOF", err)
+	}
+
+	ui = NewTUI(NewGameBoardWithSeed(3, 4, 1), strings.NewReader("#QQ"), io.Discard)
+	if err := NewEditor(ui, path).Run(); err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Run() saved the scenario without being asked to")
+	}
+}
+
+func TestEditorCities(t *testing.T) {
+	board := newIslandBoard(5, 5)
+	editor := NewEditor(NewTUI(board, strings.NewReader(""), io.Discard), "")
+
+	if err := editor.toggleCity(Coordinate{2, 2}); err != nil {
+		t.Fatalf("toggleCity() error = %v", err)
+	}
+	if err := editor.toggleCity(Coordinate{2, 3}); err == nil {
+		t.Errorf("toggleCity() next to a city, got no error; want an error")
+	}
+	editor.CitySpacing = false
+	if err := editor.toggleCity(Coordinate{2, 3}); err != nil {
+		t.Errorf("toggleCity() next to a city without spacing, error = %v", err)
+	}
+
+	if err := editor.cycleOwner(Coordinate{2, 2}); err != nil {
+		t.Fatalf("cycleOwner() error = %v", err)
+	}
+	if err := editor.cycleProduction(Coordinate{2, 2}); err != nil {
+		t.Fatalf("cycleProduction() error = %v", err)
+	}
+	city := board.getCityAtCoordinates(Coordinate{2, 2})
+	if city.OccupyingPlayer != OccupiedByPlayer1 || city.ManufacturingUnit != Tank {
+		t.Errorf("cycleOwner() and cycleProduction(), city = %+v; want player 1's city making tanks", city)
+	}
+	if err := editor.cycleProduction(Coordinate{2, 3}); err == nil {
+		t.Errorf("cycleProduction() of a neutral city, got no error; want an error")
+	}
+
+	editor.setTerrain(Coordinate{2, 3}, false, TerrainPlain)
+	if board.Grid[2][3].HasCity || len(board.Cities) != 1 {
+		t.Errorf("setTerrain() to sea left the city at (2, 3)")
+	}
+	if err := editor.toggleCity(Coordinate{2, 3}); err == nil {
+		t.Errorf("toggleCity() at sea, got no error; want an error")
+	}
+}
+
+func TestEditorUnits(t *testing.T) {
+	board := newIslandBoard(5, 5)
+	board.Grid[0][0].IsLand = false
+	editor := NewEditor(NewTUI(board, strings.NewReader(""), io.Discard), "")
+
+	type test struct {
+		name       string
+		unitType   UnitType
+		coordinate Coordinate
+		wantErr    bool
+	}
+	tests := []test{
+		{name: "tank on land", unitType: Tank, coordinate: Coordinate{2, 2}},
+		{name: "tank at sea", unitType: Tank, coordinate: Coordinate{0, 0}, wantErr: true},
+		{name: "destroyer on land", unitType: Destroyer, coordinate: Coordinate{2, 2}, wantErr: true},
+		{name: "destroyer at sea", unitType: Destroyer, coordinate: Coordinate{0, 0}},
+		{name: "fighter on land", unitType: Fighter, coordinate: Coordinate{3, 3}},
+	}
+	for _, tc := range tests {
+		editor.UnitType = tc.unitType
+		if err := editor.placeUnit(tc.coordinate); (err != nil) != tc.wantErr {
+			t.Errorf("placeUnit(), name:%s, got error %v; want error %t", tc.name, err, tc.wantErr)
+		}
+	}
+	if len(board.Units) != 3 {
+		t.Fatalf("placeUnit() placed %d units; want 3", len(board.Units))
+	}
+
+	editor.setTerrain(Coordinate{0, 0}, true, TerrainPlain)
+	if len(board.Units) != 2 {
+		t.Errorf("setTerrain() to land left the destroyer on it")
+	}
+	editor.removeUnits(Coordinate{2, 2})
+	if len(board.Units) != 1 || board.Units[0].Type != Fighter {
+		t.Errorf("removeUnits() left units %+v; want only the fighter", board.Units)
+	}
+}
+
+func TestEditorCityNextToSea(t *testing.T) {
+	board := newIslandBoard(5, 5)
+	editor := NewEditor(NewTUI(board, strings.NewReader(""), io.Discard), "")
+	editor.UI.Cursor = Coordinate{2, 2}
+	for _, key := range []string{"c", "up", "."} {
+		if err := editor.HandleKey(key); err != nil {
+			t.Fatalf("HandleKey(%q) error = %v", key, err)
+		}
+	}
+	if !board.Cities[0].IsCityNextToSea {
+		t.Errorf("HandleKey() painting sea next to a city did not make it a port")
+	}
+}
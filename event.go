@@ -0,0 +1,66 @@
+package main
+
+// EventType represents the type of something that happened in the game.
+type EventType int
+
+const (
+	// EventDayStarted is emitted at the start of each day
+	EventDayStarted EventType = iota
+	// EventUnitProduced is emitted when a city finishes manufacturing a unit
+	EventUnitProduced
+	// EventUnitDestroyed is emitted when a unit is destroyed
+	EventUnitDestroyed
+	// EventCityCaptured is emitted when a player captures a city
+	EventCityCaptured
+	// EventTurnEnded is emitted at the end of each player's turn
+	EventTurnEnded
+	// EventUnitPromoted is emitted when a unit rises a rank
+	EventUnitPromoted
+)
+
+// Event describes something that happened in the game.
+type Event struct {
+	Type       EventType
+	Day        int
+	Player     int      // the player the event happened to
+	UnitType   UnitType // the unit involved, if any
+	Coordinate Coordinate
+}
+
+// AddEventListener registers a function to be called with every event of the game.
+func (g *GameBoard) AddEventListener(listener func(Event)) {
+	g.listeners = append(g.listeners, listener)
+}
+
+// emit passes an event to all listeners.
+func (g *GameBoard) emit(eventType EventType, player int, unitType UnitType, coordinate Coordinate) {
+	event := Event{
+		Type:       eventType,
+		Day:        g.Day,
+		Player:     player,
+		UnitType:   unitType,
+		Coordinate: coordinate,
+	}
+	for _, listener := range g.listeners {
+		listener(event)
+	}
+}
+
+func eventTypeToString(eventType EventType) string {
+	switch eventType {
+	case EventDayStarted:
+		return "DayStarted"
+	case EventUnitProduced:
+		return "UnitProduced"
+	case EventUnitDestroyed:
+		return "UnitDestroyed"
+	case EventCityCaptured:
+		return "CityCaptured"
+	case EventTurnEnded:
+		return "TurnEnded"
+	case EventUnitPromoted:
+		return "UnitPromoted"
+	default:
+		return "Unknown"
+	}
+}
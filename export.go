@@ -0,0 +1,460 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImageOptions configures an image of the board.
+type ImageOptions struct {
+	Player   int          // draw the board through this player's fog of war, 0 to show everything
+	CellSize int          // pixels per cell, defaultCellSize when 0
+	Route    []Coordinate // drawn as a line through the cells, e.g. a route from FindPath
+	Moves    []Coordinate // cells outlined as possible moves, e.g. from getLegalMoves
+
+	// Territory shades land by the owner of the nearest city on the same island, so front lines show.
+	Territory bool
+}
+
+const defaultCellSize = 20
+
+// Colours of exported images.
+var (
+	imageSea      = color.RGBA{0x2a, 0x5c, 0xaa, 0xff}
+	imageLand     = color.RGBA{0x3c, 0x8c, 0x3c, 0xff}
+	imageFog      = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	imageMountain = color.RGBA{0x8a, 0x6e, 0x4b, 0xff}
+	imageForest   = color.RGBA{0x1e, 0x5a, 0x28, 0xff}
+	imageShallows = color.RGBA{0x4a, 0x9c, 0xd0, 0xff}
+	imageIce      = color.RGBA{0xe8, 0xf0, 0xf8, 0xff}
+	imageNeutral  = color.RGBA{0xee, 0xee, 0xee, 0xff}
+	imagePlayer1  = color.RGBA{0xf0, 0xc0, 0x20, 0xff}
+	imagePlayer2  = color.RGBA{0xd0, 0x40, 0xd0, 0xff}
+	imageGlyph    = color.RGBA{0x10, 0x10, 0x10, 0xff}
+	imageRoute    = color.RGBA{0xff, 0x30, 0x30, 0xff}
+	imageMove     = color.RGBA{0xff, 0xff, 0xff, 0xff}
+
+	imageTerritory1 = color.RGBA{0x96, 0xa6, 0x2e, 0xff} // land half way to player 1's colour
+	imageTerritory2 = color.RGBA{0x86, 0x66, 0x86, 0xff} // land half way to player 2's colour
+)
+
+// unitGlyphs are 5x7 bitmaps of the unit symbols, for drawing units without a font.
+var unitGlyphs = map[string][7]string{
+	"T": {"#####", "..#..", "..#..", "..#..", "..#..", "..#..", "..#.."},
+	"F": {"#####", "#....", "#....", "####.", "#....", "#....", "#...."},
+	"B": {"####.", "#...#", "#...#", "####.", "#...#", "#...#", "####."},
+	"R": {"####.", "#...#", "#...#", "####.", "#.#..", "#..#.", "#...#"},
+	"D": {"####.", "#...#", "#...#", "#...#", "#...#", "#...#", "####."},
+	"U": {"#...#", "#...#", "#...#", "#...#", "#...#", "#...#", ".###."},
+	"N": {"#...#", "##..#", "#.#.#", "#..##", "#...#", "#...#", "#...#"},
+	"Y": {"#...#", "#...#", ".#.#.", "..#..", "..#..", "..#..", "..#.."},
+	"?": {".###.", "#...#", "....#", "...#.", "..#..", ".....", "..#.."},
+}
+
+// runExportCommand runs the "export" command with its command line arguments.
+func runExportCommand(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	gamePath := flags.String("game", "", "saved game or play-by-email game file to export, a new random game when empty")
+	mapFlags := addMapFlags(flags)
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of a new game")
+	days := flags.Int("days", 0, "days the AI plays a new game before it is exported")
+	player := flags.Int("player", 0, "draw the board through this player's fog of war, 0 to show everything")
+	territory := flags.Bool("territory", false, "shade land by the owner of the nearest city")
+	cellSize := flags.Int("cellSize", defaultCellSize, "pixels per cell")
+	unitID := flags.Int("unit", 0, "ID of a unit whose possible moves are shown")
+	to := flags.String("to", "", "x,y destination of the unit's route found with FindPath")
+	svgPath := flags.String("svg", "", "write an SVG image to this file")
+	pngPath := flags.String("png", "", "write a PNG image to this file")
+	scenarioPath := flags.String("writeScenario", "", "write the board as a scenario file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *svgPath == "" && *pngPath == "" && *scenarioPath == "" {
+		return errors.New("nothing to export, use -svg, -png or -writeScenario")
+	}
+
+	var board *GameBoard
+	if *gamePath != "" {
+		var err error
+		if board, err = readGameFile(*gamePath); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if board, err = mapFlags.newGameBoard(*seed); err != nil {
+			return err
+		}
+		board.Output = io.Discard
+		board.DayZero()
+		if *days > 0 {
+			board.PlayGame(*days)
+		}
+	}
+
+	options := ImageOptions{Player: *player, CellSize: *cellSize, Territory: *territory}
+	if *unitID != 0 {
+		unit := board.getUnitByID(*unitID)
+		if unit == nil {
+			return fmt.Errorf("there is no unit %d", *unitID)
+		}
+		options.Moves = board.getLegalMoves(unit)
+		if *to != "" {
+			target, ok := parseHumanMove(*to, unit)
+			if !ok {
+				return fmt.Errorf("invalid destination %q, want x,y", *to)
+			}
+			if options.Route = board.FindPath(target, unit); options.Route == nil {
+				return fmt.Errorf("unit %d has no route to (%d, %d)", *unitID, target.PositionX, target.PositionY)
+			}
+		}
+	}
+	if *svgPath != "" {
+		if err := writeFile(*svgPath, func(w io.Writer) error { return board.WriteSVG(w, options) }); err != nil {
+			return err
+		}
+	}
+	if *pngPath != "" {
+		if err := writeFile(*pngPath, func(w io.Writer) error { return board.WritePNG(w, options) }); err != nil {
+			return err
+		}
+	}
+	if *scenarioPath != "" {
+		if err := writeFile(*scenarioPath, board.WriteScenario); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readGameFile reads a game saved with SaveGame, or a play-by-email game file.
+func readGameFile(path string) (*GameBoard, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(data), `"nextPlayer"`) {
+		game, err := ReadPlayByEmailGame(strings.NewReader(string(data)))
+		if err != nil {
+			return nil, err
+		}
+		return game.Board, nil
+	}
+	return LoadGame(strings.NewReader(string(data)))
+}
+
+// cellSize returns the pixels per cell.
+func (o ImageOptions) cellSize() int {
+	if o.CellSize <= 0 {
+		return defaultCellSize
+	}
+	return o.CellSize
+}
+
+// imageCell is what an exported image shows in one cell.
+type imageCell struct {
+	terrain color.RGBA
+	city    *color.RGBA // owner colour of a city, nil when there is none
+	unit    *Unit       // the unit drawn, nil when there is none
+	stacked bool        // more than one unit is in the cell
+}
+
+// getImageCell returns what the image shows at the coordinate.
+// territory is the owner of each cell from getTerritory, or nil to leave land unshaded.
+func (g *GameBoard) getImageCell(coordinate Coordinate, player int, territory [][]CityState) imageCell {
+	x, y := coordinate.PositionX, coordinate.PositionY
+	if player != 0 && g.isFogForPlayer(player, x, y) {
+		return imageCell{terrain: imageFog}
+	}
+	c := imageCell{terrain: imageSea}
+	switch cell := g.Grid[x][y]; {
+	case cell.Terrain == TerrainMountains:
+		c.terrain = imageMountain
+	case cell.Terrain == TerrainForest:
+		c.terrain = imageForest
+	case cell.Terrain == TerrainShallows:
+		c.terrain = imageShallows
+	case cell.Terrain == TerrainIce:
+		c.terrain = imageIce
+	case cell.IsLand:
+		c.terrain = imageLand
+		if territory != nil {
+			switch territory[x][y] {
+			case OccupiedByPlayer1:
+				c.terrain = imageTerritory1
+			case OccupiedByPlayer2:
+				c.terrain = imageTerritory2
+			}
+		}
+	}
+	if city := g.getCityAtCoordinates(coordinate); city != nil {
+		owner := imagePlayerColour(int(city.OccupyingPlayer))
+		c.city = &owner
+	}
+	units := g.visibleUnitsAt(player, coordinate)
+	if len(units) > 0 {
+		c.unit = &units[0]
+		for i := range units {
+			if units[i].Player == player {
+				c.unit = &units[i] // show the player's own unit on top
+				break
+			}
+		}
+	}
+	c.stacked = len(units) > 1
+	return c
+}
+
+// territory returns the owner of each cell if the image shades territory, or nil.
+func (g *GameBoard) territory(options ImageOptions) [][]CityState {
+	if !options.Territory {
+		return nil
+	}
+	return g.getTerritory()
+}
+
+// getTerritory returns the owner of the nearest city, counting steps over land, for every land cell.
+// Cells on islands without cities are Unoccupied, as are cells nearest to a neutral city.
+func (g *GameBoard) getTerritory() [][]CityState {
+	territory := make([][]CityState, g.Rows)
+	for i := range territory {
+		territory[i] = make([]CityState, g.Columns)
+	}
+	visited := g.newVisitedGrid()
+	var queue []Coordinate
+	for _, city := range g.Cities {
+		if !visited[city.PositionX][city.PositionY] {
+			visited[city.PositionX][city.PositionY] = true
+			territory[city.PositionX][city.PositionY] = city.OccupyingPlayer
+			queue = append(queue, Coordinate{city.PositionX, city.PositionY})
+		}
+	}
+	// breadth first from every city at once, so each cell is reached first from its nearest city
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for i := current.PositionX - 1; i <= current.PositionX+1; i++ {
+			for j := current.PositionY - 1; j <= current.PositionY+1; j++ {
+				if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns && !visited[i][j] && g.Grid[i][j].IsLand {
+					visited[i][j] = true
+					territory[i][j] = territory[current.PositionX][current.PositionY]
+					queue = append(queue, Coordinate{i, j})
+				}
+			}
+		}
+	}
+	return territory
+}
+
+// imagePlayerColour returns the colour of a player, or of neutral cities.
+func imagePlayerColour(player int) color.RGBA {
+	switch player {
+	case 1:
+		return imagePlayer1
+	case 2:
+		return imagePlayer2
+	default:
+		return imageNeutral
+	}
+}
+
+// svgColour returns the colour as an SVG colour.
+func svgColour(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// cellRect returns the pixels of the cell in the row and column of an image with cells of the size.
+func cellRect(row, column, size int) image.Rectangle {
+	return image.Rect(column*size, row*size, (column+1)*size, (row+1)*size)
+}
+
+// rankBars returns the bars marking the unit's rank along the bottom of its cell, one for each
+// rank above recruit.
+func rankBars(unit *Unit, cell image.Rectangle) []image.Rectangle {
+	size := cell.Dx()
+	width, height := size/5+1, size/8+1
+	var bars []image.Rectangle
+	for rank := 0; rank < int(unit.Rank()); rank++ {
+		left := cell.Min.X + size/8 + rank*(width+size/10+1)
+		bars = append(bars, image.Rect(left, cell.Max.Y-1-height, left+width, cell.Max.Y-1))
+	}
+	return bars
+}
+
+// WriteSVG writes an SVG image of the board to w.
+func (g *GameBoard) WriteSVG(w io.Writer, options ImageOptions) error {
+	size := options.cellSize()
+	territory := g.territory(options)
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		g.Columns*size, g.Rows*size, g.Columns*size, g.Rows*size)
+	fmt.Fprintf(&sb, "<title>Day %d</title>\n", g.Day)
+	for i := 0; i < g.Rows; i++ {
+		for j := 0; j < g.Columns; j++ {
+			c := g.getImageCell(Coordinate{i, j}, options.Player, territory)
+			left, top := j*size, i*size
+			fmt.Fprintf(&sb, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", left, top, size, size, svgColour(c.terrain))
+			if c.city != nil {
+				fmt.Fprintf(&sb, `<rect class="city" x="%d" y="%d" width="%d" height="%d" fill="%s" stroke="%s"/>`+"\n",
+					left+size/8, top+size/8, size-size/4, size-size/4, svgColour(*c.city), svgColour(imageGlyph))
+			}
+			if c.unit != nil {
+				fmt.Fprintf(&sb, `<circle class="unit" cx="%d" cy="%d" r="%d" fill="%s" stroke="%s"/>`+"\n",
+					left+size/2, top+size/2, size*3/8, svgColour(imagePlayerColour(c.unit.Player)), svgColour(imageGlyph))
+				fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="monospace" font-size="%d" font-weight="bold" text-anchor="middle" dominant-baseline="central" fill="%s">%s</text>`+"\n",
+					left+size/2, top+size/2, size/2, svgColour(imageGlyph), g.unitRules().symbol(c.unit.Type))
+				for _, bar := range rankBars(c.unit, cellRect(i, j, size)) {
+					fmt.Fprintf(&sb, `<rect class="rank" x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+						bar.Min.X, bar.Min.Y, bar.Dx(), bar.Dy(), svgColour(imageMove))
+				}
+			}
+			if c.stacked {
+				fmt.Fprintf(&sb, `<circle class="stack" cx="%d" cy="%d" r="%d" fill="%s"/>`+"\n",
+					left+size-size/6, top+size/6, size/10+1, svgColour(imageMove))
+			}
+		}
+	}
+	for _, move := range options.Moves {
+		fmt.Fprintf(&sb, `<rect class="move" x="%d" y="%d" width="%d" height="%d" fill="none" stroke="%s" stroke-width="2" stroke-dasharray="3,2"/>`+"\n",
+			move.PositionY*size+1, move.PositionX*size+1, size-2, size-2, svgColour(imageMove))
+	}
+	if len(options.Route) > 0 {
+		var points []string
+		for _, step := range options.Route {
+			points = append(points, strconv.Itoa(step.PositionY*size+size/2)+","+strconv.Itoa(step.PositionX*size+size/2))
+		}
+		fmt.Fprintf(&sb, `<polyline class="route" points="%s" fill="none" stroke="%s" stroke-width="3" stroke-linejoin="round"/>`+"\n",
+			strings.Join(points, " "), svgColour(imageRoute))
+	}
+	sb.WriteString("</svg>\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// RenderImage draws the board as an image.
+func (g *GameBoard) RenderImage(options ImageOptions) *image.RGBA {
+	size := options.cellSize()
+	territory := g.territory(options)
+	img := image.NewRGBA(image.Rect(0, 0, g.Columns*size, g.Rows*size))
+	for i := 0; i < g.Rows; i++ {
+		for j := 0; j < g.Columns; j++ {
+			c := g.getImageCell(Coordinate{i, j}, options.Player, territory)
+			cell := cellRect(i, j, size)
+			draw.Draw(img, cell, image.NewUniform(c.terrain), image.Point{}, draw.Src)
+			if c.city != nil {
+				inset := cell.Inset(size / 8)
+				draw.Draw(img, inset, image.NewUniform(imageGlyph), image.Point{}, draw.Src)
+				draw.Draw(img, inset.Inset(1), image.NewUniform(*c.city), image.Point{}, draw.Src)
+			}
+			if c.unit != nil {
+				fillCircle(img, cell.Min.X+size/2, cell.Min.Y+size/2, size*3/8, imagePlayerColour(c.unit.Player))
+				drawGlyph(img, cell, g.unitRules().symbol(c.unit.Type), imageGlyph)
+				for _, bar := range rankBars(c.unit, cell) {
+					draw.Draw(img, bar, image.NewUniform(imageMove), image.Point{}, draw.Src)
+				}
+			}
+			if c.stacked {
+				fillCircle(img, cell.Max.X-size/6, cell.Min.Y+size/6, size/10+1, imageMove)
+			}
+		}
+	}
+	for _, move := range options.Moves {
+		r := image.Rect(move.PositionY*size, move.PositionX*size, (move.PositionY+1)*size, (move.PositionX+1)*size).Inset(1)
+		drawOutline(img, r, imageMove)
+	}
+	for k := 1; k < len(options.Route); k++ {
+		from, to := options.Route[k-1], options.Route[k]
+		drawLine(img, from.PositionY*size+size/2, from.PositionX*size+size/2,
+			to.PositionY*size+size/2, to.PositionX*size+size/2, imageRoute)
+	}
+	return img
+}
+
+// WritePNG writes a PNG image of the board to w.
+func (g *GameBoard) WritePNG(w io.Writer, options ImageOptions) error {
+	return png.Encode(w, g.RenderImage(options))
+}
+
+// fillCircle draws a filled circle.
+func fillCircle(img *image.RGBA, cx, cy, r int, c color.RGBA) {
+	for y := cy - r; y <= cy+r; y++ {
+		for x := cx - r; x <= cx+r; x++ {
+			if (x-cx)*(x-cx)+(y-cy)*(y-cy) <= r*r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// drawGlyph draws a unit symbol in the middle of the cell, scaled to fit.
+func drawGlyph(img *image.RGBA, cell image.Rectangle, symbol string, c color.RGBA) {
+	glyph, ok := unitGlyphs[symbol]
+	if !ok {
+		glyph = unitGlyphs["?"]
+	}
+	scale := cell.Dx() / 10
+	if scale < 1 {
+		scale = 1
+	}
+	left := cell.Min.X + (cell.Dx()-5*scale)/2
+	top := cell.Min.Y + (cell.Dy()-7*scale)/2
+	for row, line := range glyph {
+		for col, pixel := range line {
+			if pixel == '#' {
+				draw.Draw(img, image.Rect(left+col*scale, top+row*scale, left+(col+1)*scale, top+(row+1)*scale),
+					image.NewUniform(c), image.Point{}, draw.Src)
+			}
+		}
+	}
+}
+
+// drawOutline draws the border of a rectangle, two pixels wide.
+func drawOutline(img *image.RGBA, r image.Rectangle, c color.RGBA) {
+	for x := r.Min.X; x < r.Max.X; x++ {
+		for _, y := range []int{r.Min.Y, r.Min.Y + 1, r.Max.Y - 2, r.Max.Y - 1} {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for _, x := range []int{r.Min.X, r.Min.X + 1, r.Max.X - 2, r.Max.X - 1} {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawLine draws a line three pixels wide between two points.
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.RGBA) {
+	steps := abs(x1 - x0)
+	if abs(y1-y0) > steps {
+		steps = abs(y1 - y0)
+	}
+	for k := 0; k <= steps; k++ {
+		x, y := x0, y0
+		if steps > 0 {
+			x = x0 + (x1-x0)*k/steps
+			y = y0 + (y1-y0)*k/steps
+		}
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				img.SetRGBA(x+dx, y+dy, c)
+			}
+		}
+	}
+}
+
+// abs returns the absolute value of n.
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
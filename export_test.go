@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newExportBoard returns a 3x4 board with sea in the first column, a tank of each player
+// and two fighters of player 1 stacked in one cell.
+func newExportBoard() *GameBoard {
+	board := newLandBoard(3, 4)
+	for i := 0; i < board.Rows; i++ {
+		board.Grid[i][0].IsLand = false
+	}
+	board.addUnit(NewUnit(1, 1, Tank, 1))
+	board.addUnit(NewUnit(1, 3, Tank, 2))
+	board.addUnit(NewUnit(2, 2, Fighter, 1))
+	board.addUnit(NewUnit(2, 2, Fighter, 1))
+	return board
+}
+
+func TestWriteSVG(t *testing.T) {
+	board := newExportBoard()
+	unit := &board.Units[0]
+	unit.Battles = eliteBattles
+	options := ImageOptions{
+		CellSize: 10,
+		Route:    board.FindPath(Coordinate{0, 2}, unit),
+		Moves:    board.getLegalMoves(unit),
+	}
+	var buf bytes.Buffer
+	if err := board.WriteSVG(&buf, options); err != nil {
+		t.Fatalf("WriteSVG() error = %v", err)
+	}
+	svg := buf.String()
+
+	decoder := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("WriteSVG() wrote invalid XML: %v", err)
+		}
+	}
+	type test struct {
+		name  string
+		class string
+		want  int
+	}
+	tests := []test{
+		{name: "cities", class: `class="city"`, want: 2},
+		{name: "units", class: `class="unit"`, want: 3},
+		{name: "stacks", class: `class="stack"`, want: 1},
+		{name: "ranks of the elite tank", class: `class="rank"`, want: 2},
+		{name: "moves", class: `class="move"`, want: len(options.Moves)},
+		{name: "route", class: `class="route"`, want: 1},
+	}
+	for _, tc := range tests {
+		if got := strings.Count(svg, tc.class); got != tc.want {
+			t.Errorf("WriteSVG(), name:%s, got %d; want %d", tc.name, got, tc.want)
+		}
+	}
+	if !strings.Contains(svg, `width="40" height="30"`) {
+		t.Errorf("WriteSVG() image is not 40x30 pixels: %s", svg)
+	}
+	if !strings.Contains(svg, svgColour(imagePlayer2)) {
+		t.Errorf("WriteSVG() does not show player 2's colour")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	board := newExportBoard()
+	board.Units[0].Battles = veteranBattles
+	board.updateFogOfWarForPlayer(1)
+	options := ImageOptions{
+		Player:   1,
+		CellSize: 10,
+		Route:    []Coordinate{{1, 1}, {1, 2}},
+	}
+	var buf bytes.Buffer
+	if err := board.WritePNG(&buf, options); err != nil {
+		t.Fatalf("WritePNG() error = %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+	if bounds := img.Bounds(); bounds.Dx() != 40 || bounds.Dy() != 30 {
+		t.Fatalf("WritePNG() image is %dx%d; want 40x30", bounds.Dx(), bounds.Dy())
+	}
+
+	type test struct {
+		name string
+		x, y int
+		want color.RGBA
+	}
+	tests := []test{
+		{name: "sea", x: 1, y: 1, want: imageSea},
+		{name: "land", x: 11, y: 1, want: imageLand},
+		{name: "route", x: 20, y: 15, want: imageRoute},
+		{name: "city", x: 32, y: 5, want: imagePlayer1},
+		{name: "tank", x: 12, y: 15, want: imagePlayer1},
+		{name: "veteran tank's rank", x: 12, y: 18, want: imageMove},
+		{name: "no second rank", x: 17, y: 18, want: imageLand},
+		{name: "enemy tank", x: 32, y: 15, want: imagePlayer2}, // in sight of player 1's city
+	}
+	for _, tc := range tests {
+		got := color.RGBAModel.Convert(img.At(tc.x, tc.y)).(color.RGBA)
+		if got != tc.want {
+			t.Errorf("WritePNG(), name:%s, pixel (%d, %d) = %v; want %v", tc.name, tc.x, tc.y, got, tc.want)
+		}
+	}
+
+	// player 2 has not seen the sea in the first column
+	board.updateFogOfWarForPlayer(2)
+	fogged := board.RenderImage(ImageOptions{Player: 2, CellSize: 10})
+	if got := fogged.RGBAAt(1, 1); got != imageFog {
+		t.Errorf("RenderImage() for player 2, pixel (1, 1) = %v; want the fog of war %v", got, imageFog)
+	}
+}
+
+func TestGetLegalMoves(t *testing.T) {
+	board := newExportBoard()
+	moves := board.getLegalMoves(&board.Units[0])
+	want := []Coordinate{{0, 1}, {0, 2}, {1, 2}, {2, 1}, {2, 2}}
+	if len(moves) != len(want) {
+		t.Fatalf("getLegalMoves() = %v; want %v", moves, want)
+	}
+	for i := range want {
+		if moves[i] != want[i] {
+			t.Errorf("getLegalMoves() = %v; want %v", moves, want)
+			break
+		}
+	}
+}
+
+func TestGetTerritory(t *testing.T) {
+	board := newLandBoard(3, 7)
+	// sea in column 3 splits the board into two islands; the western island has no city.
+	// (2, 4) is as near to both cities, and goes to the first.
+	for i := 0; i < board.Rows; i++ {
+		board.Grid[i][3].IsLand = false
+	}
+	territory := board.getTerritory()
+	want := [][]CityState{
+		{Unoccupied, Unoccupied, Unoccupied, Unoccupied, OccupiedByPlayer1, OccupiedByPlayer1, OccupiedByPlayer1},
+		{Unoccupied, Unoccupied, Unoccupied, Unoccupied, OccupiedByPlayer1, OccupiedByPlayer1, OccupiedByPlayer1},
+		{Unoccupied, Unoccupied, Unoccupied, Unoccupied, OccupiedByPlayer1, OccupiedByPlayer2, OccupiedByPlayer2},
+	}
+	for i := range want {
+		for j := range want[i] {
+			if territory[i][j] != want[i][j] {
+				t.Errorf("getTerritory() = %v; want %v", territory, want)
+				return
+			}
+		}
+	}
+}
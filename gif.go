@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+)
+
+// gifPalette holds every colour of exported images.
+var gifPalette = color.Palette{
+	imageSea, imageLand, imageFog, imageNeutral, imagePlayer1, imagePlayer2,
+	imageGlyph, imageRoute, imageMove, imageTerritory1, imageTerritory2,
+	imageMountain, imageForest, imageShallows, imageIce,
+}
+
+// GIFRecorder captures frames of a game and writes them as an animated GIF.
+type GIFRecorder struct {
+	Options   ImageOptions // how each frame is drawn
+	Delay     int          // delay after each frame, in hundredths of a second
+	EveryTurn bool         // capture a frame after each player's turn, rather than after each day
+
+	frames []*image.Paletted
+}
+
+// NewGIFRecorder creates a recorder shading territory, with half a second per frame.
+func NewGIFRecorder() *GIFRecorder {
+	return &GIFRecorder{
+		Options: ImageOptions{CellSize: 10, Territory: true},
+		Delay:   50,
+	}
+}
+
+// Attach captures a frame of the board at the end of every day, or every turn, and when a
+// player wins.
+func (r *GIFRecorder) Attach(g *GameBoard) {
+	g.AddEventListener(func(event Event) {
+		if event.Type == EventTurnEnded && (r.EveryTurn || event.Player == 2 || g.hasPlayerWon(event.Player)) {
+			r.Capture(g)
+		}
+	})
+}
+
+// Capture adds a frame showing the board as it is now.
+func (r *GIFRecorder) Capture(g *GameBoard) {
+	img := g.RenderImage(r.Options)
+	frame := image.NewPaletted(img.Bounds(), gifPalette)
+	draw.Draw(frame, frame.Bounds(), img, image.Point{}, draw.Src)
+	r.frames = append(r.frames, frame)
+}
+
+// Frames returns the number of frames captured.
+func (r *GIFRecorder) Frames() int {
+	return len(r.frames)
+}
+
+// WriteGIF writes the frames as an animated GIF, looping forever and pausing on the last frame.
+func (r *GIFRecorder) WriteGIF(w io.Writer) error {
+	if len(r.frames) == 0 {
+		return errors.New("no frames were captured")
+	}
+	animation := &gif.GIF{Image: r.frames}
+	for i := range r.frames {
+		delay := r.Delay
+		if i == len(r.frames)-1 {
+			delay *= 4
+		}
+		animation.Delay = append(animation.Delay, delay)
+	}
+	return gif.EncodeAll(w, animation)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"io"
+	"testing"
+)
+
+func TestGIFRecorder(t *testing.T) {
+	type test struct {
+		name       string
+		everyTurn  bool
+		wantFrames int
+	}
+	tests := []test{
+		{name: "every day", everyTurn: false, wantFrames: 4},
+		{name: "every turn", everyTurn: true, wantFrames: 7},
+	}
+	for _, tc := range tests {
+		board := newLandBoard(4, 6)
+		board.Output = io.Discard
+		board.Player1 = &Player{Controller: &ScriptedController{}}
+		board.Player2 = &Player{Controller: &ScriptedController{}}
+		recorder := NewGIFRecorder()
+		recorder.EveryTurn = tc.everyTurn
+		recorder.Capture(board)
+		recorder.Attach(board)
+		board.PlayGame(3)
+
+		var buf bytes.Buffer
+		if err := recorder.WriteGIF(&buf); err != nil {
+			t.Fatalf("WriteGIF(), name:%s, error = %v", tc.name, err)
+		}
+		animation, err := gif.DecodeAll(&buf)
+		if err != nil {
+			t.Fatalf("gif.DecodeAll(), name:%s, error = %v", tc.name, err)
+		}
+		if len(animation.Image) != tc.wantFrames {
+			t.Errorf("WriteGIF(), name:%s, got %d frames; want %d", tc.name, len(animation.Image), tc.wantFrames)
+			continue
+		}
+		if last := animation.Delay[len(animation.Delay)-1]; last != 4*recorder.Delay {
+			t.Errorf("WriteGIF(), name:%s, last frame delay = %d; want %d", tc.name, last, 4*recorder.Delay)
+		}
+		frame := animation.Image[len(animation.Image)-1]
+		// (0, 3) is nearer player 1's city at (0, 5), (3, 3) nearer player 2's city at (3, 5)
+		if got := color.RGBAModel.Convert(frame.At(31, 1)); got != imageTerritory1 {
+			t.Errorf("WriteGIF(), name:%s, (0, 3) shaded %v; want player 1's territory", tc.name, got)
+		}
+		if got := color.RGBAModel.Convert(frame.At(31, 31)); got != imageTerritory2 {
+			t.Errorf("WriteGIF(), name:%s, (3, 3) shaded %v; want player 2's territory", tc.name, got)
+		}
+	}
+}
+
+func TestGIFRecorderNoFrames(t *testing.T) {
+	if err := NewGIFRecorder().WriteGIF(io.Discard); err == nil {
+		t.Errorf("WriteGIF() without frames got no error")
+	}
+}
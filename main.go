@@ -1,49 +1,118 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
-	"runtime"
+	"strings"
+	"time"
 )
 
 func main() {
+	commands := map[string]func(args []string) error{
+		"tournament": runTournamentCommand,
+		"serve":      runServeCommand,
+		"join":       runJoinCommand,
+		"pbem":       runPBEMCommand,
+		"report":     runReportCommand,
+		"export":     runExportCommand,
+		"edit":       runEditCommand,
+		"web":        runWebCommand,
+	}
+	if len(os.Args) > 1 && commands[os.Args[1]] != nil {
+		if err := commands[os.Args[1]](os.Args[2:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
 
-	rows, columns := 10, 20 // x, y (horizontal, vertical) (rows, columns)
-	board := NewGameBoard(rows, columns)
-	numIslands := 4
-	board.GenerateRandomIslands(numIslands)
-	numCities := 12
-	board.AddCities(numCities)
-	board.Player1 = NewPlayer("player 1", true)
+	human := flag.Bool("human", false, "player 1 is played from the terminal")
+	bot1 := flag.String("bot1", "", "command line of an external bot playing player 1")
+	bot2 := flag.String("bot2", "", "command line of an external bot playing player 2")
+	botTimeLimit := flag.Duration("botTimeLimit", DefaultBotTimeLimit, "time a bot may take to answer a turn")
+	useTUI := flag.Bool("tui", false, "play in a full-screen terminal UI")
+	gifPath := flag.String("gif", "", "write an animated GIF of the game to this file")
+	gifEveryTurn := flag.Bool("gifEveryTurn", false, "capture a GIF frame after each player's turn rather than after each day")
+	mapFlags := addMapFlags(flag.CommandLine)
+	flag.Parse()
+
+	board, err := mapFlags.newGameBoard(time.Now().UnixNano())
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	board.Player1 = NewPlayer("player 1", !*human)
 	board.Player2 = NewPlayer("player 2", true)
-	board.DayZero()
-	for {
-		if board.Day == 20 {
-			fmt.Println("demo game cut short")
-			break
+	var bots []*BotController
+	for _, bot := range []struct {
+		player  *Player
+		command string
+	}{{board.Player1, *bot1}, {board.Player2, *bot2}} {
+		if bot.command == "" {
+			continue
 		}
-		board.NextDay()
-		board.DoPlayerTurnAI(1)
-		if board.hasPlayerWon(1) {
-			break
+		controller, err := startBot(bot.command, *botTimeLimit)
+		if err != nil {
+			fmt.Printf("starting bot for %s: %v\n", bot.player.Name, err)
+			// os.Exit skips the deferred closes, which would leave the bots already started running
+			for _, started := range bots {
+				started.Close()
+			}
+			os.Exit(1)
 		}
-		board.DoPlayerTurnAI(2)
-		if board.hasPlayerWon(2) {
-			break
+		defer controller.Close()
+		bots = append(bots, controller)
+		bot.player.IsAI = true
+		bot.player.Controller = controller
+	}
+	var ui *TUI
+	if *useTUI {
+		ui = NewTUI(board, os.Stdin, os.Stdout)
+		board.Output = ui
+		if *human {
+			ui.Player = 1
+			board.Player1.Controller = NewTUIController(ui)
+		}
+		ui.Start()
+	}
+	board.DayZero()
+	var recorder *GIFRecorder
+	if *gifPath != "" {
+		recorder = NewGIFRecorder()
+		recorder.EveryTurn = *gifEveryTurn
+		recorder.Capture(board)
+		recorder.Attach(board)
+	}
+	winner := board.PlayGame(20)
+	if ui != nil {
+		ui.Browse(fmt.Sprintf("GAME OVER, winner: %d. Arrows to look around, q to quit", winner))
+		ui.Stop()
+	}
+	if winner == 0 {
+		fmt.Println("demo game cut short")
+	}
+	if recorder != nil {
+		if err := writeFile(*gifPath, recorder.WriteGIF); err != nil {
+			fmt.Printf("writing %s: %v\n", *gifPath, err)
 		}
 	}
 	fmt.Println("GAME OVER")
 }
 
-func clearScreen() {
-	if runtime.GOOS == "windows" {
-		cmd := exec.Command("cmd", "/c", "cls")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
-	} else {
-		cmd := exec.Command("clear")
-		cmd.Stdout = os.Stdout
-		cmd.Run()
+// startBot starts an external bot from its command line.
+func startBot(commandLine string, timeLimit time.Duration) (*BotController, error) {
+	fields := strings.Fields(commandLine)
+	if len(fields) == 0 {
+		return nil, errors.New("empty bot command line")
 	}
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stderr = os.Stderr
+	return NewBotController(cmd, timeLimit)
+}
+
+func clearScreen() {
+	fmt.Print(ansiHome + ansiClear)
 }
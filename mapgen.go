@@ -0,0 +1,615 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// MapAlgorithm is a way of shaping the land of a generated map.
+type MapAlgorithm int
+
+const (
+	MapOvals       MapAlgorithm = iota // overlapping oval islands
+	MapContinents                      // a few large continents shaped by smooth noise
+	MapArchipelago                     // many small islands
+	MapFractal                         // islands with rugged, fractal coastlines
+)
+
+const (
+	defaultLandPercent   = 40
+	defaultMinIslandSize = 4
+	maxMapAttempts       = 100 // maps generated before giving up on finding a playable one
+)
+
+// MapOptions describes the map to generate.
+type MapOptions struct {
+	Algorithm     MapAlgorithm
+	Islands       int // islands of the ovals and archipelago algorithms
+	Cities        int
+	LandPercent   int      // share of the cells which are land
+	MinIslandSize int      // islands with fewer cells are sunk
+	Mirror        bool     // the map is the same when turned half way round, so starts can be mirror images
+	Terrain       bool     // add mountains, forests, shallows and polar ice
+	Rules         *RuleSet // rule set the game is played under, the classic rules when nil
+}
+
+// NewMapOptions returns the options of a map of oval islands with the default share of land.
+func NewMapOptions(numIslands, numCities int) MapOptions {
+	return MapOptions{
+		Algorithm:     MapOvals,
+		Islands:       numIslands,
+		Cities:        numCities,
+		LandPercent:   defaultLandPercent,
+		MinIslandSize: defaultMinIslandSize,
+	}
+}
+
+// mapAlgorithmToString returns the name of a map algorithm.
+func mapAlgorithmToString(algorithm MapAlgorithm) string {
+	switch algorithm {
+	case MapOvals:
+		return "ovals"
+	case MapContinents:
+		return "continents"
+	case MapArchipelago:
+		return "archipelago"
+	case MapFractal:
+		return "fractal"
+	default:
+		return "unknown"
+	}
+}
+
+// mapAlgorithmFromString returns the map algorithm with the given name.
+func mapAlgorithmFromString(name string) (MapAlgorithm, error) {
+	for algorithm := MapOvals; algorithm <= MapFractal; algorithm++ {
+		if mapAlgorithmToString(algorithm) == name {
+			return algorithm, nil
+		}
+	}
+	return MapOvals, fmt.Errorf("unknown map algorithm %q", name)
+}
+
+// mapFlags are the command line flags describing the map of a new game.
+type mapFlags struct {
+	rows, columns *int
+	algorithm     *string
+	scenario      *string
+	units         *string
+	rules         *string
+	options       MapOptions
+}
+
+// addMapFlags defines the flags describing the map of a new game.
+func addMapFlags(flags *flag.FlagSet) *mapFlags {
+	f := &mapFlags{}
+	f.rows = flags.Int("rows", 10, "rows of the map")
+	f.columns = flags.Int("columns", 20, "columns of the map")
+	f.algorithm = flags.String("map", "ovals", "map generator: ovals, continents, archipelago or fractal")
+	flags.IntVar(&f.options.Islands, "islands", 4, "islands on the map")
+	flags.IntVar(&f.options.Cities, "cities", 12, "cities on the map")
+	flags.IntVar(&f.options.LandPercent, "land", defaultLandPercent, "percentage of the map which is land")
+	flags.IntVar(&f.options.MinIslandSize, "minIsland", defaultMinIslandSize, "cells of the smallest island")
+	f.scenario = flags.String("scenario", "", "scenario file to play instead of a generated map")
+	flags.BoolVar(&f.options.Mirror, "mirror", false, "mirror the map so both players start in mirror-image positions")
+	flags.BoolVar(&f.options.Terrain, "terrain", false, "add mountains, forests, shallows and polar ice to the map")
+	f.units = flags.String("units", "", "rules file overriding or adding unit definitions")
+	f.rules = flags.String("rules", "", "rule set: "+strings.Join(RuleSetNames(), ", "))
+	return f
+}
+
+// newGameBoard generates the map described by the flags, or loads the scenario, played under
+// the rule set and unit rules of the flags.
+func (f *mapFlags) newGameBoard(seed int64) (*GameBoard, error) {
+	rules, err := loadRuleSet(*f.rules, *f.units)
+	if err != nil {
+		return nil, err
+	}
+	if *f.scenario != "" {
+		file, err := os.Open(*f.scenario)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		scenario, err := ReadScenario(file)
+		if err != nil {
+			return nil, err
+		}
+		scenario.Rules = rules
+		return NewGameBoardFromScenario(scenario, seed)
+	}
+	algorithm, err := mapAlgorithmFromString(*f.algorithm)
+	if err != nil {
+		return nil, err
+	}
+	options := f.options
+	options.Algorithm = algorithm
+	options.Rules = rules
+	return NewGeneratedGameBoard(*f.rows, *f.columns, options, seed)
+}
+
+// NewGeneratedGameBoard creates a game board with a generated map. Maps which turn out not to
+// be playable are generated again with another seed, until one is or too many attempts fail.
+func NewGeneratedGameBoard(rows, columns int, options MapOptions, seed int64) (*GameBoard, error) {
+	if err := options.validate(rows, columns); err != nil {
+		return nil, err
+	}
+	var err error
+	for attempt := int64(0); attempt < maxMapAttempts; attempt++ {
+		board := NewGameBoardWithSeed(rows, columns, seed+attempt*1000003)
+		board.Rules = options.Rules
+		if err = board.GenerateMap(options); err == nil {
+			return board, nil
+		}
+	}
+	return nil, fmt.Errorf("no playable map in %d attempts: %w", maxMapAttempts, err)
+}
+
+// validate checks the options can describe a map of the given size.
+func (o MapOptions) validate(rows, columns int) error {
+	switch {
+	case rows < 3 || columns < 3:
+		return fmt.Errorf("a map of %dx%d cells is too small", rows, columns)
+	case o.Algorithm < MapOvals || o.Algorithm > MapFractal:
+		return errors.New("unknown map algorithm")
+	case o.LandPercent <= 0 || o.LandPercent > 100:
+		return fmt.Errorf("land percentage %d is not between 1 and 100", o.LandPercent)
+	case o.Islands < 1 && (o.Algorithm == MapOvals || o.Algorithm == MapArchipelago):
+		return fmt.Errorf("%s maps need at least one island", mapAlgorithmToString(o.Algorithm))
+	case o.Cities < 2:
+		return errors.New("a map needs a city for each player")
+	case o.Mirror && o.Cities%2 != 0:
+		return errors.New("a mirrored map needs an even number of cities")
+	case o.MinIslandSize > rows*columns*o.LandPercent/100:
+		return fmt.Errorf("islands of %d cells do not fit in %d%% of the map", o.MinIslandSize, o.LandPercent)
+	}
+	return nil
+}
+
+// GenerateMap generates the land and cities of the board. It fails if the cities do not fit on
+// the land, or if a player starting in one city could not reach another.
+func (g *GameBoard) GenerateMap(options MapOptions) error {
+	if err := options.validate(g.Rows, g.Columns); err != nil {
+		return err
+	}
+	var elevation [][]float64
+	switch options.Algorithm {
+	case MapOvals:
+		elevation = g.getOvalElevation(options.Islands, g.Rows/4+1, g.Columns/4+1)
+	case MapContinents:
+		elevation = g.getNoiseElevation(float64(maxInt(g.Rows, g.Columns))/2, 4)
+	case MapArchipelago:
+		elevation = g.getOvalElevation(options.Islands*4, 2, 3)
+		noise := g.getNoiseElevation(2, 1)
+		for i := range elevation {
+			for j := range elevation[i] {
+				elevation[i][j] += 0.3 * noise[i][j]
+			}
+		}
+	case MapFractal:
+		elevation = g.getFractalElevation(0.6)
+	}
+	if options.Mirror {
+		g.mirror(func(from, to Coordinate) {
+			elevation[to.PositionX][to.PositionY] = elevation[from.PositionX][from.PositionY]
+		})
+	}
+	target := (g.Rows*g.Columns*options.LandPercent + 50) / 100
+	g.raiseLand(elevation, target, options.MinIslandSize)
+	addCities := g.AddCities
+	if options.Mirror {
+		// mirrored cells have the same elevation, but may still differ where the target was reached
+		g.mirror(func(from, to Coordinate) {
+			g.Grid[to.PositionX][to.PositionY].IsLand = g.Grid[from.PositionX][from.PositionY].IsLand
+		})
+		addCities = g.addMirroredCities
+	}
+	if options.Terrain {
+		g.addTerrain(elevation)
+		if options.Mirror {
+			g.mirror(func(from, to Coordinate) {
+				g.Grid[to.PositionX][to.PositionY].Terrain = g.Grid[from.PositionX][from.PositionY].Terrain
+			})
+		}
+	}
+	if err := addCities(options.Cities); err != nil {
+		return err
+	}
+	return g.validateMap()
+}
+
+// addTerrain turns the highest land into mountains and patches of the rest into forest, the
+// sea closest to surfacing along the coasts into shallows, and the sea of the top and bottom
+// rows into polar ice.
+func (g *GameBoard) addTerrain(elevation [][]float64) {
+	var land, coast []Coordinate
+	sea := 0
+	g.IterateGrid(func(row, col int, cell *Cell) {
+		switch {
+		case cell.IsLand:
+			land = append(land, Coordinate{row, col})
+		case row == 0 || row == g.Rows-1:
+			cell.Terrain = TerrainIce
+		default:
+			sea++
+			if g.isNextToLand(Coordinate{row, col}) {
+				coast = append(coast, Coordinate{row, col})
+			}
+		}
+	})
+	byHeight := func(cells []Coordinate, height [][]float64) {
+		sort.SliceStable(cells, func(a, b int) bool {
+			return height[cells[a].PositionX][cells[a].PositionY] > height[cells[b].PositionX][cells[b].PositionY]
+		})
+	}
+
+	byHeight(land, elevation)
+	mountains := len(land) * mountainPercentOfLand / 100
+	for _, c := range land[:mountains] {
+		g.Grid[c.PositionX][c.PositionY].Terrain = TerrainMountains
+	}
+	rest := land[mountains:]
+	byHeight(rest, g.getNoiseElevation(3, 2))
+	for _, c := range rest[:len(land)*forestPercentOfLand/100] {
+		g.Grid[c.PositionX][c.PositionY].Terrain = TerrainForest
+	}
+
+	byHeight(coast, elevation)
+	for i := 0; i < len(coast) && i < sea*shallowsPercentOfSea/100; i++ {
+		g.Grid[coast[i].PositionX][coast[i].PositionY].Terrain = TerrainShallows
+	}
+}
+
+// getOvalElevation stamps ovals on the board, each cell's elevation being how far inside the
+// nearest oval it is. Radii are at most maxRadiusRow rows and maxRadiusCol columns.
+func (g *GameBoard) getOvalElevation(numOvals, maxRadiusRow, maxRadiusCol int) [][]float64 {
+	r := g.random()
+	elevation := newElevation(g.Rows, g.Columns, math.Inf(-1))
+	for n := 0; n < numOvals; n++ {
+		centerRow := r.Intn(g.Rows)
+		centerCol := r.Intn(g.Columns)
+		radiusRow := 1 + r.Intn(maxRadiusRow)
+		radiusCol := 1 + r.Intn(maxRadiusCol)
+		for i := range elevation {
+			for j := range elevation[i] {
+				dx := float64(i-centerRow) / float64(radiusRow)
+				dy := float64(j-centerCol) / float64(radiusCol)
+				elevation[i][j] = math.Max(elevation[i][j], 1-(dx*dx+dy*dy))
+			}
+		}
+	}
+	for i := range elevation {
+		for j := range elevation[i] {
+			elevation[i][j] += r.Float64() * 0.1 // roughen the coasts, and break ties
+		}
+	}
+	return elevation
+}
+
+// getNoiseElevation returns smooth value noise with features about scale cells across, adding
+// octaves of ever finer detail.
+func (g *GameBoard) getNoiseElevation(scale float64, octaves int) [][]float64 {
+	r := g.random()
+	elevation := newElevation(g.Rows, g.Columns, 0)
+	amplitude := 1.0
+	for octave := 0; octave < octaves && scale >= 1; octave++ {
+		lattice := newElevation(int(float64(g.Rows)/scale)+2, int(float64(g.Columns)/scale)+2, 0)
+		for i := range lattice {
+			for j := range lattice[i] {
+				lattice[i][j] = r.Float64()
+			}
+		}
+		for i := range elevation {
+			for j := range elevation[i] {
+				x, y := float64(i)/scale, float64(j)/scale
+				x0, y0 := int(x), int(y)
+				fx, fy := smoothStep(x-float64(x0)), smoothStep(y-float64(y0))
+				top := lattice[x0][y0]*(1-fy) + lattice[x0][y0+1]*fy
+				bottom := lattice[x0+1][y0]*(1-fy) + lattice[x0+1][y0+1]*fy
+				elevation[i][j] += amplitude * (top*(1-fx) + bottom*fx)
+			}
+		}
+		scale /= 2
+		amplitude /= 2
+	}
+	return elevation
+}
+
+// getFractalElevation returns a height map made with the diamond-square algorithm, whose
+// coastlines stay rugged at every scale. Lower roughness gives smoother coasts.
+func (g *GameBoard) getFractalElevation(roughness float64) [][]float64 {
+	r := g.random()
+	size := 1
+	for size+1 < maxInt(g.Rows, g.Columns) {
+		size *= 2
+	}
+	height := newElevation(size+1, size+1, 0)
+	for _, corner := range [][2]int{{0, 0}, {0, size}, {size, 0}, {size, size}} {
+		height[corner[0]][corner[1]] = r.Float64()
+	}
+	displace := func(value float64, spread float64) float64 {
+		return value + (r.Float64()*2-1)*spread
+	}
+	spread := 1.0
+	for step := size; step > 1; step /= 2 {
+		half := step / 2
+		// diamond step: the centre of each square from its corners
+		for i := half; i < size; i += step {
+			for j := half; j < size; j += step {
+				average := (height[i-half][j-half] + height[i-half][j+half] + height[i+half][j-half] + height[i+half][j+half]) / 4
+				height[i][j] = displace(average, spread)
+			}
+		}
+		// square step: the middle of each edge from its neighbours
+		for i := 0; i <= size; i += half {
+			for j := (i/half + 1) % 2 * half; j <= size; j += step {
+				sum, count := 0.0, 0
+				for _, d := range [][2]int{{-half, 0}, {half, 0}, {0, -half}, {0, half}} {
+					x, y := i+d[0], j+d[1]
+					if x >= 0 && x <= size && y >= 0 && y <= size {
+						sum += height[x][y]
+						count++
+					}
+				}
+				height[i][j] = displace(sum/float64(count), spread)
+			}
+		}
+		spread *= roughness
+	}
+	elevation := make([][]float64, g.Rows)
+	for i := range elevation {
+		elevation[i] = height[i][:g.Columns]
+	}
+	return elevation
+}
+
+// raiseLand turns the target number of highest cells into land. Islands smaller than
+// minIslandSize are sunk, and the land they had is made up by growing the coasts of the
+// other islands.
+func (g *GameBoard) raiseLand(elevation [][]float64, target, minIslandSize int) {
+	cells := make([]Coordinate, 0, g.Rows*g.Columns)
+	g.IterateGrid(func(row, col int, cell *Cell) {
+		cells = append(cells, Coordinate{row, col})
+	})
+	sort.SliceStable(cells, func(a, b int) bool {
+		return elevation[cells[a].PositionX][cells[a].PositionY] > elevation[cells[b].PositionX][cells[b].PositionY]
+	})
+	for _, cell := range cells[:target] {
+		g.Grid[cell.PositionX][cell.PositionY].IsLand = true
+	}
+
+	land := target
+	labels, sizes := g.getMapComponents()
+	g.IterateGrid(func(row, col int, cell *Cell) {
+		if cell.IsLand && sizes[labels[row][col]] < minIslandSize {
+			cell.IsLand = false
+			land--
+		}
+	})
+	for grown := true; land < target && grown; {
+		grown = false
+		for _, c := range cells {
+			if land == target {
+				break
+			}
+			if !g.Grid[c.PositionX][c.PositionY].IsLand && g.isNextToLand(c) {
+				g.Grid[c.PositionX][c.PositionY].IsLand = true
+				land++
+				grown = true
+			}
+		}
+	}
+}
+
+// getMirrorImage returns the cell opposite the coordinate when the board is turned half way round.
+func (g *GameBoard) getMirrorImage(coordinate Coordinate) Coordinate {
+	return Coordinate{g.Rows - 1 - coordinate.PositionX, g.Columns - 1 - coordinate.PositionY}
+}
+
+// mirror calls copy for each cell of the first half of the board with its mirror image.
+func (g *GameBoard) mirror(copy func(from, to Coordinate)) {
+	for i := 0; i < g.Rows; i++ {
+		for j := 0; j < g.Columns; j++ {
+			if i*g.Columns+j < (g.Rows*g.Columns)/2 {
+				copy(Coordinate{i, j}, g.getMirrorImage(Coordinate{i, j}))
+			}
+		}
+	}
+}
+
+// addMirroredCities adds cities in pairs, each city with another on its mirror image.
+// It fails if there is no room left for a pair.
+func (g *GameBoard) addMirroredCities(numCities int) error {
+	r := g.random()
+	excludeTargetCell := false
+	isFree := func(c Coordinate) bool {
+		return g.Grid[c.PositionX][c.PositionY].IsLand && !g.HasNeighboringCity(c.PositionX, c.PositionY, excludeTargetCell)
+	}
+	for i := 0; i < numCities; i += 2 {
+		var candidates []Coordinate
+		g.mirror(func(from, to Coordinate) {
+			dx, dy := abs(from.PositionX-to.PositionX), abs(from.PositionY-to.PositionY)
+			if isFree(from) && isFree(to) && (dx > 1 || dy > 1) {
+				candidates = append(candidates, from)
+			}
+		})
+		if len(candidates) == 0 {
+			return fmt.Errorf("no room for cities %d and %d of %d", i+1, i+2, numCities)
+		}
+		c := candidates[r.Intn(len(candidates))]
+		for _, coordinate := range []Coordinate{c, g.getMirrorImage(c)} {
+			g.Grid[coordinate.PositionX][coordinate.PositionY].HasCity = true
+			city := g.newCity(coordinate.PositionX, coordinate.PositionY)
+			city.IsCityNextToSea = g.IsCityNextToSea(city.PositionX, city.PositionY)
+			g.Cities = append(g.Cities, *city)
+		}
+	}
+	return nil
+}
+
+// isNextToLand checks if any of the cells around the coordinate is land.
+func (g *GameBoard) isNextToLand(coordinate Coordinate) bool {
+	for i := coordinate.PositionX - 1; i <= coordinate.PositionX+1; i++ {
+		for j := coordinate.PositionY - 1; j <= coordinate.PositionY+1; j++ {
+			if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns && g.Grid[i][j].IsLand {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Classes of cells for getComponents.
+const (
+	classBlocked = -1 // terrain neither tanks nor transports can cross
+	classSea     = 0
+	classLand    = 1
+)
+
+// getMapComponents labels each cell with the island or the body of water it belongs to,
+// cells being connected diagonally too, as units move. It also returns the cells of each label.
+func (g *GameBoard) getMapComponents() ([][]int, []int) {
+	return g.getComponents(func(cell *Cell) int {
+		if cell.IsLand {
+			return classLand
+		}
+		return classSea
+	})
+}
+
+// getPassableClass returns the class of a cell as tanks and transports see it: land they can
+// cross, including cities, sea they can cross, or blocked by mountains or ice.
+func getPassableClass(cell *Cell) int {
+	switch {
+	case cell.HasCity:
+		return classLand
+	case cell.Terrain == TerrainMountains || cell.Terrain == TerrainIce:
+		return classBlocked
+	case cell.IsLand:
+		return classLand
+	default:
+		return classSea
+	}
+}
+
+// getComponents labels each cell with the connected group of cells of its class it belongs to,
+// cells being connected diagonally too, as units move. Blocked cells are labelled -1. It also
+// returns the cells of each label.
+func (g *GameBoard) getComponents(class func(cell *Cell) int) ([][]int, []int) {
+	labels := make([][]int, g.Rows)
+	for i := range labels {
+		labels[i] = make([]int, g.Columns)
+		for j := range labels[i] {
+			labels[i][j] = -1
+		}
+	}
+	var sizes []int
+	g.IterateGrid(func(row, col int, cell *Cell) {
+		if labels[row][col] >= 0 || class(cell) == classBlocked {
+			return
+		}
+		label := len(sizes)
+		sizes = append(sizes, 0)
+		labels[row][col] = label
+		queue := []Coordinate{{row, col}}
+		for len(queue) > 0 {
+			c := queue[0]
+			queue = queue[1:]
+			sizes[label]++
+			for i := c.PositionX - 1; i <= c.PositionX+1; i++ {
+				for j := c.PositionY - 1; j <= c.PositionY+1; j++ {
+					if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns && labels[i][j] < 0 && class(&g.Grid[i][j]) == class(cell) {
+						labels[i][j] = label
+						queue = append(queue, Coordinate{i, j})
+					}
+				}
+			}
+		}
+	})
+	return labels, sizes
+}
+
+// validateMap checks that a player starting in any city can reach every other city: by land,
+// or by sea on a transport built in a city on the coast, going round mountains and ice.
+func (g *GameBoard) validateMap() error {
+	if len(g.Cities) < 2 {
+		return errors.New("a map needs a city for each player")
+	}
+	labels, sizes := g.getComponents(getPassableClass)
+	// seas[island] are the bodies of water next to the island's cities, where it can launch ships;
+	// shores[sea] are the islands next to the body of water, where ships can land
+	seas := make([]map[int]bool, len(sizes))
+	shores := make([]map[int]bool, len(sizes))
+	for i := range sizes {
+		seas[i], shores[i] = map[int]bool{}, map[int]bool{}
+	}
+	g.IterateGrid(func(row, col int, cell *Cell) {
+		if getPassableClass(cell) != classSea {
+			return
+		}
+		for i := row - 1; i <= row+1; i++ {
+			for j := col - 1; j <= col+1; j++ {
+				if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns && getPassableClass(&g.Grid[i][j]) == classLand {
+					shores[labels[row][col]][labels[i][j]] = true
+					if g.Grid[i][j].HasCity {
+						seas[labels[i][j]][labels[row][col]] = true
+					}
+				}
+			}
+		}
+	})
+
+	for _, from := range g.Cities {
+		reached := map[int]bool{labels[from.PositionX][from.PositionY]: true}
+		queue := []int{labels[from.PositionX][from.PositionY]}
+		for len(queue) > 0 {
+			island := queue[0]
+			queue = queue[1:]
+			for sea := range seas[island] {
+				for shore := range shores[sea] {
+					if !reached[shore] {
+						reached[shore] = true
+						queue = append(queue, shore)
+					}
+				}
+			}
+		}
+		for _, to := range g.Cities {
+			if !reached[labels[to.PositionX][to.PositionY]] {
+				return fmt.Errorf("the city at (%d, %d) cannot reach the city at (%d, %d)",
+					from.PositionX, from.PositionY, to.PositionX, to.PositionY)
+			}
+		}
+	}
+	return nil
+}
+
+// newElevation returns a rows by columns height map at the given height.
+func newElevation(rows, columns int, height float64) [][]float64 {
+	elevation := make([][]float64, rows)
+	for i := range elevation {
+		elevation[i] = make([]float64, columns)
+		for j := range elevation[i] {
+			elevation[i][j] = height
+		}
+	}
+	return elevation
+}
+
+// smoothStep eases an interpolation weight between 0 and 1 so noise has no visible creases.
+func smoothStep(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// maxInt returns the larger of two ints.
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestGenerateMap(t *testing.T) {
+	for algorithm := MapOvals; algorithm <= MapFractal; algorithm++ {
+		for seed := int64(1); seed <= 10; seed++ {
+			name := mapAlgorithmToString(algorithm)
+			options := MapOptions{Algorithm: algorithm, Islands: 4, Cities: 8, LandPercent: 35, MinIslandSize: 5}
+			board, err := NewGeneratedGameBoard(12, 24, options, seed)
+			if err != nil {
+				t.Errorf("NewGeneratedGameBoard(), name:%s, seed %d, error = %v", name, seed, err)
+				continue
+			}
+
+			land := 0
+			board.IterateGrid(func(row, col int, cell *Cell) {
+				if cell.IsLand {
+					land++
+				}
+			})
+			if want := 12 * 24 * 35 / 100; land != want && land != want+1 {
+				t.Errorf("NewGeneratedGameBoard(), name:%s, seed %d, got %d land cells; want %d", name, seed, land, want)
+			}
+			labels, sizes := board.getMapComponents()
+			board.IterateGrid(func(row, col int, cell *Cell) {
+				if cell.IsLand && sizes[labels[row][col]] < options.MinIslandSize {
+					t.Errorf("NewGeneratedGameBoard(), name:%s, seed %d, island at (%d, %d) has %d cells; want at least %d",
+						name, seed, row, col, sizes[labels[row][col]], options.MinIslandSize)
+				}
+			})
+			if len(board.Cities) != options.Cities {
+				t.Errorf("NewGeneratedGameBoard(), name:%s, seed %d, got %d cities; want %d", name, seed, len(board.Cities), options.Cities)
+			}
+			for _, city := range board.Cities {
+				if !board.Grid[city.PositionX][city.PositionY].IsLand || board.HasNeighboringCity(city.PositionX, city.PositionY, true) {
+					t.Errorf("NewGeneratedGameBoard(), name:%s, seed %d, city at (%d, %d) is at sea or next to another city",
+						name, seed, city.PositionX, city.PositionY)
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateMapIsDeterministic(t *testing.T) {
+	options := MapOptions{Algorithm: MapFractal, Islands: 4, Cities: 6, LandPercent: 40}
+	a, errA := NewGeneratedGameBoard(10, 20, options, 7)
+	b, errB := NewGeneratedGameBoard(10, 20, options, 7)
+	if errA != nil || errB != nil {
+		t.Fatalf("NewGeneratedGameBoard() errors = %v, %v", errA, errB)
+	}
+	if a.StateHash() != b.StateHash() {
+		t.Errorf("NewGeneratedGameBoard() generated different maps from the same seed")
+	}
+}
+
+func TestNewGeneratedGameBoardInvalid(t *testing.T) {
+	type test struct {
+		name    string
+		rows    int
+		columns int
+		options MapOptions
+	}
+	tests := []test{
+		{name: "too small", rows: 2, columns: 20, options: NewMapOptions(4, 6)},
+		{name: "no land", rows: 10, columns: 20, options: MapOptions{Islands: 4, Cities: 6}},
+		{name: "one city", rows: 10, columns: 20, options: NewMapOptions(4, 1)},
+		{name: "no islands", rows: 10, columns: 20, options: NewMapOptions(0, 6)},
+		{name: "islands larger than the land", rows: 10, columns: 20, options: MapOptions{Islands: 4, Cities: 6, LandPercent: 10, MinIslandSize: 30}},
+		{name: "too many cities", rows: 10, columns: 20, options: NewMapOptions(4, 60)},
+	}
+	for _, tc := range tests {
+		if _, err := NewGeneratedGameBoard(tc.rows, tc.columns, tc.options, 1); err == nil {
+			t.Errorf("NewGeneratedGameBoard(), name:%s, got no error; want an error", tc.name)
+		}
+	}
+}
+
+func TestAddCitiesWithoutRoom(t *testing.T) {
+	board := NewGameBoardWithSeed(2, 2, 1)
+	board.IterateGrid(func(row, col int, cell *Cell) {
+		cell.IsLand = true
+	})
+	if err := board.AddCities(2); err == nil {
+		t.Errorf("AddCities(2) on a 2x2 island, got no error; want an error as cities cannot be neighbours")
+	}
+	if len(board.Cities) != 1 {
+		t.Errorf("AddCities(2) on a 2x2 island added %d cities; want 1", len(board.Cities))
+	}
+}
+
+func TestValidateMap(t *testing.T) {
+	// two islands of three columns, split by sea in column 3
+	board := NewGameBoard(5, 7)
+	board.IterateGrid(func(row, col int, cell *Cell) {
+		cell.IsLand = col != 3
+	})
+	addCity := func(row, col int) {
+		board.Grid[row][col].HasCity = true
+		board.Cities = append(board.Cities, *NewCity(row, col))
+	}
+	addCity(2, 1)
+	addCity(2, 5)
+	if err := board.validateMap(); err == nil {
+		t.Errorf("validateMap() with only inland cities, got no error; want an error")
+	}
+
+	addCity(2, 4)
+	if err := board.validateMap(); err == nil {
+		t.Errorf("validateMap() with one island without a port, got no error; want an error")
+	}
+
+	addCity(0, 2)
+	if err := board.validateMap(); err != nil {
+		t.Errorf("validateMap() with a port on each island, error = %v", err)
+	}
+}
@@ -0,0 +1,400 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// PlayByEmailGame is a game played asynchronously by exchanging turn files.
+// Each player keeps their own copy of the game: after playing a turn they send the
+// turn file to their opponent, who imports it to replay the turn on their copy.
+type PlayByEmailGame struct {
+	NextPlayer int // the player whose turn is next
+	Winner     int // 0 while the game goes on
+	Board      *GameBoard
+}
+
+// playByEmailFile is the file format of a PlayByEmailGame.
+type playByEmailFile struct {
+	NextPlayer int       `json:"nextPlayer"`
+	Winner     int       `json:"winner"`
+	Board      SavedGame `json:"board"`
+}
+
+// TurnFile holds the orders of one player's turn, signed with a key shared by the players.
+type TurnFile struct {
+	Version    int            `json:"version"`
+	Day        int            `json:"day"`
+	Player     int            `json:"player"`
+	BaseHash   string         `json:"baseHash"`   // state hash of the game before the turn
+	Decisions  []turnDecision `json:"decisions"`  // the player's decisions, in the order they were asked for
+	ResultHash string         `json:"resultHash"` // state hash of the game after the turn
+	Signature  string         `json:"signature"`  // HMAC-SHA256 of the rest of the turn file
+}
+
+// turnDecision is one answer of a player's controller during a turn.
+type turnDecision struct {
+	Unit        int         `json:"unit,omitempty"`
+	Move        *Coordinate `json:"move,omitempty"` // nil when the unit stays put
+	City        *Coordinate `json:"city,omitempty"` // set for a production choice
+	Production  string      `json:"production,omitempty"`
+	RandomDraws uint64      `json:"randomDraws,omitempty"` // random numbers the controller drew while deciding
+}
+
+// pbemKeyVariable is the environment variable holding the key turn files are signed with.
+const pbemKeyVariable = "STRATCON_PBEM_KEY"
+
+// runPBEMCommand runs the "pbem" command with its command line arguments.
+func runPBEMCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: pbem new|play [flags]")
+	}
+	flags := flag.NewFlagSet("pbem "+args[0], flag.ContinueOnError)
+	gamePath := flags.String("game", "game.json", "file holding your copy of the game")
+	key := flags.String("key", os.Getenv(pbemKeyVariable), "key shared with your opponent to sign turn files, $"+pbemKeyVariable+" by default")
+	switch args[0] {
+	case "new":
+		mapFlags := addMapFlags(flags)
+		seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the game")
+		player1 := flags.String("player1", "player 1", "name of player 1")
+		player2 := flags.String("player2", "player 2", "name of player 2")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		board, err := mapFlags.newGameBoard(*seed)
+		if err != nil {
+			return err
+		}
+		board.Player1 = &Player{Name: *player1, IsAI: true}
+		board.Player2 = &Player{Name: *player2, IsAI: true}
+		board.DayZero()
+		game := &PlayByEmailGame{NextPlayer: 1, Board: board}
+		fmt.Printf("created %s, send a copy to your opponent\n", *gamePath)
+		return writeFile(*gamePath, game.Write)
+	case "play":
+		player := flags.Int("player", 1, "the player you are")
+		turnPath := flags.String("turn", "", "turn file received from your opponent, to import before playing")
+		outPath := flags.String("out", "turn.json", "turn file to send to your opponent")
+		useAI := flags.Bool("ai", false, "let the AI play your turn")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if *key == "" {
+			return errors.New("a key is needed to sign turn files, use -key or $" + pbemKeyVariable)
+		}
+		game, err := readPlayByEmailGame(*gamePath)
+		if err != nil {
+			return err
+		}
+		if *turnPath != "" {
+			f, err := os.Open(*turnPath)
+			if err != nil {
+				return err
+			}
+			turn, err := ReadTurnFile(f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+			if err := game.ImportTurn(turn, []byte(*key)); err != nil {
+				return err
+			}
+		}
+		if game.Winner != 0 {
+			fmt.Printf("GAME OVER, winner: %d\n", game.Winner)
+			return writeFile(*gamePath, game.Write)
+		}
+		if game.NextPlayer != *player {
+			return fmt.Errorf("it is player %d's turn, import their turn file first", game.NextPlayer)
+		}
+		var controller Controller = NewHumanController(os.Stdin, os.Stdout)
+		if *useAI {
+			controller = &AIController{}
+		}
+		turn, err := game.PlayTurn(controller, []byte(*key))
+		if err != nil {
+			return err
+		}
+		if err := writeFile(*outPath, func(w io.Writer) error { return WriteTurnFile(w, turn) }); err != nil {
+			return err
+		}
+		fmt.Printf("send %s to your opponent\n", *outPath)
+		return writeFile(*gamePath, game.Write)
+	default:
+		return fmt.Errorf("unknown pbem command %q", args[0])
+	}
+}
+
+// readPlayByEmailGame reads a game written by PlayByEmailGame.Write from a file.
+func readPlayByEmailGame(path string) (*PlayByEmailGame, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadPlayByEmailGame(f)
+}
+
+// ReadPlayByEmailGame reads a game written by PlayByEmailGame.Write.
+func ReadPlayByEmailGame(r io.Reader) (*PlayByEmailGame, error) {
+	var file playByEmailFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return nil, err
+	}
+	if file.NextPlayer != 1 && file.NextPlayer != 2 {
+		return nil, fmt.Errorf("invalid next player %d", file.NextPlayer)
+	}
+	board, err := newGameBoardFromSave(file.Board)
+	if err != nil {
+		return nil, err
+	}
+	return &PlayByEmailGame{NextPlayer: file.NextPlayer, Winner: file.Winner, Board: board}, nil
+}
+
+// Write writes the game as JSON.
+func (game *PlayByEmailGame) Write(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(playByEmailFile{NextPlayer: game.NextPlayer, Winner: game.Winner, Board: game.Board.newSavedGame()})
+}
+
+// PlayTurn plays the next player's turn with the controller and returns the signed turn file.
+// Player 1's turn starts a new day.
+func (game *PlayByEmailGame) PlayTurn(controller Controller, key []byte) (*TurnFile, error) {
+	if game.Winner != 0 {
+		return nil, fmt.Errorf("the game is over, player %d has won", game.Winner)
+	}
+	g := game.Board
+	player := game.NextPlayer
+	if player == 1 {
+		g.NextDay()
+	}
+	turn := &TurnFile{Version: saveVersion, Day: g.Day, Player: player, BaseHash: g.StateHash()}
+	recorder := &recordingController{controller: controller}
+	if err := game.playTurn(recorder); err != nil {
+		return nil, err
+	}
+	turn.Decisions = recorder.decisions
+	turn.ResultHash = g.StateHash()
+	turn.Signature = turn.sign(key)
+	return turn, nil
+}
+
+// ImportTurn checks the opponent's turn file and replays it.
+// It rejects turn files which were tampered with, signed with another key, or are
+// not the next turn of the game.
+func (game *PlayByEmailGame) ImportTurn(turn *TurnFile, key []byte) error {
+	if !hmac.Equal([]byte(turn.Signature), []byte(turn.sign(key))) {
+		return errors.New("the turn file signature is invalid, it was changed or signed with another key")
+	}
+	if game.Winner != 0 {
+		return fmt.Errorf("the game is over, player %d has won", game.Winner)
+	}
+	g := game.Board
+	day := g.Day
+	if game.NextPlayer == 1 {
+		day++
+	}
+	if turn.Player != game.NextPlayer || turn.Day != day {
+		return fmt.Errorf("the turn file is for day %d player %d, the game is waiting for day %d player %d",
+			turn.Day, turn.Player, day, game.NextPlayer)
+	}
+
+	// replay on a copy of the game so that a bad turn file leaves the game untouched
+	copied, err := game.copy()
+	if err != nil {
+		return err
+	}
+	if copied.NextPlayer == 1 {
+		copied.Board.NextDay()
+	}
+	if hash := copied.Board.StateHash(); hash != turn.BaseHash {
+		return errors.New("the turn file was played on a different game state")
+	}
+	replay := &replayController{decisions: turn.Decisions}
+	if err := copied.playTurn(replay); err != nil {
+		return err
+	}
+	if replay.err != nil {
+		return replay.err
+	}
+	if len(replay.decisions) > 0 {
+		return fmt.Errorf("the turn file has %d decisions left over after the turn", len(replay.decisions))
+	}
+	if copied.Board.StateHash() != turn.ResultHash {
+		return errors.New("replaying the turn file does not give the game state it was signed with")
+	}
+	copied.Board.Output = g.Output
+	copied.Board.listeners = g.listeners
+	*game = *copied
+	return nil
+}
+
+// playTurn runs the next player's turn with the controller and passes the turn to the opponent.
+func (game *PlayByEmailGame) playTurn(controller Controller) error {
+	g := game.Board
+	player := game.NextPlayer
+	p := &Player{Name: fmt.Sprintf("player %d", player), IsAI: true}
+	if player == 1 && g.Player1 != nil {
+		p.Name = g.Player1.Name
+	} else if player == 2 && g.Player2 != nil {
+		p.Name = g.Player2.Name
+	}
+	p.Controller = controller
+	if player == 1 {
+		g.Player1 = p
+	} else {
+		g.Player2 = p
+	}
+	if err := g.DoPlayerTurn(player); err != nil {
+		return err
+	}
+	if g.hasPlayerWon(player) {
+		game.Winner = player
+	}
+	game.NextPlayer = 3 - player
+	return nil
+}
+
+// copy returns a deep copy of the game, through its save.
+func (game *PlayByEmailGame) copy() (*PlayByEmailGame, error) {
+	board, err := newGameBoardFromSave(game.Board.newSavedGame())
+	if err != nil {
+		return nil, err
+	}
+	return &PlayByEmailGame{NextPlayer: game.NextPlayer, Winner: game.Winner, Board: board}, nil
+}
+
+// sign returns the HMAC-SHA256 of the turn file, leaving out its signature.
+func (turn *TurnFile) sign(key []byte) string {
+	unsigned := *turn
+	unsigned.Signature = ""
+	data, _ := json.Marshal(unsigned)
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// WriteTurnFile writes the turn file as JSON.
+func WriteTurnFile(w io.Writer, turn *TurnFile) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(turn)
+}
+
+// ReadTurnFile reads a turn file written by WriteTurnFile.
+func ReadTurnFile(r io.Reader) (*TurnFile, error) {
+	var turn TurnFile
+	if err := json.NewDecoder(r).Decode(&turn); err != nil {
+		return nil, err
+	}
+	if turn.Version != saveVersion {
+		return nil, fmt.Errorf("unsupported turn file version %d", turn.Version)
+	}
+	return &turn, nil
+}
+
+// recordingController is a Controller which records the decisions of another controller.
+type recordingController struct {
+	controller Controller
+	decisions  []turnDecision
+}
+
+// BeginTurn implements Controller.
+func (c *recordingController) BeginTurn(g *GameBoard, player int) error {
+	return c.controller.BeginTurn(g, player)
+}
+
+// ChooseProduction implements Controller.
+func (c *recordingController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	draws := g.randomDraws()
+	unitType := c.controller.ChooseProduction(g, city)
+	c.decisions = append(c.decisions, turnDecision{
+		City:        &Coordinate{city.PositionX, city.PositionY},
+		Production:  g.unitTypeToString(unitType),
+		RandomDraws: g.randomDraws() - draws,
+	})
+	return unitType
+}
+
+// ChooseMove implements Controller.
+func (c *recordingController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	draws := g.randomDraws()
+	move, ok, err := c.controller.ChooseMove(g, unit)
+	if err != nil {
+		return move, ok, err
+	}
+	decision := turnDecision{Unit: unit.ID, RandomDraws: g.randomDraws() - draws}
+	if ok {
+		decision.Move = &Coordinate{move.PositionX, move.PositionY}
+	}
+	c.decisions = append(c.decisions, decision)
+	return move, ok, nil
+}
+
+// replayController is a Controller which replays the decisions of a turn file.
+// Random numbers the original controller drew are skipped, so the game draws the same numbers.
+type replayController struct {
+	decisions []turnDecision
+	err       error // the first decision which did not fit the game
+}
+
+// BeginTurn implements Controller.
+func (c *replayController) BeginTurn(g *GameBoard, player int) error {
+	return nil
+}
+
+// next returns the next decision.
+func (c *replayController) next() (turnDecision, bool) {
+	if len(c.decisions) == 0 {
+		return turnDecision{}, false
+	}
+	decision := c.decisions[0]
+	c.decisions = c.decisions[1:]
+	return decision, true
+}
+
+// ChooseProduction implements Controller.
+func (c *replayController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	decision, ok := c.next()
+	unitType, known := g.unitRules().typeFromString(decision.Production)
+	if !ok || decision.City == nil || *decision.City != (Coordinate{city.PositionX, city.PositionY}) || !known {
+		if c.err == nil {
+			c.err = fmt.Errorf("the turn file has no production for the city at (%d, %d)", city.PositionX, city.PositionY)
+		}
+		return Blank
+	}
+	if err := g.skipRandomDraws(decision.RandomDraws); err != nil {
+		if c.err == nil {
+			c.err = err
+		}
+		return Blank
+	}
+	return unitType
+}
+
+// ChooseMove implements Controller.
+func (c *replayController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	if c.err != nil {
+		return Coordinate{}, false, c.err
+	}
+	decision, ok := c.next()
+	if !ok || decision.City != nil || decision.Unit != unit.ID {
+		return Coordinate{}, false, fmt.Errorf("the turn file has no order for unit %d", unit.ID)
+	}
+	if err := g.skipRandomDraws(decision.RandomDraws); err != nil {
+		return Coordinate{}, false, err
+	}
+	if decision.Move == nil {
+		return Coordinate{}, false, nil
+	}
+	return *decision.Move, true, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// newPlayByEmailCopies returns the copies of a new play-by-email game kept by each player.
+func newPlayByEmailCopies(t *testing.T) (*PlayByEmailGame, *PlayByEmailGame) {
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	board.DayZero()
+	var buf bytes.Buffer
+	if err := (&PlayByEmailGame{NextPlayer: 1, Board: board}).Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	save := buf.Bytes()
+	var copies [2]*PlayByEmailGame
+	for i := range copies {
+		game, err := ReadPlayByEmailGame(bytes.NewReader(save))
+		if err != nil {
+			t.Fatalf("ReadPlayByEmailGame() error = %v", err)
+		}
+		game.Board.Output = io.Discard
+		copies[i] = game
+	}
+	return copies[0], copies[1]
+}
+
+func TestPlayByEmail(t *testing.T) {
+	key := []byte("secret")
+	first, second := newPlayByEmailCopies(t)
+	players := map[int]*PlayByEmailGame{1: first, 2: second}
+
+	for turns := 0; turns < 20 && first.Winner == 0; turns++ {
+		player := first.NextPlayer
+		mine, theirs := players[player], players[3-player]
+		turn, err := mine.PlayTurn(&AIController{}, key)
+		if err != nil {
+			t.Fatalf("PlayTurn() error = %v", err)
+		}
+		var buf bytes.Buffer
+		if err := WriteTurnFile(&buf, turn); err != nil {
+			t.Fatalf("WriteTurnFile() error = %v", err)
+		}
+		received, err := ReadTurnFile(&buf)
+		if err != nil {
+			t.Fatalf("ReadTurnFile() error = %v", err)
+		}
+		if err := theirs.ImportTurn(received, key); err != nil {
+			t.Fatalf("ImportTurn() day %d player %d error = %v", turn.Day, turn.Player, err)
+		}
+		if mine.Board.StateHash() != theirs.Board.StateHash() || mine.NextPlayer != theirs.NextPlayer {
+			t.Fatalf("the copies of the game differ after day %d player %d", turn.Day, turn.Player)
+		}
+		// importing the same turn again is out of order
+		if err := theirs.ImportTurn(received, key); err == nil {
+			t.Fatalf("ImportTurn() of a turn already imported got no error")
+		}
+	}
+	if first.Board.Day < 2 {
+		t.Errorf("the game played %d days; want it to run", first.Board.Day)
+	}
+}
+
+func TestImportTurnRejected(t *testing.T) {
+	key := []byte("secret")
+	first, second := newPlayByEmailCopies(t)
+	turn, err := first.PlayTurn(&AIController{}, key)
+	if err != nil {
+		t.Fatalf("PlayTurn() error = %v", err)
+	}
+	hash := second.Board.StateHash()
+
+	type test struct {
+		name   string
+		key    []byte
+		change func(turn *TurnFile)
+	}
+	tests := []test{
+		{name: "wrong key", key: []byte("guess"), change: func(turn *TurnFile) {}},
+		{name: "changed decisions", key: key, change: func(turn *TurnFile) {
+			turn.Decisions = append(turn.Decisions, turnDecision{Unit: 1})
+		}},
+		{name: "changed decisions and signed again", key: key, change: func(turn *TurnFile) {
+			turn.Decisions = append(turn.Decisions, turnDecision{Unit: 1})
+			turn.Signature = turn.sign(key)
+		}},
+		{name: "changed result hash and signed again", key: key, change: func(turn *TurnFile) {
+			turn.ResultHash = hash
+			turn.Signature = turn.sign(key)
+		}},
+		{name: "out of order", key: key, change: func(turn *TurnFile) {
+			turn.Player = 2
+			turn.Signature = turn.sign(key)
+		}},
+		{name: "other game", key: key, change: func(turn *TurnFile) {
+			turn.BaseHash = hash
+			turn.Signature = turn.sign(key)
+		}},
+	}
+	for _, tc := range tests {
+		changed := *turn
+		changed.Decisions = append([]turnDecision(nil), turn.Decisions...)
+		tc.change(&changed)
+		if err := second.ImportTurn(&changed, tc.key); err == nil {
+			t.Errorf("ImportTurn(), name:%s, got no error", tc.name)
+		}
+		if second.Board.StateHash() != hash || second.NextPlayer != 1 {
+			t.Fatalf("ImportTurn(), name:%s, changed the game", tc.name)
+		}
+	}
+	if err := second.ImportTurn(turn, key); err != nil {
+		t.Errorf("ImportTurn() of the original turn error = %v", err)
+	}
+}
+
+func TestReplayTooManyRandomDraws(t *testing.T) {
+	board := newLandBoard(2, 2)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	replay := &replayController{decisions: []turnDecision{
+		{Unit: board.Units[0].ID, RandomDraws: 1 << 60},
+		{City: &Coordinate{0, 1}, Production: "Tank", RandomDraws: 1 << 60},
+	}}
+	if _, _, err := replay.ChooseMove(board, &board.Units[0]); err == nil {
+		t.Errorf("ChooseMove() of a decision with %d random draws got no error", uint64(1<<60))
+	}
+	if replay.ChooseProduction(board, &board.Cities[0]); replay.err == nil {
+		t.Errorf("ChooseProduction() of a decision with %d random draws got no error", uint64(1<<60))
+	}
+}
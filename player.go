@@ -1,14 +1,23 @@
 package main
 
+import "os"
+
 // Player struct represents a player in the game
 type Player struct {
-	Name string
-	IsAI bool
+	Name       string
+	IsAI       bool
+	Controller Controller // decides the player's production and unit actions
 }
 
+// NewPlayer creates a player run by the AI, or by a human at the terminal.
 func NewPlayer(name string, isAI bool) *Player {
+	var controller Controller = &AIController{}
+	if !isAI {
+		controller = NewHumanController(os.Stdin, os.Stdout)
+	}
 	return &Player{
-		Name: name,
-		IsAI: isAI,
+		Name:       name,
+		IsAI:       isAI,
+		Controller: controller,
 	}
 }
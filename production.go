@@ -0,0 +1,115 @@
+package main
+
+import "fmt"
+
+// getPlayersCity returns the player's city at the coordinate, or an error if the player holds no
+// city there.
+func (g *GameBoard) getPlayersCity(player int, coordinate Coordinate) (*City, error) {
+	city := g.getCityAtCoordinates(coordinate)
+	if city == nil || int(city.OccupyingPlayer) != player {
+		return nil, fmt.Errorf("player %d holds no city at (%d, %d)", player, coordinate.PositionX, coordinate.PositionY)
+	}
+	return city, nil
+}
+
+// SetCityProduction sets what the player's city at the coordinate manufactures, once or over and
+// over, and empties its queue.
+func (g *GameBoard) SetCityProduction(player int, coordinate Coordinate, unitType UnitType, once bool) error {
+	city, err := g.getPlayersCity(player, coordinate)
+	if err != nil {
+		return err
+	}
+	if unitType == Blank || g.unitRules().definition(unitType) == nil {
+		return fmt.Errorf("unknown unit type %d", unitType)
+	}
+	if !g.canCityBuild(city, unitType) {
+		return fmt.Errorf("the city at (%d, %d) cannot build a %s away from the sea", coordinate.PositionX, coordinate.PositionY, g.unitTypeToString(unitType))
+	}
+	city.SetManufacturingUnit(g.unitRules(), unitType)
+	city.Queue = nil
+	city.BuildOnce = once
+	return nil
+}
+
+// canCityBuild checks if the city can manufacture the unit type: units which only move on water
+// are built in cities next to the sea.
+func (g *GameBoard) canCityBuild(city *City, unitType UnitType) bool {
+	definition := g.unitRules().definition(unitType)
+	if unitType == Blank || definition == nil {
+		return false
+	}
+	return city.IsCityNextToSea || definition.CanMoveOnLand || definition.CanFly
+}
+
+// getProductionChoices returns the unit types the city can manufacture.
+func (g *GameBoard) getProductionChoices(city *City) []UnitType {
+	var choices []UnitType
+	for _, unitType := range g.unitRules().unitTypes() {
+		if g.canCityBuild(city, unitType) {
+			choices = append(choices, unitType)
+		}
+	}
+	return choices
+}
+
+// setChosenProduction sets what the city manufactures to the unit type a controller chose,
+// leaving the city idle, to be asked again, if it cannot build it.
+func (g *GameBoard) setChosenProduction(city *City, unitType UnitType) {
+	if unitType != Blank && !g.canCityBuild(city, unitType) {
+		g.printf("City at (%d, %d) cannot build a %s\n", city.PositionX, city.PositionY, g.unitTypeToString(unitType))
+		unitType = Blank
+	}
+	city.SetManufacturingUnit(g.unitRules(), unitType)
+}
+
+// SetRallyPoint sets where the units the player's city at the coordinate produces head, or
+// clears it when the rally point is nil.
+func (g *GameBoard) SetRallyPoint(player int, coordinate Coordinate, rallyPoint *Coordinate) error {
+	city, err := g.getPlayersCity(player, coordinate)
+	if err != nil {
+		return err
+	}
+	if rallyPoint != nil && !g.isOnBoard(*rallyPoint) {
+		return fmt.Errorf("rally point (%d, %d) is off the board", rallyPoint.PositionX, rallyPoint.PositionY)
+	}
+	city.RallyPoint = rallyPoint
+	return nil
+}
+
+// chooseIdleProduction asks the controller what each of the player's cities which manufactures
+// nothing, such as a city which has built a unit once, should manufacture.
+func (g *GameBoard) chooseIdleProduction(player int, controller Controller) {
+	for i := range g.Cities {
+		city := &g.Cities[i]
+		if int(city.OccupyingPlayer) == player && city.ManufacturingUnit == Blank {
+			g.setChosenProduction(city, controller.ChooseProduction(g, city))
+		}
+	}
+}
+
+// getRallyMove returns the unit's next step towards its rally point. It returns false, and the
+// unit takes orders again, once the unit arrives or its way is blocked.
+func (g *GameBoard) getRallyMove(unit *Unit) (Coordinate, bool) {
+	if unit.Destination == nil {
+		return Coordinate{}, false
+	}
+	destination := *unit.Destination
+	if unit.PositionX == destination.PositionX && unit.PositionY == destination.PositionY {
+		unit.Destination = nil
+		return Coordinate{}, false
+	}
+	move := destination
+	if !g.isAdjacentMove(destination, unit) {
+		step := getSecondCoordinate(g.FindPath(destination, unit))
+		if step == nil {
+			unit.Destination = nil
+			return Coordinate{}, false
+		}
+		move = *step
+	}
+	if !g.isLegalMove(move, unit) || g.determineAction(move, unit) != ActionMove {
+		unit.Destination = nil
+		return Coordinate{}, false
+	}
+	return move, true
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSetCityProduction(t *testing.T) {
+	type test struct {
+		name       string
+		player     int
+		coordinate Coordinate
+		unitType   UnitType
+		wantErr    bool
+	}
+	tests := []test{
+		{name: "own city", player: 1, coordinate: Coordinate{0, 3}, unitType: Fighter},
+		{name: "enemy city", player: 2, coordinate: Coordinate{0, 3}, unitType: Fighter, wantErr: true},
+		{name: "no city", player: 1, coordinate: Coordinate{0, 0}, unitType: Fighter, wantErr: true},
+		{name: "nothing", player: 1, coordinate: Coordinate{0, 3}, unitType: Blank, wantErr: true},
+		{name: "unknown unit", player: 1, coordinate: Coordinate{0, 3}, unitType: UnitType(99), wantErr: true},
+		{name: "ship in an inland city", player: 1, coordinate: Coordinate{0, 3}, unitType: Destroyer, wantErr: true},
+	}
+	for _, tc := range tests {
+		board := newLandBoard(4, 4)
+		err := board.SetCityProduction(tc.player, tc.coordinate, tc.unitType, false)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("SetCityProduction(), name:%s, error = %v; want error %t", tc.name, err, tc.wantErr)
+		}
+		if err == nil && board.Cities[0].ManufacturingUnit != tc.unitType {
+			t.Errorf("SetCityProduction(), name:%s, city manufactures %s; want %s",
+				tc.name, unitTypeToString(board.Cities[0].ManufacturingUnit), unitTypeToString(tc.unitType))
+		}
+	}
+}
+
+func TestInlandCityProduction(t *testing.T) {
+	board := newLandBoard(4, 4)
+	city := &board.Cities[0]
+	for _, unitType := range board.getProductionChoices(city) {
+		if def := board.unitDefinitionOf(unitType); !def.CanMoveOnLand && !def.CanFly {
+			t.Errorf("getProductionChoices() of an inland city offers %s", board.unitTypeToString(unitType))
+		}
+	}
+
+	// a controller choosing a ship for an inland city leaves it idle, to be asked again
+	city.ManufacturingUnit = Blank
+	board.Player1 = &Player{Name: "player 1", Controller: &ScriptedController{Production: []UnitType{Destroyer}}}
+	if err := board.DoPlayerTurn(1); err != nil {
+		t.Fatalf("DoPlayerTurn() error = %v", err)
+	}
+	if city.ManufacturingUnit != Blank {
+		t.Errorf("DoPlayerTurn() left the inland city making %s; want nothing", unitTypeToString(city.ManufacturingUnit))
+	}
+
+	city.IsCityNextToSea = true
+	if err := board.SetCityProduction(1, Coordinate{0, 3}, Destroyer, false); err != nil {
+		t.Errorf("SetCityProduction() of a ship in a city next to the sea error = %v", err)
+	}
+}
+
+func TestBuildOnce(t *testing.T) {
+	board := newLandBoard(4, 4)
+	if err := board.SetCityProduction(1, Coordinate{0, 3}, Tank, true); err != nil {
+		t.Fatalf("SetCityProduction() error = %v", err)
+	}
+	city := &board.Cities[0]
+	city.DaysUntilUnitReady = 1
+	board.NextDay()
+	if len(board.Units) != 1 || city.ManufacturingUnit != Blank {
+		t.Fatalf("NextDay() of a city building once left %d units and the city making %s; want a tank and nothing",
+			len(board.Units), unitTypeToString(city.ManufacturingUnit))
+	}
+
+	// the player is asked what the idle city should manufacture at the start of the turn
+	board.Player1 = &Player{Name: "player 1", Controller: &ScriptedController{Production: []UnitType{Fighter}}}
+	if err := board.DoPlayerTurn(1); err != nil {
+		t.Fatalf("DoPlayerTurn() error = %v", err)
+	}
+	if city.ManufacturingUnit != Fighter {
+		t.Errorf("DoPlayerTurn() left the idle city making %s; want Fighter", unitTypeToString(city.ManufacturingUnit))
+	}
+}
+
+func TestRallyPoint(t *testing.T) {
+	board := newLandBoard(4, 4)
+	if err := board.SetRallyPoint(1, Coordinate{0, 3}, &Coordinate{9, 9}); err == nil {
+		t.Errorf("SetRallyPoint() off the board, want error")
+	}
+	if err := board.SetRallyPoint(1, Coordinate{0, 3}, &Coordinate{0, 1}); err != nil {
+		t.Fatalf("SetRallyPoint() error = %v", err)
+	}
+	city := &board.Cities[0]
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
+	city.DaysUntilUnitReady = 1
+	board.NextDay()
+
+	// the tank heads for the rally point without orders, then takes orders there
+	controller := &ScriptedController{Moves: map[int][]Coordinate{1: {{1, 0}}}}
+	board.Player1 = &Player{Name: "player 1", Controller: controller}
+	for day := 0; day < 3; day++ {
+		board.NextDay()
+		if err := board.DoPlayerTurn(1); err != nil {
+			t.Fatalf("DoPlayerTurn() error = %v", err)
+		}
+	}
+	tank := board.Units[0]
+	if tank.PositionX != 1 || tank.PositionY != 0 || tank.Destination != nil {
+		t.Errorf("DoPlayerTurn() left the tank at (%d, %d) heading for %v; want it to rally at (0, 1) and then move to (1, 0)",
+			tank.PositionX, tank.PositionY, tank.Destination)
+	}
+
+	// a unit whose way is blocked by an enemy takes orders
+	board = newLandBoard(4, 4)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(0, 1, Tank, 2))
+	board.Units[0].Destination = &Coordinate{0, 1}
+	if _, ok := board.getRallyMove(&board.Units[0]); ok || board.Units[0].Destination != nil {
+		t.Errorf("getRallyMove() onto an enemy = %t; want false and the rally point cleared", ok)
+	}
+}
+
+func TestHumanControllerProductionCommands(t *testing.T) {
+	board := newLandBoard(4, 4)
+	unit := NewUnit(1, 1, Tank, 1)
+	var out bytes.Buffer
+	input := "p 0,3\nFighter\ny\nr 0,3\nnonsense\n2,2\np 3,3\ns\n"
+	controller := NewHumanController(strings.NewReader(input), &out)
+	if _, ok, err := controller.ChooseMove(board, unit); ok || err != nil {
+		t.Fatalf("ChooseMove() = %t, %v; want the unit skipped", ok, err)
+	}
+	city := board.Cities[0]
+	if city.ManufacturingUnit != Fighter || !city.BuildOnce {
+		t.Errorf("ChooseMove() with p left the city making %s, once %t; want a Fighter once", unitTypeToString(city.ManufacturingUnit), city.BuildOnce)
+	}
+	if city.RallyPoint == nil || *city.RallyPoint != (Coordinate{2, 2}) {
+		t.Errorf("ChooseMove() with r left the rally point at %v; want (2, 2)", city.RallyPoint)
+	}
+	if !strings.Contains(out.String(), "player 1 holds no city at (3, 3)") {
+		t.Errorf("ChooseMove() with p on an enemy city did not report it")
+	}
+}
+
+func TestTUIControllerProductionKeys(t *testing.T) {
+	board := newLandBoard(3, 3)
+	board.addUnit(NewUnit(1, 1, Tank, 1))
+	// move the cursor to the city, build bombers over and over, then rally at (2, 0) and hold
+	ui := NewTUI(board, strings.NewReader("kllp3rrhhjjrs"), io.Discard)
+	controller := NewTUIController(ui)
+	if _, ok, err := controller.ChooseMove(board, &board.Units[0]); ok || err != nil {
+		t.Fatalf("ChooseMove() = %t, %v; want the unit held", ok, err)
+	}
+	city := board.Cities[0]
+	if city.ManufacturingUnit != Bomber || city.BuildOnce {
+		t.Errorf("ChooseMove() with p left the city making %s, once %t; want Bombers over and over", unitTypeToString(city.ManufacturingUnit), city.BuildOnce)
+	}
+	if city.RallyPoint == nil || *city.RallyPoint != (Coordinate{2, 0}) {
+		t.Errorf("ChooseMove() with r left the rally point at %v; want (2, 0)", city.RallyPoint)
+	}
+}
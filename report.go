@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// runReportCommand runs the "report" command with its command line arguments.
+func runReportCommand(args []string) error {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	gamePath := flags.String("game", "", "saved game or play-by-email game file to report on")
+	player := flags.Int("player", 1, "player whose cities are reported")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *gamePath == "" {
+		return errors.New("no game to report on, use -game")
+	}
+	if *player != 1 && *player != 2 {
+		return fmt.Errorf("player %d is not 1 or 2", *player)
+	}
+	board, err := readGameFile(*gamePath)
+	if err != nil {
+		return err
+	}
+	return board.WriteProductionReport(os.Stdout, *player)
+}
+
+// WriteProductionReport writes a line for each of the player's cities, from the top left, with
+// the city's level and the days until it produces its current unit and each unit in its queue.
+func (g *GameBoard) WriteProductionReport(w io.Writer, player int) error {
+	var cities []*City
+	for i := range g.Cities {
+		if int(g.Cities[i].OccupyingPlayer) == player {
+			cities = append(cities, &g.Cities[i])
+		}
+	}
+	sort.Slice(cities, func(a, b int) bool {
+		if cities[a].PositionX != cities[b].PositionX {
+			return cities[a].PositionX < cities[b].PositionX
+		}
+		return cities[a].PositionY < cities[b].PositionY
+	})
+	if _, err := fmt.Fprintf(w, "day %d, player %d, %d cities\n", g.Day, player, len(cities)); err != nil {
+		return err
+	}
+	for _, city := range cities {
+		var production []string
+		units := append([]UnitType{city.ManufacturingUnit}, city.Queue...)
+		for i, eta := range city.getProductionETAs(g.unitRules()) {
+			production = append(production, fmt.Sprintf("%s in %d days", g.unitTypeToString(units[i]), eta))
+		}
+		if len(production) == 0 {
+			production = []string{"nothing"}
+		}
+		if _, err := fmt.Fprintf(w, "(%d, %d) level %d: %s\n",
+			city.PositionX, city.PositionY, city.Level(), strings.Join(production, ", then ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
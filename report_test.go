@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestWriteProductionReport(t *testing.T) {
+	board := newIslandBoard(3, 3)
+	city := addCity(board, Coordinate{2, 2}, 1)
+	city.SetManufacturingUnit(defaultRules.unitRules, Tank)
+	city.QueueUnit(Fighter)
+	city.HeldDays = cityLevelDays
+	addCity(board, Coordinate{0, 1}, 1)
+	addCity(board, Coordinate{1, 1}, 2)
+
+	var buf bytes.Buffer
+	if err := board.WriteProductionReport(&buf, 1); err != nil {
+		t.Fatalf("WriteProductionReport() error = %v", err)
+	}
+	want := fmt.Sprintf("day 0, player 1, 2 cities\n(0, 1) level 0: nothing\n(2, 2) level 1: Tank in %d days, then Fighter in %d days\n",
+		GetDaysToProduceUnit(Tank), GetDaysToProduceUnit(Tank)+(GetDaysToProduceUnit(Fighter)*90+50)/100)
+	if got := buf.String(); got != want {
+		t.Errorf("WriteProductionReport() = %q; want %q", got, want)
+	}
+}
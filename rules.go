@@ -0,0 +1,294 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ruleSetsJSON is the file defining the preset rule sets.
+//
+//go:embed rules.json
+var ruleSetsJSON []byte
+
+// CombatModel decides the odds of an attack.
+type CombatModel string
+
+const (
+	// CombatClassic gives every attack even odds, terrain aside, and an attacker only damages a
+	// unit no stronger than itself.
+	CombatClassic CombatModel = "classic"
+	// CombatStrength gives an attack odds in proportion to the strengths of the attacker and
+	// the defender.
+	CombatStrength CombatModel = "strength"
+)
+
+// VictoryCondition decides when a player has won.
+type VictoryCondition string
+
+const (
+	// VictoryConquest is won when the opponent holds no city.
+	VictoryConquest VictoryCondition = "conquest"
+	// VictoryAnnihilation is won when the opponent holds no city and has no unit left.
+	VictoryAnnihilation VictoryCondition = "annihilation"
+	// VictoryMajority is won when the player holds two thirds of all the cities, or by conquest.
+	VictoryMajority VictoryCondition = "majority"
+)
+
+// classicRules is the name of the rule set of the original game, played unless another is chosen.
+const classicRules = "classic"
+
+// RuleSet is a named bundle of house rules: the unit types, the strength of captured cities,
+// how fights are decided, when the game is won, and whether units repair and fire from range.
+type RuleSet struct {
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	CityStrength int               `json:"cityStrength"` // strength of a city when it is captured
+	Combat       CombatModel       `json:"combat"`
+	Victory      VictoryCondition  `json:"victory"`
+	Repair       bool              `json:"repair,omitempty"`     // units regain a point of strength a day in their own cities
+	RangedFire   bool              `json:"rangedFire,omitempty"` // units with an attack range above 1 attack enemies that far away
+	Supply       bool              `json:"supply,omitempty"`     // land units out of supply lose a point of strength a day
+	Weather      bool              `json:"weather,omitempty"`    // days have weather, nights and seasons
+	Units        []json.RawMessage `json:"units,omitempty"`      // overrides of the standard unit types, as in a unit rules file
+
+	unitRules *UnitRules
+}
+
+// ruleSets are the preset rule sets, classic first.
+var ruleSets = mustParseRuleSets()
+
+// defaultRules are the classic rules, played on boards without a rule set of their own.
+var defaultRules = ruleSets[0]
+
+// mustParseRuleSets parses the embedded rule sets, which must be valid.
+func mustParseRuleSets() []*RuleSet {
+	var file struct {
+		RuleSets []*RuleSet `json:"ruleSets"`
+	}
+	if err := json.Unmarshal(ruleSetsJSON, &file); err != nil {
+		panic(fmt.Sprintf("rule sets: %v", err))
+	}
+	for _, rules := range file.RuleSets {
+		if err := rules.validate(); err != nil {
+			panic(fmt.Sprintf("rule set %q: %v", rules.Name, err))
+		}
+	}
+	if len(file.RuleSets) == 0 || file.RuleSets[0].Name != classicRules {
+		panic("rule sets: the classic rules must come first")
+	}
+	return file.RuleSets
+}
+
+// validate checks the rule set is complete, and parses its unit types.
+func (r *RuleSet) validate() error {
+	switch {
+	case r.Name == "":
+		return errors.New("a rule set needs a name")
+	case r.CityStrength <= 0:
+		return fmt.Errorf("city strength %d is not positive", r.CityStrength)
+	case r.Combat != CombatClassic && r.Combat != CombatStrength:
+		return fmt.Errorf("unknown combat model %q", r.Combat)
+	case r.Victory != VictoryConquest && r.Victory != VictoryAnnihilation && r.Victory != VictoryMajority:
+		return fmt.Errorf("unknown victory condition %q", r.Victory)
+	}
+	units, err := overrideUnitRules(DefaultUnitRules(), r.Units)
+	if err != nil {
+		return err
+	}
+	r.unitRules = units
+	return nil
+}
+
+// RuleSetNames returns the names of the preset rule sets.
+func RuleSetNames() []string {
+	names := make([]string, len(ruleSets))
+	for i, rules := range ruleSets {
+		names[i] = rules.Name
+	}
+	return names
+}
+
+// getRuleSet returns the preset rule set with the name.
+func getRuleSet(name string) (*RuleSet, error) {
+	for _, rules := range ruleSets {
+		if rules.Name == name {
+			return rules, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown rule set %q, want one of %s", name, strings.Join(RuleSetNames(), ", "))
+}
+
+// loadRuleSet returns the named preset rule set, or the classic rules if name is empty, with
+// the unit types overridden by the unit rules file at unitsPath, unless it is empty.
+func loadRuleSet(name, unitsPath string) (*RuleSet, error) {
+	rules := defaultRules
+	if name != "" {
+		var err error
+		if rules, err = getRuleSet(name); err != nil {
+			return nil, err
+		}
+	}
+	if unitsPath == "" {
+		return rules, nil
+	}
+	units, err := LoadUnitRulesFile(rules.unitRules, unitsPath)
+	if err != nil {
+		return nil, err
+	}
+	return rules.withUnitRules(units), nil
+}
+
+// withUnitRules returns a copy of the rule set played with the unit types of units.
+func (r *RuleSet) withUnitRules(units *UnitRules) *RuleSet {
+	rules := *r
+	rules.unitRules = units
+	return &rules
+}
+
+// rules returns the rule set of the board, the classic rules unless it has one of its own.
+func (g *GameBoard) rules() *RuleSet {
+	if g.Rules == nil {
+		return defaultRules
+	}
+	return g.Rules
+}
+
+// unitRules returns the unit types of the board's rule set.
+func (g *GameBoard) unitRules() *UnitRules {
+	return g.rules().unitRules
+}
+
+// getCombatOutcome decides the outcome of an attack by the unit on the defender at the coordinate,
+// as the combat model of the board's rules has it, with the odds shifted by the terrain and by
+// anti-aircraft units.
+func (g *GameBoard) getCombatOutcome(attacker *Unit, coordinate Coordinate) bool {
+	attackBonus, defenceBonus := g.getAntiAircraftBonuses(attacker, coordinate)
+	defenceBonus += g.getTerrainDefenceBonus(coordinate)
+	attackRank, defenceRank := g.getRankBonuses(attacker, coordinate)
+	attackBonus += attackRank
+	defenceBonus += defenceRank
+	if g.rules().Combat != CombatStrength {
+		if attackBonus == 0 && defenceBonus == 0 {
+			return g.getAttackOutcome()
+		}
+		return g.random().Intn(100+defenceBonus) < attackOddsPercent*(100+attackBonus)/100
+	}
+	attack := attacker.Strength * (100 + attackBonus)
+	defence := g.getDefenderStrength(attacker, coordinate) * (100 + defenceBonus)
+	return g.random().Intn(attack+defence) < attack
+}
+
+// getDefenderStrength returns the strength of the enemy unit or city the unit attacks at the coordinate.
+func (g *GameBoard) getDefenderStrength(attacker *Unit, coordinate Coordinate) int {
+	if defender := g.getUnitAtCoordinates(coordinate, attacker.Player); defender != nil {
+		return defender.Strength
+	}
+	if city := g.getCityAtCoordinates(coordinate); city != nil {
+		return city.Strength
+	}
+	return 1
+}
+
+// isRangedAttack checks if the unit can fire on an enemy unit at the coordinate, which is beyond
+// the next cell but within its attack range, if the unit can fire from range. A unit
+// sees as far as it fires, except into a forest, where its player must have the target in sight.
+func (g *GameBoard) isRangedAttack(coordinate Coordinate, unit *Unit) bool {
+	if !g.canFireFromRange(unit) {
+		return false
+	}
+	if coordinate.PositionX < 0 || coordinate.PositionX >= g.Rows || coordinate.PositionY < 0 || coordinate.PositionY >= g.Columns {
+		return false
+	}
+	distance := maxInt(abs(coordinate.PositionX-unit.PositionX), abs(coordinate.PositionY-unit.PositionY))
+	if distance <= 1 || distance > unit.AttackRange {
+		return false
+	}
+	target := g.getUnitAtCoordinates(coordinate, unit.Player)
+	return target != nil && (!g.isHiddenByTerrain(target) || g.isUnitInSightOfPlayer(unit.Player, target))
+}
+
+// getRangedTargets returns the coordinates of the enemy units the unit can fire on from range.
+func (g *GameBoard) getRangedTargets(unit *Unit) []Coordinate {
+	if !g.canFireFromRange(unit) {
+		return nil
+	}
+	var targets []Coordinate
+	for i := unit.PositionX - unit.AttackRange; i <= unit.PositionX+unit.AttackRange; i++ {
+		for j := unit.PositionY - unit.AttackRange; j <= unit.PositionY+unit.AttackRange; j++ {
+			if g.isRangedAttack(Coordinate{i, j}, unit) {
+				targets = append(targets, Coordinate{i, j})
+			}
+		}
+	}
+	return targets
+}
+
+// resolveRangedAttack determines the outcome of a unit firing on a defending unit from range.
+// Unlike a close attack, a miss does the attacker no harm.
+func (g *GameBoard) resolveRangedAttack(attacker, defender *Unit, attackOutcome bool) {
+	g.printf("resolveRangedAttack defender %d, %d\n", defender.PositionX, defender.PositionY)
+	attacker.MovesLeftThisDay--
+	if attacker.CanFly {
+		attacker.Fuel--
+	}
+	if !attackOutcome {
+		return
+	}
+	defender.Strength -= g.getAttackDamage(attacker)
+	g.recordBattle(attacker, defender.Strength <= 0)
+	if defender.Strength <= 0 {
+		g.printf("Defender is destroyed\n")
+		g.emit(EventUnitDestroyed, defender.Player, defender.Type, Coordinate{defender.PositionX, defender.PositionY})
+		g.removeUnit(defender)
+	} else {
+		g.recordBattle(defender, false)
+	}
+}
+
+// repairUnits gives every damaged unit in a city of its player a point of strength back.
+func (g *GameBoard) repairUnits() {
+	for i := range g.Units {
+		unit := &g.Units[i]
+		if unit.Strength >= g.unitDefinitionOf(unit.Type).Strength {
+			continue
+		}
+		city := g.getCityAtCoordinates(Coordinate{unit.PositionX, unit.PositionY})
+		if city != nil && int(city.OccupyingPlayer) == unit.Player {
+			unit.Strength++
+		}
+	}
+}
+
+// hasPlayerConquered checks if the opponent of the player holds no city.
+func (g *GameBoard) hasPlayerConquered(playerID int) bool {
+	for _, city := range g.Cities {
+		if city.OccupyingPlayer != Unoccupied && int(city.OccupyingPlayer) != playerID {
+			return false
+		}
+	}
+	return true
+}
+
+// hasMajorityOfCities checks if the player holds two thirds of all the cities.
+func (g *GameBoard) hasMajorityOfCities(playerID int) bool {
+	held := 0
+	for _, city := range g.Cities {
+		if int(city.OccupyingPlayer) == playerID {
+			held++
+		}
+	}
+	return held*3 >= len(g.Cities)*2
+}
+
+// hasOpponentUnits checks if the opponent of the player has any unit left.
+func (g *GameBoard) hasOpponentUnits(playerID int) bool {
+	for _, unit := range g.Units {
+		if unit.Player != playerID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+// useRuleSetForTest plays the board under the named rule set.
+func useRuleSetForTest(t *testing.T, board *GameBoard, name string) {
+	t.Helper()
+	rules, err := getRuleSet(name)
+	if err != nil {
+		t.Fatalf("getRuleSet(%q) error = %v", name, err)
+	}
+	board.Rules = rules
+}
+
+func TestRuleSets(t *testing.T) {
+	type test struct {
+		name         string
+		cityStrength int
+		tankDays     int
+		combat       CombatModel
+		victory      VictoryCondition
+	}
+	tests := []test{
+		{name: "classic", cityStrength: 2, tankDays: 4, combat: CombatClassic, victory: VictoryConquest},
+		{name: "modern", cityStrength: 3, tankDays: 4, combat: CombatStrength, victory: VictoryAnnihilation},
+		{name: "blitz", cityStrength: 1, tankDays: 2, combat: CombatClassic, victory: VictoryMajority},
+	}
+	for _, tc := range tests {
+		board := NewGameBoard(1, 1)
+		useRuleSetForTest(t, board, tc.name)
+		if got := board.newCity(0, 0).Strength; got != tc.cityStrength {
+			t.Errorf("newCity(), name:%s, got city strength %d; want %d", tc.name, got, tc.cityStrength)
+		}
+		if got := board.unitDefinitionOf(Tank).DaysToProduce; got != tc.tankDays {
+			t.Errorf("unitDefinitionOf(Tank), name:%s, got %d days to produce; want %d", tc.name, got, tc.tankDays)
+		}
+		if rules := board.rules(); rules.Combat != tc.combat || rules.Victory != tc.victory {
+			t.Errorf("rules(), name:%s, got %s combat and %s victory; want %s and %s",
+				tc.name, rules.Combat, rules.Victory, tc.combat, tc.victory)
+		}
+	}
+	// other boards keep the classic rules
+	if got := NewGameBoard(1, 1).unitDefinitionOf(Tank).DaysToProduce; got != GetDaysToProduceUnit(Tank) {
+		t.Errorf("unitDefinitionOf(Tank) of a board without rules, got %d days to produce; want %d", got, GetDaysToProduceUnit(Tank))
+	}
+
+	if _, err := loadRuleSet("chess", ""); err == nil {
+		t.Errorf("loadRuleSet(chess), got no error; want an error")
+	}
+	if rules, err := loadRuleSet("", ""); err != nil || rules != defaultRules {
+		t.Errorf("loadRuleSet() of no name = %v, %v; want the classic rules", rules, err)
+	}
+}
+
+func TestRuleSetValidate(t *testing.T) {
+	type test struct {
+		name  string
+		rules RuleSet
+	}
+	tests := []test{
+		{name: "no name", rules: RuleSet{CityStrength: 1, Combat: CombatClassic, Victory: VictoryConquest}},
+		{name: "weak cities", rules: RuleSet{Name: "x", Combat: CombatClassic, Victory: VictoryConquest}},
+		{name: "unknown combat", rules: RuleSet{Name: "x", CityStrength: 1, Combat: "dice", Victory: VictoryConquest}},
+		{name: "unknown victory", rules: RuleSet{Name: "x", CityStrength: 1, Combat: CombatClassic, Victory: "points"}},
+		{name: "invalid unit", rules: RuleSet{Name: "x", CityStrength: 1, Combat: CombatClassic, Victory: VictoryConquest,
+			Units: []json.RawMessage{[]byte(`{"name": "Tank", "strength": 0}`)}}},
+	}
+	for _, tc := range tests {
+		if err := tc.rules.validate(); err == nil {
+			t.Errorf("validate(), name:%s, got no error; want an error", tc.name)
+		}
+	}
+}
+
+func TestHasPlayerWonRules(t *testing.T) {
+	type test struct {
+		name         string
+		rules        string
+		player2City  bool // player 2 still holds a city
+		player2Unit  bool // player 2 still has a unit
+		player1Share int  // cities of the six held by player 1
+		want         bool
+	}
+	tests := []test{
+		{name: "classic conquest", rules: "classic", player2Unit: true, player1Share: 6, want: true},
+		{name: "classic with an enemy city", rules: "classic", player2City: true, player1Share: 5, want: false},
+		{name: "annihilation with an enemy unit", rules: "modern", player2Unit: true, player1Share: 6, want: false},
+		{name: "annihilation", rules: "modern", player1Share: 6, want: true},
+		{name: "majority of two thirds", rules: "blitz", player2City: true, player1Share: 4, want: true},
+		{name: "short of a majority", rules: "blitz", player2City: true, player1Share: 3, want: false},
+	}
+	for _, tc := range tests {
+		board := newIslandBoard(1, 6)
+		useRuleSetForTest(t, board, tc.rules)
+		for i := 0; i < 6; i++ {
+			city := NewCity(0, i)
+			if i < tc.player1Share {
+				city.OccupyCity(1)
+			} else if tc.player2City && i == 5 {
+				city.OccupyCity(2)
+			}
+			board.Grid[0][i].HasCity = true
+			board.Cities = append(board.Cities, *city)
+		}
+		if tc.player2Unit {
+			board.addUnit(NewUnit(0, 0, Fighter, 2))
+		}
+		if got := board.hasPlayerWon(1); got != tc.want {
+			t.Errorf("hasPlayerWon(), name:%s, got %t; want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRepairUnits(t *testing.T) {
+	for _, rules := range []string{"classic", "modern"} {
+		board := newLandBoard(3, 3)
+		useRuleSetForTest(t, board, rules)
+		board.Output = io.Discard
+		board.addUnit(NewUnit(0, 2, Tank, 1)) // in player 1's city
+		board.addUnit(NewUnit(1, 1, Tank, 1)) // in the field
+		for i := range board.Units {
+			board.Units[i].Strength = 1
+		}
+		board.NextDay()
+		board.NextDay()
+		want := 1
+		if rules == "modern" {
+			want = GetNewUnitStrength(Tank) // repaired a point a day, up to full strength
+		}
+		if got := board.Units[0].Strength; got != want {
+			t.Errorf("NextDay(), rules %s, tank in its city has strength %d; want %d", rules, got, want)
+		}
+		if got := board.Units[1].Strength; got != 1 {
+			t.Errorf("NextDay(), rules %s, tank in the field has strength %d; want 1", rules, got)
+		}
+	}
+}
+
+func TestRangedFire(t *testing.T) {
+	board := newTerrainBoard("SSSSS")
+	board.addUnit(NewUnit(0, 0, Battleship, 1))
+	board.addUnit(NewUnit(0, 3, Destroyer, 2))
+	target := Coordinate{0, 3}
+
+	if board.isLegalMove(target, &board.Units[0]) {
+		t.Errorf("isLegalMove() of firing from range under the classic rules = true; want false")
+	}
+
+	useRuleSetForTest(t, board, "modern")
+	battleship := &board.Units[0]
+	if !board.isLegalMove(target, battleship) {
+		t.Fatalf("isLegalMove() of a battleship firing from range under the modern rules = false; want true")
+	}
+	if board.isLegalMove(Coordinate{0, 4}, battleship) {
+		t.Errorf("isLegalMove() of firing on an empty cell = true; want false")
+	}
+	if targets := board.getRangedTargets(battleship); len(targets) != 1 || targets[0] != target {
+		t.Errorf("getRangedTargets() = %v; want [%v]", targets, target)
+	}
+	destroyer := &board.Units[1]
+	if board.isLegalMove(Coordinate{0, 0}, destroyer) {
+		t.Errorf("isLegalMove() of a destroyer firing beyond its range = true; want false")
+	}
+
+	strength := battleship.Strength
+	for i := 0; i < 10 && len(board.Units) == 2; i++ {
+		battleship.MovesLeftThisDay = 1
+		battleship.PositionX, battleship.PositionY = 0, 0
+		board.attemptMoveTo(target, battleship)
+		if battleship.PositionY != 0 || battleship.Strength != strength {
+			t.Fatalf("attemptMoveTo() firing from range, battleship at column %d with strength %d; want column 0 with %d",
+				battleship.PositionY, battleship.Strength, strength)
+		}
+	}
+	if board.Units[0].Type != Battleship {
+		t.Errorf("attemptMoveTo() firing from range destroyed the battleship")
+	}
+}
+
+func TestStrengthCombat(t *testing.T) {
+	board := newIslandBoard(1, 2)
+	useRuleSetForTest(t, board, "modern")
+	board.addUnit(NewUnit(0, 1, Tank, 2))
+	attacker := NewUnit(0, 0, Tank, 1)
+	attacker.Strength = 6 // three times the defender's strength
+	wins := 0
+	for i := 0; i < 4000; i++ {
+		if board.getCombatOutcome(attacker, Coordinate{0, 1}) {
+			wins++
+		}
+	}
+	if wins < 2800 || wins > 3200 {
+		t.Errorf("getCombatOutcome() of 6 against 2 succeeded %d times in 4000; want about 3000", wins)
+	}
+}
+
+func TestSaveRecordsRules(t *testing.T) {
+	board := newLandBoard(3, 3)
+	useRuleSetForTest(t, board, "blitz")
+	var buf bytes.Buffer
+	if err := board.SaveGame(&buf); err != nil {
+		t.Fatalf("SaveGame() error = %v", err)
+	}
+	loaded, err := LoadGame(&buf)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if loaded.rules().Name != "blitz" || loaded.unitDefinitionOf(Tank).DaysToProduce != 2 {
+		t.Errorf("LoadGame() played the %s rules; want the blitz rules of the save", loaded.rules().Name)
+	}
+	if got := NewGameBoard(1, 1).rules().Name; got != classicRules {
+		t.Errorf("LoadGame() left new boards playing the %s rules; want the classic rules", got)
+	}
+}
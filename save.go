@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// saveVersion is the version of the save format written by SaveGame.
+const saveVersion = 1
+
+// SavedGame is the save format of a GameBoard.
+type SavedGame struct {
+	Version     int         `json:"version"`
+	Rows        int         `json:"rows"`
+	Columns     int         `json:"columns"`
+	Day         int         `json:"day"`
+	Seed        int64       `json:"seed"`
+	RandomDraws uint64      `json:"randomDraws"` // random numbers drawn since the board was seeded
+	NextUnitID  int         `json:"nextUnitId"`
+	Players     [2]string   `json:"players"`
+	Grid        []string    `json:"grid"` // one string per row of terrainToSymbol letters, lower case under the fog of war
+	Fog         [2][]string `json:"fog"`  // fog of war of each player: '?' fog, '.' seen, empty until the player has seen the map
+	Cities      []City      `json:"cities"`
+	Units       []Unit      `json:"units"`
+	Rules       string      `json:"rules,omitempty"`     // name of the rule set in play, classic when empty
+	UnitTypes   *UnitRules  `json:"unitTypes,omitempty"` // unit types played, with any rules file on top; the rule set's when absent
+}
+
+// SaveGame writes the game to w as JSON.
+// Players' controllers are not saved, only their names.
+func (g *GameBoard) SaveGame(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g.newSavedGame())
+}
+
+// newSavedGame returns the save of the game.
+func (g *GameBoard) newSavedGame() SavedGame {
+	saved := SavedGame{
+		Version:     saveVersion,
+		Rows:        g.Rows,
+		Columns:     g.Columns,
+		Day:         g.Day,
+		Seed:        g.Seed,
+		RandomDraws: g.randomDraws(),
+		NextUnitID:  g.nextUnitID,
+		Cities:      g.Cities,
+		Units:       g.Units,
+		Rules:       g.rules().Name,
+		UnitTypes:   g.unitRules(),
+	}
+	for i, player := range []*Player{g.Player1, g.Player2} {
+		if player != nil {
+			saved.Players[i] = player.Name
+		}
+	}
+	for _, row := range g.Grid {
+		var sb strings.Builder
+		for _, cell := range row {
+			c := rune(terrainToSymbol(cell))
+			if cell.IsFog {
+				c += 'a' - 'A'
+			}
+			sb.WriteRune(c)
+		}
+		saved.Grid = append(saved.Grid, sb.String())
+	}
+	for i, fog := range g.Fog {
+		for _, row := range fog {
+			var sb strings.Builder
+			for _, isFog := range row {
+				if isFog {
+					sb.WriteByte('?')
+				} else {
+					sb.WriteByte('.')
+				}
+			}
+			saved.Fog[i] = append(saved.Fog[i], sb.String())
+		}
+	}
+	return saved
+}
+
+// LoadGame reads a game written by SaveGame.
+// The players of the loaded game have no controller, so they are run by the AI until one is set.
+func LoadGame(r io.Reader) (*GameBoard, error) {
+	var saved SavedGame
+	if err := json.NewDecoder(r).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return newGameBoardFromSave(saved)
+}
+
+// newGameBoardFromSave recreates the board of a saved game.
+func newGameBoardFromSave(saved SavedGame) (*GameBoard, error) {
+	if saved.Version != saveVersion {
+		return nil, fmt.Errorf("unsupported save version %d", saved.Version)
+	}
+	if saved.Rows <= 0 || saved.Columns <= 0 || len(saved.Grid) != saved.Rows {
+		return nil, fmt.Errorf("save has %d rows of grid for a %dx%d board", len(saved.Grid), saved.Rows, saved.Columns)
+	}
+
+	name := saved.Rules
+	if name == "" {
+		name = classicRules
+	}
+	rules, err := getRuleSet(name)
+	if err != nil {
+		return nil, err
+	}
+	if saved.UnitTypes != nil {
+		if err := saved.UnitTypes.Validate(); err != nil {
+			return nil, fmt.Errorf("unit types of the save: %w", err)
+		}
+		rules = rules.withUnitRules(saved.UnitTypes)
+	}
+
+	g := NewGameBoardWithSeed(saved.Rows, saved.Columns, saved.Seed)
+	g.Rules = rules
+	g.Day = saved.Day
+	if err := g.skipRandomDraws(saved.RandomDraws); err != nil {
+		return nil, err
+	}
+	g.nextUnitID = saved.NextUnitID
+	for i, row := range saved.Grid {
+		if len(row) != saved.Columns {
+			return nil, fmt.Errorf("row %d of the save has %d cells; want %d", i, len(row), saved.Columns)
+		}
+		for j, c := range row {
+			cell := &g.Grid[i][j]
+			isLand, terrain, ok := terrainFromSymbol(unicode.ToUpper(c))
+			if !ok {
+				return nil, fmt.Errorf("unknown terrain %q at (%d, %d) in the save", c, i, j)
+			}
+			cell.IsLand, cell.Terrain = isLand, terrain
+			cell.IsFog = unicode.IsLower(c)
+		}
+	}
+	for i, rows := range saved.Fog {
+		if len(rows) == 0 {
+			continue
+		}
+		if len(rows) != saved.Rows {
+			return nil, fmt.Errorf("fog of player %d has %d rows; want %d", i+1, len(rows), saved.Rows)
+		}
+		fog := make([][]bool, saved.Rows)
+		for x, row := range rows {
+			if len(row) != saved.Columns {
+				return nil, fmt.Errorf("fog of player %d has %d cells in row %d; want %d", i+1, len(row), x, saved.Columns)
+			}
+			fog[x] = make([]bool, saved.Columns)
+			for y := range row {
+				fog[x][y] = row[y] == '?'
+			}
+		}
+		g.Fog[i] = fog
+	}
+	g.Cities = append([]City(nil), saved.Cities...)
+	for _, city := range g.Cities {
+		if city.PositionX < 0 || city.PositionX >= g.Rows || city.PositionY < 0 || city.PositionY >= g.Columns {
+			return nil, fmt.Errorf("city at (%d, %d) is off the board", city.PositionX, city.PositionY)
+		}
+		g.Grid[city.PositionX][city.PositionY].HasCity = true
+		for _, unitType := range append([]UnitType{city.ManufacturingUnit}, city.Queue...) {
+			if unitType != Blank && g.unitRules().definition(unitType) == nil {
+				return nil, fmt.Errorf("city at (%d, %d) manufactures unit type %d, which the save does not define", city.PositionX, city.PositionY, unitType)
+			}
+		}
+	}
+	g.Units = append([]Unit(nil), saved.Units...)
+	ids := make(map[int]bool)
+	for _, unit := range g.Units {
+		if ids[unit.ID] {
+			return nil, fmt.Errorf("more than one unit has ID %d", unit.ID)
+		}
+		ids[unit.ID] = true
+		if unit.PositionX < 0 || unit.PositionX >= g.Rows || unit.PositionY < 0 || unit.PositionY >= g.Columns {
+			return nil, fmt.Errorf("unit %d at (%d, %d) is off the board", unit.ID, unit.PositionX, unit.PositionY)
+		}
+		if g.unitRules().definition(unit.Type) == nil {
+			return nil, fmt.Errorf("unit %d is of unit type %d, which the save does not define", unit.ID, unit.Type)
+		}
+	}
+	g.Player1 = &Player{Name: saved.Players[0], IsAI: true}
+	g.Player2 = &Player{Name: saved.Players[1], IsAI: true}
+	return g, nil
+}
+
+// StateHash returns a hash of the game state, which is the same for two boards
+// if and only if their saves are the same.
+func (g *GameBoard) StateHash() string {
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(g.newSavedGame())
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestSaveGame(t *testing.T) {
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	board.Player1 = &Player{Name: "alice", IsAI: true}
+	board.Player2 = &Player{Name: "bob", IsAI: true}
+	board.DayZero()
+	for day := 0; day < 5; day++ {
+		board.NextDay()
+		board.DoPlayerTurnAI(1)
+		board.DoPlayerTurnAI(2)
+	}
+
+	var buf bytes.Buffer
+	if err := board.SaveGame(&buf); err != nil {
+		t.Fatalf("SaveGame() error = %v", err)
+	}
+	loaded, err := LoadGame(&buf)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	loaded.Output = io.Discard
+
+	if !reflect.DeepEqual(loaded.Grid, board.Grid) {
+		t.Errorf("LoadGame() grid differs from the saved grid")
+	}
+	if !reflect.DeepEqual(loaded.Fog, board.Fog) {
+		t.Errorf("LoadGame() fog of war differs from the saved fog of war")
+	}
+	if !reflect.DeepEqual(loaded.Units, board.Units) || !reflect.DeepEqual(loaded.Cities, board.Cities) {
+		t.Errorf("LoadGame() units or cities differ from the saved ones")
+	}
+	if loaded.Player1.Name != "alice" || loaded.Player2.Name != "bob" {
+		t.Errorf("LoadGame() players = %q, %q; want alice, bob", loaded.Player1.Name, loaded.Player2.Name)
+	}
+	if loaded.StateHash() != board.StateHash() {
+		t.Errorf("StateHash() of the loaded game differs from the saved game")
+	}
+
+	// the loaded game goes on exactly as the saved one
+	for day := 0; day < 5; day++ {
+		for _, g := range []*GameBoard{board, loaded} {
+			g.NextDay()
+			g.DoPlayerTurnAI(1)
+			g.DoPlayerTurnAI(2)
+		}
+	}
+	if loaded.StateHash() != board.StateHash() {
+		t.Errorf("the loaded game played differently from the saved game")
+	}
+}
+
+func TestLoadGameInvalid(t *testing.T) {
+	type test struct {
+		name string
+		save string
+	}
+	tests := []test{
+		{name: "not JSON", save: "L"},
+		{name: "unknown version", save: `{"version":99,"rows":1,"columns":1,"grid":["L"]}`},
+		{name: "short grid", save: `{"version":1,"rows":2,"columns":1,"grid":["L"]}`},
+		{name: "unknown terrain", save: `{"version":1,"rows":1,"columns":1,"grid":["X"]}`},
+		{name: "city off the board", save: `{"version":1,"rows":1,"columns":1,"grid":["L"],"cities":[{"PositionX":3}]}`},
+		{name: "undefined unit type", save: `{"version":1,"rows":1,"columns":1,"grid":["L"],"units":[{"Type":13}]}`},
+		{name: "undefined production", save: `{"version":1,"rows":1,"columns":1,"grid":["L"],"cities":[{"ManufacturingUnit":1,"Queue":[13]}]}`},
+		{name: "too many random draws", save: `{"version":1,"rows":1,"columns":1,"grid":["L"],"randomDraws":1000000000000000000}`},
+		{name: "duplicate unit IDs", save: `{"version":1,"rows":1,"columns":1,"grid":["L"],"units":[{"ID":1,"Type":1},{"ID":1,"Type":1}]}`},
+		{name: "invalid unit types", save: `{"version":1,"rows":1,"columns":1,"grid":["L"],"unitTypes":{"units":[]}}`},
+	}
+	for _, tc := range tests {
+		if _, err := LoadGame(bytes.NewBufferString(tc.save)); err == nil {
+			t.Errorf("LoadGame(), name:%s, got no error", tc.name)
+		}
+	}
+}
+
+func TestSaveUnitTypes(t *testing.T) {
+	const file = `{"units": [
+		{"name": "Tank", "strength": 3},
+		{"name": "Hovercraft", "symbol": "V", "strength": 2, "movesPerDay": 3, "daysToProduce": 6,
+		 "canMoveOnLand": true, "canMoveOnWater": true, "attackRange": 1, "attacksPerDay": 1}
+	]}`
+	units, err := ParseUnitRules(DefaultUnitRules(), []byte(file))
+	if err != nil {
+		t.Fatalf("ParseUnitRules() error = %v", err)
+	}
+	board := newLandBoard(3, 3)
+	board.Rules = defaultRules.withUnitRules(units)
+	hovercraft, _ := units.typeFromString("Hovercraft")
+	board.addUnit(board.newUnit(0, 0, hovercraft, 1))
+	board.Cities[0].SetManufacturingUnit(units, hovercraft)
+
+	var buf bytes.Buffer
+	if err := board.SaveGame(&buf); err != nil {
+		t.Fatalf("SaveGame() error = %v", err)
+	}
+	loaded, err := LoadGame(&buf)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if !reflect.DeepEqual(loaded.unitRules(), units) || loaded.StateHash() != board.StateHash() {
+		t.Errorf("LoadGame() lost the unit types of the save")
+	}
+
+	buf.Reset()
+	if err := (&PlayByEmailGame{NextPlayer: 1, Board: board}).Write(&buf); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	game, err := ReadPlayByEmailGame(&buf)
+	if err != nil {
+		t.Fatalf("ReadPlayByEmailGame() error = %v", err)
+	}
+	if got := game.Board.unitTypeToString(game.Board.Units[0].Type); got != "Hovercraft" || game.Board.unitDefinitionOf(Tank).Strength != 3 {
+		t.Errorf("ReadPlayByEmailGame() of a game with a Hovercraft has a %s; want the unit types of the game", got)
+	}
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Scenario is a hand-authored map and starting position.
+// The map has one string per row: 'S' sea, 'L' land, 'H' mountains, 'G' forest, 'W' shallows,
+// 'I' ice, 'C' a neutral city, '1' and '2' cities of player 1 and player 2. A scenario file is
+// either the JSON of a Scenario or just the rows of its map, one per line.
+type Scenario struct {
+	Name       string         `json:"name,omitempty"`
+	Day        int            `json:"day"`
+	Seed       int64          `json:"seed,omitempty"` // seed of the game, the loader's seed when 0
+	Map        []string       `json:"map"`
+	Production [2]string      `json:"production"`       // unit manufactured by each player's cities, unless the city says otherwise
+	Cities     []ScenarioCity `json:"cities,omitempty"` // cities of the map which differ from new cities
+	Units      []ScenarioUnit `json:"units,omitempty"`
+	Rules      *RuleSet       `json:"-"` // rule set the scenario is played under, the classic rules when nil
+}
+
+// ScenarioCity describes a city of a scenario's map.
+type ScenarioCity struct {
+	PositionX  int      `json:"x"`
+	PositionY  int      `json:"y"`
+	Terrain    string   `json:"terrain,omitempty"`  // "mountains" or "forest" the city stands on, open land when empty
+	Strength   *int     `json:"strength,omitempty"` // the city strength of the rules when absent
+	Production string   `json:"production,omitempty"`
+	DaysLeft   int      `json:"daysLeft,omitempty"` // days until the unit is ready, the unit's production time when 0
+	Queue      []string `json:"queue,omitempty"`    // units to produce after the production, in order
+	HeldDays   int      `json:"heldDays,omitempty"` // days the player has held the city, which set its level
+}
+
+// ScenarioUnit describes a unit placed on a scenario's map.
+type ScenarioUnit struct {
+	PositionX int    `json:"x"`
+	PositionY int    `json:"y"`
+	Player    int    `json:"player"`
+	Type      string `json:"type"`
+	Strength  *int   `json:"strength,omitempty"` // a new unit's strength when absent
+	Fuel      *int   `json:"fuel,omitempty"`     // a new unit's fuel when absent
+	Kills     int    `json:"kills,omitempty"`    // enemy units destroyed, which with battles set the unit's rank
+	Battles   int    `json:"battles,omitempty"`
+}
+
+// ReadScenario reads a scenario file.
+func ReadScenario(r io.Reader) (Scenario, error) {
+	var scenario Scenario
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return scenario, err
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		err := json.Unmarshal(data, &scenario)
+		return scenario, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			scenario.Map = append(scenario.Map, line)
+		}
+	}
+	return scenario, scanner.Err()
+}
+
+// LoadScenario reads a scenario file and builds its board.
+func LoadScenario(r io.Reader, seed int64) (*GameBoard, error) {
+	scenario, err := ReadScenario(r)
+	if err != nil {
+		return nil, err
+	}
+	return NewGameBoardFromScenario(scenario, seed)
+}
+
+// NewGameBoardFromScenario builds the board of a scenario. Its random numbers come from the
+// scenario's seed, or from seed if the scenario has none.
+func NewGameBoardFromScenario(scenario Scenario, seed int64) (*GameBoard, error) {
+	rows := len(scenario.Map)
+	if rows == 0 {
+		return nil, fmt.Errorf("scenario has no map")
+	}
+	columns := len(scenario.Map[0])
+	if scenario.Seed != 0 {
+		seed = scenario.Seed
+	}
+	g := NewGameBoardWithSeed(rows, columns, seed)
+	g.Rules = scenario.Rules
+	g.Day = scenario.Day
+
+	var production [2]UnitType
+	for i, name := range scenario.Production {
+		if name == "" {
+			continue
+		}
+		unitType, err := g.parseScenarioUnitType(name)
+		if err != nil {
+			return nil, fmt.Errorf("production of player %d: %w", i+1, err)
+		}
+		production[i] = unitType
+	}
+
+	for i, row := range scenario.Map {
+		if len(row) != columns {
+			return nil, fmt.Errorf("row %d of the map has %d cells; want %d", i, len(row), columns)
+		}
+		for j, c := range row {
+			cell := &g.Grid[i][j]
+			switch c {
+			case 'S', 'L', 'H', 'G', 'W', 'I':
+				cell.IsLand, cell.Terrain, _ = terrainFromSymbol(c)
+			case 'C', '1', '2':
+				cell.IsLand = true
+				cell.HasCity = true
+				city := g.newCity(i, j)
+				if c != 'C' {
+					player := int(c - '0')
+					city.OccupyCity(player)
+					if production[player-1] != Blank {
+						city.SetManufacturingUnit(g.unitRules(), production[player-1])
+					}
+				}
+				g.Cities = append(g.Cities, *city)
+			default:
+				return nil, fmt.Errorf("unknown cell %q at (%d, %d) of the map", c, i, j)
+			}
+		}
+	}
+	for i := range g.Cities {
+		g.Cities[i].IsCityNextToSea = g.IsCityNextToSea(g.Cities[i].PositionX, g.Cities[i].PositionY)
+	}
+
+	for _, c := range scenario.Cities {
+		city := g.getCityAtCoordinates(Coordinate{c.PositionX, c.PositionY})
+		if city == nil {
+			return nil, fmt.Errorf("there is no city at (%d, %d) of the map", c.PositionX, c.PositionY)
+		}
+		if c.Terrain != "" {
+			terrain, ok := terrainFromString(c.Terrain)
+			if !ok {
+				return nil, fmt.Errorf("city at (%d, %d) stands on unknown terrain %q", c.PositionX, c.PositionY, c.Terrain)
+			}
+			g.Grid[c.PositionX][c.PositionY].Terrain = terrain
+		}
+		if c.Strength != nil {
+			city.Strength = *c.Strength
+		}
+		city.HeldDays = c.HeldDays
+		if c.Production != "" {
+			unitType, err := g.parseScenarioUnitType(c.Production)
+			if err != nil {
+				return nil, fmt.Errorf("city at (%d, %d): %w", c.PositionX, c.PositionY, err)
+			}
+			city.SetManufacturingUnit(g.unitRules(), unitType)
+		}
+		if city.ManufacturingUnit != Blank {
+			city.DaysUntilUnitReady = city.GetDaysToProduce(g.unitRules(), city.ManufacturingUnit)
+		}
+		if c.DaysLeft != 0 {
+			city.DaysUntilUnitReady = c.DaysLeft
+		}
+		for _, name := range c.Queue {
+			unitType, err := g.parseScenarioUnitType(name)
+			if err != nil {
+				return nil, fmt.Errorf("queue of city at (%d, %d): %w", c.PositionX, c.PositionY, err)
+			}
+			city.QueueUnit(unitType)
+		}
+	}
+
+	for _, u := range scenario.Units {
+		if !g.isOnBoard(Coordinate{u.PositionX, u.PositionY}) {
+			return nil, fmt.Errorf("unit at (%d, %d) is off the map", u.PositionX, u.PositionY)
+		}
+		if u.Player != 1 && u.Player != 2 {
+			return nil, fmt.Errorf("unit at (%d, %d) belongs to player %d; want 1 or 2", u.PositionX, u.PositionY, u.Player)
+		}
+		unitType, err := g.parseScenarioUnitType(u.Type)
+		if err != nil {
+			return nil, fmt.Errorf("unit at (%d, %d): %w", u.PositionX, u.PositionY, err)
+		}
+		unit := g.newUnit(u.PositionX, u.PositionY, unitType, u.Player)
+		if u.Strength != nil {
+			unit.Strength = *u.Strength
+		}
+		if u.Fuel != nil {
+			unit.Fuel = *u.Fuel
+		}
+		unit.Kills, unit.Battles = u.Kills, u.Battles
+		g.addUnit(unit)
+	}
+	return g, nil
+}
+
+// isScenarioStarted checks if a player already holds a city before the game starts.
+func (g *GameBoard) isScenarioStarted() bool {
+	for _, city := range g.Cities {
+		if city.OccupyingPlayer != Unoccupied {
+			return true
+		}
+	}
+	return false
+}
+
+// parseScenarioUnitType returns the unit type of the board's rules with the given name.
+func (g *GameBoard) parseScenarioUnitType(name string) (UnitType, error) {
+	unitType, ok := g.unitRules().typeFromString(name)
+	if !ok || unitType == Blank {
+		return Blank, fmt.Errorf("unknown unit type %q", name)
+	}
+	return unitType, nil
+}
+
+// isOnBoard checks if the coordinate is a cell of the board.
+func (g *GameBoard) isOnBoard(coordinate Coordinate) bool {
+	return coordinate.PositionX >= 0 && coordinate.PositionX < g.Rows && coordinate.PositionY >= 0 && coordinate.PositionY < g.Columns
+}
+
+// newScenario returns the scenario of the board as it is now. Fog of war is not kept, and
+// cities are loaded back in the order they appear on the map.
+func (g *GameBoard) newScenario() Scenario {
+	scenario := Scenario{Day: g.Day, Seed: g.Seed}
+	for i := 0; i < g.Rows; i++ {
+		row := make([]byte, g.Columns)
+		for j := 0; j < g.Columns; j++ {
+			row[j] = terrainToSymbol(g.Grid[i][j])
+		}
+		scenario.Map = append(scenario.Map, string(row))
+	}
+	// cities are listed in the order the loader finds them on the map
+	cities := append([]City(nil), g.Cities...)
+	sort.SliceStable(cities, func(a, b int) bool {
+		if cities[a].PositionX != cities[b].PositionX {
+			return cities[a].PositionX < cities[b].PositionX
+		}
+		return cities[a].PositionY < cities[b].PositionY
+	})
+	for _, city := range cities {
+		symbol := byte('C')
+		if city.OccupyingPlayer != Unoccupied {
+			symbol = byte('0' + city.OccupyingPlayer)
+		}
+		scenario.Map[city.PositionX] = scenario.Map[city.PositionX][:city.PositionY] + string(symbol) + scenario.Map[city.PositionX][city.PositionY+1:]
+
+		c := ScenarioCity{PositionX: city.PositionX, PositionY: city.PositionY}
+		if terrain := g.Grid[city.PositionX][city.PositionY].Terrain; terrain != TerrainPlain {
+			c.Terrain = terrainToString(true, terrain)
+		}
+		if city.Strength != g.rules().CityStrength {
+			strength := city.Strength
+			c.Strength = &strength
+		}
+		if city.ManufacturingUnit != Blank {
+			c.Production = g.unitTypeToString(city.ManufacturingUnit)
+			c.DaysLeft = city.DaysUntilUnitReady
+		}
+		for _, unit := range city.Queue {
+			c.Queue = append(c.Queue, g.unitTypeToString(unit))
+		}
+		if city.OccupyingPlayer != Unoccupied {
+			c.HeldDays = city.HeldDays
+		}
+		if c.Terrain != "" || c.Strength != nil || c.Production != "" || len(c.Queue) > 0 || c.HeldDays != 0 {
+			scenario.Cities = append(scenario.Cities, c)
+		}
+	}
+	for _, unit := range g.Units {
+		u := ScenarioUnit{PositionX: unit.PositionX, PositionY: unit.PositionY, Player: unit.Player, Type: g.unitTypeToString(unit.Type)}
+		if strength := unit.Strength; strength != g.unitDefinitionOf(unit.Type).Strength {
+			u.Strength = &strength
+		}
+		if fuel := unit.Fuel; fuel != g.unitDefinitionOf(unit.Type).Fuel {
+			u.Fuel = &fuel
+		}
+		u.Kills, u.Battles = unit.Kills, unit.Battles
+		scenario.Units = append(scenario.Units, u)
+	}
+	return scenario
+}
+
+// WriteScenario writes the board as it is now as a scenario file.
+func (g *GameBoard) WriteScenario(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(g.newScenario())
+}
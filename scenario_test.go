@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testScenario = `{
+  "name": "two islands",
+  "day": 4,
+  "seed": 9,
+  "map": [
+    "LL1SSSS",
+    "LLLSSCL",
+    "LLLSSL2"
+  ],
+  "production": ["Fighter", ""],
+  "cities": [
+    {"x": 2, "y": 6, "strength": 5, "production": "Transport", "daysLeft": 2}
+  ],
+  "units": [
+    {"x": 1, "y": 1, "player": 1, "type": "Tank", "strength": 1},
+    {"x": 0, "y": 4, "player": 2, "type": "Bomber", "fuel": 3}
+  ]
+}`
+
+func TestLoadScenario(t *testing.T) {
+	board, err := LoadScenario(strings.NewReader(testScenario), 1)
+	if err != nil {
+		t.Fatalf("LoadScenario() error = %v", err)
+	}
+	if board.Rows != 3 || board.Columns != 7 || board.Day != 4 || board.Seed != 9 {
+		t.Errorf("LoadScenario() board is %dx%d on day %d with seed %d; want 3x7 on day 4 with seed 9",
+			board.Rows, board.Columns, board.Day, board.Seed)
+	}
+	if !board.Grid[1][6].IsLand || board.Grid[0][3].IsLand {
+		t.Errorf("LoadScenario() land and sea do not match the map")
+	}
+
+	want := []City{
+		{PositionX: 0, PositionY: 2, Strength: NewCityStrength, OccupyingPlayer: OccupiedByPlayer1, ManufacturingUnit: Fighter,
+			DaysUntilUnitReady: GetDaysToProduceUnit(Fighter), IsCityNextToSea: true},
+		{PositionX: 1, PositionY: 5, Strength: NewCityStrength, IsCityNextToSea: true},
+		{PositionX: 2, PositionY: 6, Strength: 5, OccupyingPlayer: OccupiedByPlayer2, ManufacturingUnit: Transport,
+			DaysUntilUnitReady: 2, IsCityNextToSea: false},
+	}
+	if !reflect.DeepEqual(board.Cities, want) {
+		t.Errorf("LoadScenario() cities = %+v; want %+v", board.Cities, want)
+	}
+
+	if len(board.Units) != 2 {
+		t.Fatalf("LoadScenario() has %d units; want 2", len(board.Units))
+	}
+	if tank := board.Units[0]; tank.Type != Tank || tank.Player != 1 || tank.Strength != 1 || tank.Fuel != GetFuelPerDay(Tank) {
+		t.Errorf("LoadScenario() tank = %+v; want player 1's tank with strength 1", tank)
+	}
+	if bomber := board.Units[1]; bomber.Type != Bomber || bomber.Strength != GetNewUnitStrength(Bomber) || bomber.Fuel != 3 {
+		t.Errorf("LoadScenario() bomber = %+v; want player 2's bomber with 3 fuel", bomber)
+	}
+}
+
+func TestLoadScenarioText(t *testing.T) {
+	board, err := LoadScenario(strings.NewReader("SLLC\nS1LL\n\nSSL2\n"), 3)
+	if err != nil {
+		t.Fatalf("LoadScenario() error = %v", err)
+	}
+	if board.Rows != 3 || board.Columns != 4 || len(board.Cities) != 3 || board.Seed != 3 {
+		t.Errorf("LoadScenario() board is %dx%d with %d cities and seed %d; want 3x4 with 3 cities and seed 3",
+			board.Rows, board.Columns, len(board.Cities), board.Seed)
+	}
+	if owner := board.getCityAtCoordinates(Coordinate{1, 1}).OccupyingPlayer; owner != OccupiedByPlayer1 {
+		t.Errorf("LoadScenario() city at (1, 1) is held by %d; want player 1", owner)
+	}
+}
+
+func TestLoadScenarioInvalid(t *testing.T) {
+	type test struct {
+		name     string
+		scenario string
+	}
+	tests := []test{
+		{name: "no map", scenario: `{"day": 1}`},
+		{name: "ragged map", scenario: "LLL\nLL\n"},
+		{name: "unknown cell", scenario: "LXL\n"},
+		{name: "unknown production", scenario: `{"map": ["L1"], "production": ["Spaceship", ""]}`},
+		{name: "city not on the map", scenario: `{"map": ["L1"], "cities": [{"x": 0, "y": 0, "strength": 3}]}`},
+		{name: "unit off the map", scenario: `{"map": ["L1"], "units": [{"x": 3, "y": 0, "player": 1, "type": "Tank"}]}`},
+		{name: "unit of no player", scenario: `{"map": ["L1"], "units": [{"x": 0, "y": 0, "player": 3, "type": "Tank"}]}`},
+		{name: "unknown unit", scenario: `{"map": ["L1"], "units": [{"x": 0, "y": 0, "player": 1, "type": "Blank"}]}`},
+		{name: "invalid JSON", scenario: `{"map": [`},
+	}
+	for _, tc := range tests {
+		if _, err := LoadScenario(strings.NewReader(tc.scenario), 1); err == nil {
+			t.Errorf("LoadScenario(), name:%s, got no error; want an error", tc.name)
+		}
+	}
+}
+
+func TestWriteScenario(t *testing.T) {
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	board.DayZero()
+	board.PlayGame(6)
+
+	var buf bytes.Buffer
+	if err := board.WriteScenario(&buf); err != nil {
+		t.Fatalf("WriteScenario() error = %v", err)
+	}
+	loaded, err := LoadScenario(&buf, 1)
+	if err != nil {
+		t.Fatalf("LoadScenario() of a written scenario error = %v", err)
+	}
+	if got, want := loaded.newScenario(), board.newScenario(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadScenario() of a written scenario = %+v; want %+v", got, want)
+	}
+	for _, city := range board.Cities {
+		if got := loaded.getCityAtCoordinates(Coordinate{city.PositionX, city.PositionY}); got == nil || !reflect.DeepEqual(*got, city) {
+			t.Errorf("LoadScenario() of a written scenario, city = %+v; want %+v", got, city)
+		}
+	}
+}
+
+func TestWriteScenarioZeros(t *testing.T) {
+	board := newLandBoard(3, 3)
+	board.Cities[0].Strength = 0
+	board.addUnit(NewUnit(1, 1, Fighter, 1))
+	board.Units[0].Fuel = 0
+
+	var buf bytes.Buffer
+	if err := board.WriteScenario(&buf); err != nil {
+		t.Fatalf("WriteScenario() error = %v", err)
+	}
+	loaded, err := LoadScenario(&buf, 1)
+	if err != nil {
+		t.Fatalf("LoadScenario() of a written scenario error = %v", err)
+	}
+	if city := loaded.getCityAtCoordinates(Coordinate{0, 2}); city == nil || city.Strength != 0 {
+		t.Errorf("LoadScenario() of a written scenario, city = %+v; want strength 0", city)
+	}
+	if len(loaded.Units) != 1 || loaded.Units[0].Fuel != 0 {
+		t.Errorf("LoadScenario() of a written scenario, units = %+v; want a fighter with no fuel", loaded.Units)
+	}
+}
+
+func TestDayZeroScenario(t *testing.T) {
+	board, err := LoadScenario(strings.NewReader(testScenario), 1)
+	if err != nil {
+		t.Fatalf("LoadScenario() error = %v", err)
+	}
+	board.Cities[0].ManufacturingUnit = Blank
+	board.Player1 = &Player{Name: "player 1", Controller: &ScriptedController{Production: []UnitType{Destroyer}}}
+	board.DayZero()
+
+	if got := board.Cities[0].ManufacturingUnit; got != Destroyer {
+		t.Errorf("DayZero() on a scenario, player 1's city manufactures %s; want Destroyer", unitTypeToString(got))
+	}
+	if got := board.Cities[1].OccupyingPlayer; got != Unoccupied {
+		t.Errorf("DayZero() on a scenario gave the neutral city to player %d; want it left neutral", got)
+	}
+	if got := board.Cities[2].ManufacturingUnit; got != Transport {
+		t.Errorf("DayZero() on a scenario changed player 2's production to %s", unitTypeToString(got))
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net"
+	"time"
+)
+
+// joinRequest is the first line of JSON a client sends to a GameServer.
+type joinRequest struct {
+	Type string `json:"type"` // "join"
+	Name string `json:"name"`
+}
+
+// joinResponse tells a client which player it is.
+type joinResponse struct {
+	Type   string `json:"type"` // "joined"
+	Player int    `json:"player"`
+}
+
+// GameServer hosts a game for two clients connecting over TCP.
+// Clients speak the external bot protocol: each turn a client is sent its player's view
+// of the game and answers with its orders, which ends its turn. The day advances once both
+// players have ended their turn.
+type GameServer struct {
+	Board         *GameBoard
+	TurnTimeLimit time.Duration // time a client may take over a turn before it forfeits
+	MaxDays       int           // the game is a draw after this many days, unlimited when 0
+	JoinTimeLimit time.Duration // time a client may take to join, DefaultJoinTimeLimit when 0
+}
+
+// DefaultJoinTimeLimit is the time a client may take to join before the server waits for another.
+const DefaultJoinTimeLimit = 10 * time.Second
+
+// runServeCommand runs the "serve" command with its command line arguments.
+func runServeCommand(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", ":7777", "address to listen on")
+	websocket := flags.Bool("websocket", false, "accept players over WebSocket at ws://addr/ws instead of TCP")
+	mapFlags := addMapFlags(flags)
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the game")
+	turnTimeLimit := flags.Duration("turnTimeLimit", 10*time.Minute, "time a player may take over a turn")
+	maxDays := flags.Int("maxDays", 0, "days after which the game is a draw, unlimited when 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	board, err := mapFlags.newGameBoard(*seed)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	if *websocket {
+		listener = newWebSocketListener(listener, "/ws")
+	}
+	defer listener.Close()
+	fmt.Printf("waiting for players on %s\n", listener.Addr())
+	server := &GameServer{
+		Board:         board,
+		TurnTimeLimit: *turnTimeLimit,
+		MaxDays:       *maxDays,
+	}
+	winner, err := server.Serve(listener)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("GAME OVER, winner: %d\n", winner)
+	return nil
+}
+
+// Serve waits for two clients to join, then plays the game and returns the winner, 0 for a draw.
+func (s *GameServer) Serve(listener net.Listener) (int, error) {
+	var controllers []*BotController
+	defer func() {
+		for _, controller := range controllers {
+			controller.Close()
+		}
+	}()
+	joinTimeLimit := s.JoinTimeLimit
+	if joinTimeLimit <= 0 {
+		joinTimeLimit = DefaultJoinTimeLimit
+	}
+	for player := 1; player <= 2; player++ {
+		conn, err := listener.Accept()
+		if err != nil {
+			return 0, err
+		}
+		reader := bufio.NewReader(conn)
+		conn.SetReadDeadline(time.Now().Add(joinTimeLimit))
+		line, err := reader.ReadBytes('\n')
+		conn.SetReadDeadline(time.Time{})
+		var join joinRequest
+		if err == nil {
+			err = json.Unmarshal(line, &join)
+		}
+		if err != nil || join.Type != "join" {
+			conn.Close()
+			player-- // wait for another client to take this player's place
+			continue
+		}
+		if err := json.NewEncoder(conn).Encode(joinResponse{Type: "joined", Player: player}); err != nil {
+			conn.Close()
+			player--
+			continue
+		}
+		controller := newStreamBotController(reader, conn, s.TurnTimeLimit)
+		controllers = append(controllers, controller)
+		p := &Player{Name: join.Name, Controller: controller}
+		if player == 1 {
+			s.Board.Player1 = p
+		} else {
+			s.Board.Player2 = p
+		}
+		s.Board.printf("%s joined as player %d\n", join.Name, player)
+	}
+
+	s.Board.DayZero()
+	winner := s.Board.PlayGame(s.MaxDays)
+	for _, controller := range controllers {
+		controller.Winner = winner
+	}
+	return winner, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGameServer(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	server := &GameServer{Board: board, TurnTimeLimit: 5 * time.Second, MaxDays: 15}
+
+	type clientResult struct {
+		winner int
+		err    error
+	}
+	results := make(chan clientResult, 2)
+	for _, name := range []string{"alice", "bob"} {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		defer conn.Close()
+		go func(conn net.Conn, name string) {
+			winner, err := runClient(conn, name, true, strings.NewReader(""), io.Discard)
+			results <- clientResult{winner, err}
+		}(conn, name)
+	}
+
+	winner, err := server.Serve(listener)
+	if err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		result := <-results
+		if result.err != nil {
+			t.Errorf("runClient() error = %v", result.err)
+		}
+		if result.winner != winner {
+			t.Errorf("runClient() winner = %d; want %d", result.winner, winner)
+		}
+	}
+	if board.Day < 2 {
+		t.Errorf("Serve() played %d days; want the game to run", board.Day)
+	}
+	if board.Player1 == nil || board.Player2 == nil || board.Player1.Name == board.Player2.Name {
+		t.Errorf("Serve() players = %+v, %+v; want alice and bob", board.Player1, board.Player2)
+	}
+}
+
+func TestGameServerWebSocket(t *testing.T) {
+	tcpListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	listener := newWebSocketListener(tcpListener, "/ws")
+	defer listener.Close()
+
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	server := &GameServer{Board: board, TurnTimeLimit: 5 * time.Second, MaxDays: 5}
+
+	results := make(chan error, 2)
+	for _, name := range []string{"alice", "bob"} {
+		conn, err := dialWebSocket("ws://" + listener.Addr().String() + "/ws")
+		if err != nil {
+			t.Fatalf("dialWebSocket() error = %v", err)
+		}
+		defer conn.Close()
+		go func(conn net.Conn, name string) {
+			_, err := runClient(conn, name, true, strings.NewReader(""), io.Discard)
+			results <- err
+		}(conn, name)
+	}
+
+	if _, err := server.Serve(listener); err != nil {
+		t.Fatalf("Serve() error = %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("runClient() error = %v", err)
+		}
+	}
+	if board.Day < 2 {
+		t.Errorf("Serve() played %d days; want the game to run", board.Day)
+	}
+	if board.Player1 == nil || board.Player2 == nil || board.Player1.Name == board.Player2.Name {
+		t.Errorf("Serve() players = %+v, %+v; want alice and bob", board.Player1, board.Player2)
+	}
+}
+
+func TestGameServerSilentClient(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer listener.Close()
+
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	server := &GameServer{Board: board, TurnTimeLimit: time.Minute, MaxDays: 2, JoinTimeLimit: 100 * time.Millisecond}
+
+	// a client which never joins must not hold up the clients behind it for a turn
+	silent, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer silent.Close()
+	for _, name := range []string{"alice", "bob"} {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		defer conn.Close()
+		go runClient(conn, name, true, strings.NewReader(""), io.Discard)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := server.Serve(listener)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Serve() error = %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("Serve() still waiting on the silent client")
+	}
+	if board.Player1 == nil || board.Player2 == nil || board.Player1.Name == board.Player2.Name {
+		t.Errorf("Serve() players = %+v, %+v; want alice and bob", board.Player1, board.Player2)
+	}
+}
+
+func TestIsSafeOrder(t *testing.T) {
+	board := NewGameBoard(3, 3)
+	board.IterateGrid(func(row, col int, cell *Cell) {
+		cell.IsFog = false
+	})
+	board.Grid[0][0].IsLand = true
+	board.Grid[1][1].IsFog = true
+	ship := NewUnit(0, 1, Destroyer, 1)
+	board.Units = append(board.Units, *NewUnit(0, 0, Tank, 2))
+
+	type test struct {
+		name string
+		move Coordinate
+		want bool
+	}
+	tests := []test{
+		{name: "sea", move: Coordinate{0, 2}, want: true},
+		{name: "fog", move: Coordinate{1, 1}, want: false},
+		{name: "attack on land", move: Coordinate{0, 0}, want: false},
+		{name: "off the board", move: Coordinate{-1, 1}, want: false},
+	}
+	for _, tc := range tests {
+		if got := isSafeOrder(board, ship, tc.move); got != tc.want {
+			t.Errorf("isSafeOrder(), name:%s, got %t; want %t", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+// specialistSightRange is how far from a city the AI looks for enemies calling for a specialist unit.
+const specialistSightRange = 3
+
+// canFireFromRange checks if the unit fires from beyond the next cell, which artillery always
+// does and units with an attack range above 1 do when the board's rules allow ranged fire.
+func (g *GameBoard) canFireFromRange(unit *Unit) bool {
+	if unit.AttackRange <= 1 {
+		return false
+	}
+	return g.rules().RangedFire || g.unitDefinitionOf(unit.Type).RangedFire
+}
+
+// getAntiAircraftBonuses returns the percentage bonuses of the attacker and the defender at the
+// coordinate when anti-aircraft units fight aircraft.
+func (g *GameBoard) getAntiAircraftBonuses(attacker *Unit, coordinate Coordinate) (attack, defence int) {
+	defender := g.getUnitAtCoordinates(coordinate, attacker.Player)
+	if defender == nil {
+		return 0, 0
+	}
+	if attacker.CanFly {
+		defence = g.unitDefinitionOf(defender.Type).AntiAircraftBonus
+	}
+	if defender.CanFly {
+		attack = g.unitDefinitionOf(attacker.Type).AntiAircraftBonus
+	}
+	return attack, defence
+}
+
+// getAttackDamage returns the strength a successful attack by the unit takes from its target:
+// the whole strength of a single-use unit such as a cruise missile, and 1 for anything else.
+func (g *GameBoard) getAttackDamage(attacker *Unit) int {
+	if g.unitDefinitionOf(attacker.Type).SingleUse {
+		return attacker.Strength
+	}
+	return 1
+}
+
+// spendSingleUseUnit removes the unit after its attack if it is single use, unless the attack
+// already destroyed it.
+func (g *GameBoard) spendSingleUseUnit(unit *Unit) {
+	if !g.unitDefinitionOf(unit.Type).SingleUse {
+		return
+	}
+	// look the unit up again, as it is no longer on the board if the attack destroyed it
+	if spent := g.getUnitByID(unit.ID); spent != nil {
+		g.printf("%s %d is spent\n", g.unitTypeToString(spent.Type), spent.ID)
+		g.removeUnit(spent)
+	}
+}
+
+// getParadropBomber returns a bomber of the unit's player with moves left, in the player's city
+// the unit is in, which can drop the unit, or nil.
+func (g *GameBoard) getParadropBomber(unit *Unit) *Unit {
+	if g.unitDefinitionOf(unit.Type).DropRange <= 0 {
+		return nil
+	}
+	city := g.getCityAtCoordinates(Coordinate{unit.PositionX, unit.PositionY})
+	if city == nil || int(city.OccupyingPlayer) != unit.Player {
+		return nil
+	}
+	for i := range g.Units {
+		bomber := &g.Units[i]
+		if bomber.Type == Bomber && bomber.Player == unit.Player && bomber.MovesLeftThisDay > 0 &&
+			bomber.PositionX == unit.PositionX && bomber.PositionY == unit.PositionY {
+			return bomber
+		}
+	}
+	return nil
+}
+
+// isParadrop checks if a bomber can drop the unit at the coordinate: a drop cell of the unit, with
+// a bomber in the unit's city to carry it.
+func (g *GameBoard) isParadrop(coordinate Coordinate, unit *Unit) bool {
+	return g.isDropCell(coordinate, unit) && g.getParadropBomber(unit) != nil
+}
+
+// isDropCell checks if the unit could be dropped at the coordinate: an empty land cell without a
+// city which the unit can enter, beyond the next cell and within the unit's drop range.
+func (g *GameBoard) isDropCell(coordinate Coordinate, unit *Unit) bool {
+	if coordinate.PositionX < 0 || coordinate.PositionX >= g.Rows || coordinate.PositionY < 0 || coordinate.PositionY >= g.Columns {
+		return false
+	}
+	distance := maxInt(abs(coordinate.PositionX-unit.PositionX), abs(coordinate.PositionY-unit.PositionY))
+	if distance <= 1 || distance > g.unitDefinitionOf(unit.Type).DropRange {
+		return false
+	}
+	cell := g.Grid[coordinate.PositionX][coordinate.PositionY]
+	if !cell.IsLand || cell.HasCity || !g.canEnterTerrain(coordinate, unit) {
+		return false
+	}
+	for _, other := range g.Units {
+		if other.PositionX == coordinate.PositionX && other.PositionY == coordinate.PositionY {
+			return false
+		}
+	}
+	return true
+}
+
+// paradrop drops the unit at the coordinate, which takes the rest of the day of both the unit
+// and the bomber carrying it.
+func (g *GameBoard) paradrop(coordinate Coordinate, unit *Unit) {
+	bomber := g.getParadropBomber(unit)
+	g.printf("%s %d is dropped at %d, %d\n", g.unitTypeToString(unit.Type), unit.ID, coordinate.PositionX, coordinate.PositionY)
+	bomber.MovesLeftThisDay = 0
+	unit.PositionX, unit.PositionY = coordinate.PositionX, coordinate.PositionY
+	unit.MovesLeftThisDay = 0
+}
+
+// getParadropTargets returns the cells the unit can be dropped at next to a city its player does
+// not hold.
+func (g *GameBoard) getParadropTargets(unit *Unit) []Coordinate {
+	if g.getParadropBomber(unit) == nil {
+		return nil
+	}
+	return g.getDropTargets(unit)
+}
+
+// getDropTargets returns the drop cells of the unit next to a city its player does not hold,
+// whether or not a bomber is there to carry it.
+func (g *GameBoard) getDropTargets(unit *Unit) []Coordinate {
+	dropRange := g.unitDefinitionOf(unit.Type).DropRange
+	if dropRange <= 0 {
+		return nil
+	}
+	var targets []Coordinate
+	for i := unit.PositionX - dropRange; i <= unit.PositionX+dropRange; i++ {
+		for j := unit.PositionY - dropRange; j <= unit.PositionY+dropRange; j++ {
+			coordinate := Coordinate{i, j}
+			if g.isDropCell(coordinate, unit) && g.isNextToCityNotHeldBy(coordinate, unit.Player) {
+				targets = append(targets, coordinate)
+			}
+		}
+	}
+	return targets
+}
+
+// isNextToCityNotHeldBy checks if a city next to the coordinate is unoccupied or the opponent's.
+func (g *GameBoard) isNextToCityNotHeldBy(coordinate Coordinate, player int) bool {
+	for _, city := range g.Cities {
+		if int(city.OccupyingPlayer) != player &&
+			abs(city.PositionX-coordinate.PositionX) <= 1 && abs(city.PositionY-coordinate.PositionY) <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// getSpecialistUnitAI returns the specialist unit an AI city calls for to meet what is around
+// it: anti-aircraft against enemy aircraft, cruise missiles against enemy ships off the coast,
+// artillery against enemy land units, and paratroopers when the player has a bomber and a city
+// within drop range of this one to drop them next to. The city's AI variant weighs it against its other units. It returns false
+// when nothing calls for one, or the city already has one of the kind.
+func (g *GameBoard) getSpecialistUnitAI(coordinate Coordinate, player int, isCityNextToSea bool) (UnitType, bool) {
+	var aircraft, ships, landUnits bool
+	for i := coordinate.PositionX - specialistSightRange; i <= coordinate.PositionX+specialistSightRange; i++ {
+		for j := coordinate.PositionY - specialistSightRange; j <= coordinate.PositionY+specialistSightRange; j++ {
+			if i < 0 || i >= g.Rows || j < 0 || j >= g.Columns {
+				continue
+			}
+			for _, enemy := range g.visibleUnitsAt(player, Coordinate{i, j}) {
+				switch {
+				case enemy.Player == player:
+				case enemy.CanFly:
+					aircraft = true
+				case enemy.CanMoveOnWater:
+					ships = true
+				default:
+					landUnits = true
+				}
+			}
+		}
+	}
+	paratrooper := g.newUnit(coordinate.PositionX, coordinate.PositionY, Paratrooper, player)
+	switch {
+	case aircraft && g.getUnitCount(AntiAircraft, []Coordinate{coordinate}, player) == 0:
+		return AntiAircraft, true
+	case ships && isCityNextToSea && g.getUnitCount(CruiseMissile, []Coordinate{coordinate}, player) == 0:
+		return CruiseMissile, true
+	case landUnits && g.getUnitCount(Artillery, []Coordinate{coordinate}, player) == 0:
+		return Artillery, true
+	case g.getUnitCount(Paratrooper, []Coordinate{coordinate}, player) == 0 && g.hasUnitOfType(player, Bomber) && len(g.getDropTargets(paratrooper)) > 0:
+		return Paratrooper, true
+	}
+	return Blank, false
+}
+
+// hasUnitOfType checks if the player has a unit of the type anywhere on the board.
+func (g *GameBoard) hasUnitOfType(player int, unitType UnitType) bool {
+	for _, unit := range g.Units {
+		if unit.Player == player && unit.Type == unitType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// addCity adds a city held by the player, 0 for none, to the board.
+func addCity(board *GameBoard, coordinate Coordinate, player int) *City {
+	city := NewCity(coordinate.PositionX, coordinate.PositionY)
+	if player != 0 {
+		city.OccupyCity(player)
+	}
+	board.Grid[coordinate.PositionX][coordinate.PositionY].HasCity = true
+	board.Cities = append(board.Cities, *city)
+	return &board.Cities[len(board.Cities)-1]
+}
+
+func TestArtilleryFiresFromRange(t *testing.T) {
+	board := newTerrainBoard("LLLL")
+	board.addUnit(NewUnit(0, 0, Artillery, 1))
+	board.addUnit(NewUnit(0, 2, Tank, 2))
+	board.addUnit(NewUnit(0, 3, Tank, 2))
+	artillery := &board.Units[0]
+
+	// artillery fires from range under the classic rules, which other units do not
+	if !board.isLegalMove(Coordinate{0, 2}, artillery) {
+		t.Errorf("isLegalMove() of artillery firing from range under the classic rules = false; want true")
+	}
+	if board.isLegalMove(Coordinate{0, 3}, artillery) {
+		t.Errorf("isLegalMove() of artillery firing beyond its range = true; want false")
+	}
+
+	board = newTerrainBoard("LLG")
+	board.addUnit(NewUnit(0, 0, Artillery, 1))
+	board.addUnit(NewUnit(0, 2, Tank, 2))
+	if board.isLegalMove(Coordinate{0, 2}, &board.Units[0]) {
+		t.Errorf("isLegalMove() of artillery firing into a forest it cannot see into = true; want false")
+	}
+}
+
+func TestParadrop(t *testing.T) {
+	board := newTerrainBoard(
+		"LLLLLLLLLL",
+		"LLLLLLLLLS",
+	)
+	addCity(board, Coordinate{0, 0}, 1)
+	addCity(board, Coordinate{0, 9}, 2)
+	board.addUnit(NewUnit(0, 0, Paratrooper, 1))
+	paratrooper := &board.Units[0]
+
+	if board.isParadrop(Coordinate{0, 5}, paratrooper) {
+		t.Errorf("isParadrop() without a bomber = true; want false")
+	}
+	board.addUnit(NewUnit(0, 0, Bomber, 1))
+	paratrooper = &board.Units[0]
+	type test struct {
+		name string
+		move Coordinate
+		want bool
+	}
+	tests := []test{
+		{name: "within range", move: Coordinate{0, 5}, want: true},
+		{name: "the next cell", move: Coordinate{1, 1}, want: false},
+		{name: "at the limit of the range", move: Coordinate{1, 8}, want: true},
+		{name: "beyond the range", move: Coordinate{0, 9}, want: false},
+		{name: "at sea", move: Coordinate{1, 9}, want: false},
+	}
+	for _, tc := range tests {
+		if got := board.isParadrop(tc.move, paratrooper); got != tc.want {
+			t.Errorf("isParadrop(), name:%s, got %t; want %t", tc.name, got, tc.want)
+		}
+	}
+	if targets := board.getParadropTargets(paratrooper); !reflect.DeepEqual(targets, []Coordinate{{0, 8}, {1, 8}}) {
+		t.Errorf("getParadropTargets() = %v; want the cells next to the enemy city", targets)
+	}
+
+	board.attemptMoveTo(Coordinate{1, 8}, paratrooper)
+	if paratrooper.PositionX != 1 || paratrooper.PositionY != 8 || paratrooper.MovesLeftThisDay != 0 {
+		t.Errorf("attemptMoveTo() of a paradrop left the paratrooper at (%d, %d) with %d moves; want (1, 8) with none",
+			paratrooper.PositionX, paratrooper.PositionY, paratrooper.MovesLeftThisDay)
+	}
+	if board.Units[1].MovesLeftThisDay != 0 {
+		t.Errorf("attemptMoveTo() of a paradrop left the bomber %d moves; want none", board.Units[1].MovesLeftThisDay)
+	}
+	if !paratrooper.CanCaptureCity {
+		t.Errorf("a paratrooper cannot capture cities")
+	}
+}
+
+func TestAntiAircraftBonus(t *testing.T) {
+	board := newTerrainBoard("LL")
+	board.addUnit(NewUnit(0, 1, AntiAircraft, 2))
+	fighter := NewUnit(0, 0, Fighter, 1)
+	tank := NewUnit(0, 0, Tank, 1)
+	type test struct {
+		name     string
+		attacker *Unit
+		min, max int
+	}
+	tests := []test{
+		{name: "fighter attacking anti-aircraft", attacker: fighter, min: 1150, max: 1500}, // a third
+		{name: "tank attacking anti-aircraft", attacker: tank, min: 1850, max: 2150},       // even odds
+	}
+	for _, tc := range tests {
+		wins := 0
+		for i := 0; i < 4000; i++ {
+			if board.getCombatOutcome(tc.attacker, Coordinate{0, 1}) {
+				wins++
+			}
+		}
+		if wins < tc.min || wins > tc.max {
+			t.Errorf("getCombatOutcome(), name:%s, got %d wins in 4000; want %d to %d", tc.name, wins, tc.min, tc.max)
+		}
+	}
+
+	board = newTerrainBoard("LL")
+	board.addUnit(NewUnit(0, 1, Fighter, 2))
+	wins := 0
+	for i := 0; i < 4000; i++ {
+		if board.getCombatOutcome(NewUnit(0, 0, AntiAircraft, 1), Coordinate{0, 1}) {
+			wins++
+		}
+	}
+	if wins < 2850 || wins > 3150 { // three quarters
+		t.Errorf("getCombatOutcome() of anti-aircraft attacking a fighter, got %d wins in 4000; want about 3000", wins)
+	}
+}
+
+func TestCruiseMissile(t *testing.T) {
+	board := newTerrainBoard("SS")
+	board.addUnit(NewUnit(0, 0, CruiseMissile, 1))
+	board.addUnit(NewUnit(0, 1, Destroyer, 2))
+	missile, destroyer := &board.Units[0], &board.Units[1]
+	board.resolveUnitAttack(missile, destroyer, true)
+	board.spendSingleUseUnit(missile)
+	if len(board.Units) != 0 {
+		t.Errorf("a cruise missile hitting a destroyer left %v; want the destroyer sunk and the missile spent", board.Units)
+	}
+
+	for i := 0; i < 10; i++ {
+		board.Units = nil
+		board.addUnit(NewUnit(0, 0, CruiseMissile, 1))
+		board.addUnit(NewUnit(0, 1, Destroyer, 2))
+		var buf bytes.Buffer
+		board.Output = &buf
+		board.attemptMoveTo(Coordinate{0, 1}, &board.Units[0])
+		if strings.Contains(buf.String(), "Attacker is destroyed") && strings.Contains(buf.String(), "is spent") {
+			t.Errorf("attemptMoveTo() of a cruise missile destroyed in its attack = %q; want it not spent as well", buf.String())
+		}
+		for _, unit := range board.Units {
+			if unit.Type == CruiseMissile {
+				t.Fatalf("attemptMoveTo() of a cruise missile attack left the missile; want it spent")
+			}
+			if unit.Strength != GetNewUnitStrength(Destroyer) {
+				t.Errorf("attemptMoveTo() of a cruise missile attack left the destroyer at strength %d; want it sunk or untouched", unit.Strength)
+			}
+		}
+	}
+
+	// a missile attacking a city is spent too, whatever the outcome
+	for i := 0; i < 10; i++ {
+		board := newTerrainBoard("LL")
+		addCity(board, Coordinate{0, 1}, 2)
+		board.addUnit(NewUnit(0, 0, CruiseMissile, 1))
+		if got := board.determineAction(Coordinate{0, 1}, &board.Units[0]); got != ActionCityAttack {
+			t.Fatalf("determineAction() of a cruise missile at a city = %d; want ActionCityAttack", got)
+		}
+		board.attemptMoveTo(Coordinate{0, 1}, &board.Units[0])
+		if len(board.Units) != 0 {
+			t.Fatalf("attemptMoveTo() of a cruise missile attacking a city left %v; want the missile spent", board.Units)
+		}
+	}
+}
+
+func TestGetSpecialistUnitAI(t *testing.T) {
+	type test struct {
+		name    string
+		enemy   UnitType
+		at      Coordinate
+		own     []UnitType // units of player 1 in the city
+		bomber  bool       // player 1 has a bomber away from the city
+		want    UnitType
+		wantOK  bool
+		coastal bool
+	}
+	tests := []test{
+		{name: "nothing near", want: Blank, wantOK: false},
+		{name: "enemy fighter", enemy: Fighter, at: Coordinate{1, 1}, want: AntiAircraft, wantOK: true},
+		{name: "enemy fighter with anti-aircraft", enemy: Fighter, at: Coordinate{1, 1}, own: []UnitType{AntiAircraft}, want: Blank, wantOK: false},
+		{name: "enemy ship off the coast", enemy: Destroyer, at: Coordinate{0, 3}, coastal: true, want: CruiseMissile, wantOK: true},
+		{name: "enemy tank", enemy: Tank, at: Coordinate{1, 1}, want: Artillery, wantOK: true},
+		{name: "bomber with a city in reach", own: []UnitType{Bomber}, want: Paratrooper, wantOK: true},
+		{name: "bomber away from the city", bomber: true, want: Paratrooper, wantOK: true},
+		{name: "paratrooper already in the city", own: []UnitType{Paratrooper}, bomber: true, want: Blank, wantOK: false},
+	}
+	for _, tc := range tests {
+		board := newTerrainBoard(
+			"LLLSSSSSSS",
+			"LLLLLLLLLL",
+		)
+		addCity(board, Coordinate{0, 2}, 1)
+		addCity(board, Coordinate{1, 8}, 0)
+		for _, unitType := range tc.own {
+			board.addUnit(NewUnit(0, 2, unitType, 1))
+		}
+		if tc.bomber {
+			board.addUnit(NewUnit(1, 9, Bomber, 1))
+		}
+		if tc.enemy != Blank {
+			board.addUnit(NewUnit(tc.at.PositionX, tc.at.PositionY, tc.enemy, 2))
+		}
+		board.updateFogOfWarForPlayer(1)
+		got, ok := board.getSpecialistUnitAI(Coordinate{0, 2}, 1, tc.coastal)
+		if got != tc.want || ok != tc.wantOK {
+			t.Errorf("getSpecialistUnitAI(), name:%s, got %s, %t; want %s, %t",
+				tc.name, unitTypeToString(got), ok, unitTypeToString(tc.want), tc.wantOK)
+		}
+	}
+}
+
+func TestSpecialistProductionWeights(t *testing.T) {
+	board := newTerrainBoard(
+		"LLLSSSSSSS",
+		"LLLLLLLLLL",
+	)
+	addCity(board, Coordinate{0, 2}, 1)
+	board.addUnit(NewUnit(1, 1, Fighter, 2))
+	board.updateFogOfWarForPlayer(1)
+
+	type test struct {
+		name    string
+		variant *AIVariant
+		want    int // anti-aircraft units of the 100 built
+	}
+	tests := []test{
+		{name: "specialists left out", variant: &AIVariant{Name: "none", Inland: []unitWeight{{Tank, 1}}}, want: 0},
+		{name: "only specialists", variant: &AIVariant{Name: "only", Specialists: []unitWeight{{AntiAircraft, 1}}}, want: 100},
+	}
+	for _, tc := range tests {
+		got := 0
+		for i := 0; i < 100; i++ {
+			if board.getWhichUnitToManufactureNextAI(Coordinate{0, 2}, 1, false, tc.variant) == AntiAircraft {
+				got++
+			}
+		}
+		if got != tc.want {
+			t.Errorf("getWhichUnitToManufactureNextAI(), name:%s, built %d anti-aircraft units of 100; want %d", tc.name, got, tc.want)
+		}
+	}
+
+	got := 0
+	for i := 0; i < 1000; i++ {
+		if board.getWhichUnitToManufactureNextAI(Coordinate{0, 2}, 1, false, DefaultAIVariant) == AntiAircraft {
+			got++
+		}
+	}
+	if got == 0 || got == 1000 {
+		t.Errorf("getWhichUnitToManufactureNextAI() of the default variant built %d anti-aircraft units of 1000; want them weighed against other units", got)
+	}
+
+	// a bomber away from the city is enough for paratroopers to be built
+	board = newTerrainBoard(
+		"LLLSSSSSSS",
+		"LLLLLLLLLL",
+	)
+	addCity(board, Coordinate{0, 2}, 1)
+	addCity(board, Coordinate{1, 8}, 2)
+	board.addUnit(NewUnit(1, 9, Bomber, 1))
+	board.updateFogOfWarForPlayer(1)
+	variant := &AIVariant{Name: "paratroopers", Specialists: []unitWeight{{Paratrooper, 1}}}
+	if got := board.getWhichUnitToManufactureNextAI(Coordinate{0, 2}, 1, false, variant); got != Paratrooper {
+		t.Errorf("getWhichUnitToManufactureNextAI() with a bomber away from the city = %s; want Paratrooper", unitTypeToString(got))
+	}
+}
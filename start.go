@@ -0,0 +1,118 @@
+package main
+
+import "math"
+
+const (
+	startRadius         = 5    // moves around a starting city in which neutral cities count towards its fairness
+	startCityWeight     = 0.25 // score of each neutral city one start has near it more than the other
+	startScoreTolerance = 1e-9
+)
+
+// startPair is a possible pair of starting cities and how unfair it is.
+type startPair struct {
+	first, second int // indexes into Cities
+	score         float64
+}
+
+// chooseStartingCities returns the indexes of the starting cities of player 1 and player 2.
+// The two cities are distinct and at least MinStartDistance moves apart when possible, and
+// the pair chosen is the fairest: the islands of the two cities are as alike in size as can be,
+// and so are the numbers of neutral cities near them. On a mirrored map, the players start on a
+// city and its mirror image. Equally fair pairs are chosen between at random.
+func (g *GameBoard) chooseStartingCities() (int, int) {
+	minDistance := g.MinStartDistance
+	if minDistance == 0 {
+		minDistance = maxInt(g.Rows, g.Columns) / 3
+	}
+	labels, sizes := g.getMapComponents()
+	// on a mirrored map only a city and its mirror image may start, which is fair by design
+	mirrored := g.isMirrored()
+	isCandidate := func(i, j int) bool {
+		return !mirrored || g.isMirrorPair(i, j)
+	}
+
+	maxDistance := 0
+	for i := range g.Cities {
+		for j := i + 1; j < len(g.Cities); j++ {
+			if isCandidate(i, j) {
+				maxDistance = maxInt(maxDistance, g.getCityDistance(i, j))
+			}
+		}
+	}
+	if maxDistance < minDistance {
+		minDistance = maxDistance // no cities are far enough apart, settle for the farthest
+	}
+
+	var pairs []startPair
+	for i := range g.Cities {
+		for j := i + 1; j < len(g.Cities); j++ {
+			if !isCandidate(i, j) || g.getCityDistance(i, j) < minDistance {
+				continue
+			}
+			sizeA := float64(sizes[labels[g.Cities[i].PositionX][g.Cities[i].PositionY]])
+			sizeB := float64(sizes[labels[g.Cities[j].PositionX][g.Cities[j].PositionY]])
+			nearby := g.countNearbyNeutralCities(i, j) - g.countNearbyNeutralCities(j, i)
+			score := math.Abs(sizeA-sizeB)/math.Max(sizeA, sizeB) + startCityWeight*math.Abs(float64(nearby))
+			pairs = append(pairs, startPair{first: i, second: j, score: score})
+		}
+	}
+
+	best := math.Inf(1)
+	for _, pair := range pairs {
+		best = math.Min(best, pair.score)
+	}
+	var fairest []startPair
+	for _, pair := range pairs {
+		if pair.score <= best+startScoreTolerance {
+			fairest = append(fairest, pair)
+		}
+	}
+	r := g.random()
+	pair := fairest[r.Intn(len(fairest))]
+	if r.Intn(2) == 0 {
+		return pair.first, pair.second
+	}
+	return pair.second, pair.first
+}
+
+// isMirrored checks if the board is the same when turned half way round, as a mirrored map is.
+func (g *GameBoard) isMirrored() bool {
+	if len(g.Cities) < 2 {
+		return false
+	}
+	for i := 0; i < g.Rows; i++ {
+		for j := 0; j < g.Columns; j++ {
+			image := g.getMirrorImage(Coordinate{i, j})
+			cell, mirror := g.Grid[i][j], g.Grid[image.PositionX][image.PositionY]
+			if cell.IsLand != mirror.IsLand || cell.Terrain != mirror.Terrain || cell.HasCity != mirror.HasCity {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isMirrorPair checks if two cities are each other's mirror image.
+func (g *GameBoard) isMirrorPair(i, j int) bool {
+	image := g.getMirrorImage(Coordinate{g.Cities[i].PositionX, g.Cities[i].PositionY})
+	return image.PositionX == g.Cities[j].PositionX && image.PositionY == g.Cities[j].PositionY
+}
+
+// getCityDistance returns the moves between two cities, ignoring what lies between them.
+func (g *GameBoard) getCityDistance(i, j int) int {
+	dx := abs(g.Cities[i].PositionX - g.Cities[j].PositionX)
+	dy := abs(g.Cities[i].PositionY - g.Cities[j].PositionY)
+	return maxInt(dx, dy)
+}
+
+// countNearbyNeutralCities counts the cities within startRadius moves of a city, other than the
+// opponent's starting city.
+func (g *GameBoard) countNearbyNeutralCities(city, opponent int) int {
+	count := 0
+	for i := range g.Cities {
+		if i != city && i != opponent && g.getCityDistance(city, i) <= startRadius {
+			count++
+		}
+	}
+	return count
+}
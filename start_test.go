@@ -0,0 +1,120 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestDayZeroDistinctCities(t *testing.T) {
+	for seed := int64(1); seed <= 30; seed++ {
+		board := NewRandomGameBoard(10, 20, 4, 6, seed)
+		board.Player1 = &Player{Name: "player 1", Controller: &ScriptedController{}}
+		board.Player2 = &Player{Name: "player 2", Controller: &ScriptedController{}}
+		board.DayZero()
+		var starts [3][]int
+		for i, city := range board.Cities {
+			starts[city.OccupyingPlayer] = append(starts[city.OccupyingPlayer], i)
+		}
+		if len(starts[OccupiedByPlayer1]) != 1 || len(starts[OccupiedByPlayer2]) != 1 {
+			t.Errorf("DayZero(), seed %d, player 1 has %d cities and player 2 has %d; want one each",
+				seed, len(starts[OccupiedByPlayer1]), len(starts[OccupiedByPlayer2]))
+			continue
+		}
+		// starts are a third of the board apart, or as far apart as any cities are
+		want := 0
+		for i := range board.Cities {
+			for j := range board.Cities {
+				want = maxInt(want, board.getCityDistance(i, j))
+			}
+		}
+		if want > 20/3 {
+			want = 20 / 3
+		}
+		if distance := board.getCityDistance(starts[OccupiedByPlayer1][0], starts[OccupiedByPlayer2][0]); distance < want {
+			t.Errorf("DayZero(), seed %d, starting cities are %d moves apart; want at least %d", seed, distance, want)
+		}
+	}
+}
+
+// newStartBoard returns a 5x15 board with a city on each of two islands of 15 cells at either
+// end and a city on a small island of 6 cells in the middle.
+func newStartBoard() *GameBoard {
+	board := NewGameBoard(5, 15)
+	board.IterateGrid(func(row, col int, cell *Cell) {
+		cell.IsLand = col <= 2 || col >= 12 || (row <= 1 && col >= 6 && col <= 8)
+	})
+	for _, c := range []Coordinate{{2, 1}, {0, 7}, {2, 13}} {
+		board.Grid[c.PositionX][c.PositionY].HasCity = true
+		board.Cities = append(board.Cities, *NewCity(c.PositionX, c.PositionY))
+	}
+	return board
+}
+
+func TestChooseStartingCities(t *testing.T) {
+	type test struct {
+		name        string
+		minDistance int
+		want        [2]int // indexes of the starting cities, in either order
+	}
+	tests := []test{
+		{name: "islands of the same size", minDistance: 5, want: [2]int{0, 2}},
+		{name: "too far for any pair", minDistance: 50, want: [2]int{0, 2}},
+	}
+	for _, tc := range tests {
+		for seed := int64(1); seed <= 5; seed++ {
+			board := newStartBoard()
+			board.MinStartDistance = tc.minDistance
+			first, second := board.chooseStartingCities()
+			if !(first == tc.want[0] && second == tc.want[1]) && !(first == tc.want[1] && second == tc.want[0]) {
+				t.Errorf("chooseStartingCities(), name:%s, got %d, %d; want %v", tc.name, first, second, tc.want)
+			}
+		}
+	}
+
+	// only cities close to each other are on islands of the same size
+	board := newStartBoard()
+	board.Grid[2][1].HasCity = false
+	board.Cities[0] = *NewCity(0, 13)
+	board.Grid[0][13].HasCity = true
+	board.MinStartDistance = 3
+	first, second := board.chooseStartingCities()
+	if first != 1 && second != 1 {
+		t.Errorf("chooseStartingCities() with cities 2 moves apart, got %d, %d; want the city on the small island to start", first, second)
+	}
+}
+
+func TestMirroredMap(t *testing.T) {
+	for seed := int64(1); seed <= 30; seed++ {
+		options := NewMapOptions(4, 8)
+		options.Mirror = true
+		board, err := NewGeneratedGameBoard(10, 20, options, seed)
+		if err != nil {
+			t.Fatalf("NewGeneratedGameBoard(), seed %d, error = %v", seed, err)
+		}
+		board.IterateGrid(func(row, col int, cell *Cell) {
+			mirror := board.getMirrorImage(Coordinate{row, col})
+			other := board.Grid[mirror.PositionX][mirror.PositionY]
+			if cell.IsLand != other.IsLand || cell.HasCity != other.HasCity {
+				t.Errorf("NewGeneratedGameBoard(), seed %d, (%d, %d) differs from its mirror image", seed, row, col)
+			}
+		})
+
+		board.Player1 = &Player{Name: "player 1", Controller: &ScriptedController{}}
+		board.Player2 = &Player{Name: "player 2", Controller: &ScriptedController{}}
+		board.DayZero()
+		labels, sizes := board.getMapComponents()
+		var islands []int
+		var starts []Coordinate
+		for _, city := range board.Cities {
+			if city.OccupyingPlayer != Unoccupied {
+				islands = append(islands, sizes[labels[city.PositionX][city.PositionY]])
+				starts = append(starts, Coordinate{city.PositionX, city.PositionY})
+			}
+		}
+		if len(islands) != 2 || islands[0] != islands[1] {
+			t.Errorf("DayZero() on a mirrored map, seed %d, starting islands have %v cells; want two of the same size", seed, islands)
+		}
+		if len(starts) == 2 && board.getMirrorImage(starts[0]) != starts[1] {
+			t.Errorf("DayZero() on a mirrored map, seed %d, starting cities %v; want a city and its mirror image", seed, starts)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+// getSupplyNetwork returns, for every cell, whether the player's supply reaches it: a flood fill
+// out from the player's cities over the land of the player's territory, as from getTerritory,
+// and over the cells of the player's transports, which carry supply across the sea.
+func (g *GameBoard) getSupplyNetwork(player int) [][]bool {
+	territory := g.getTerritory()
+	transports := g.newVisitedGrid()
+	for _, unit := range g.Units {
+		if unit.Player == player && unit.Type == Transport {
+			transports[unit.PositionX][unit.PositionY] = true
+		}
+	}
+	network := g.newVisitedGrid()
+	var floodFill func(x, y int)
+	floodFill = func(x, y int) {
+		network[x][y] = true
+		for i := x - 1; i <= x+1; i++ {
+			for j := y - 1; j <= y+1; j++ {
+				if i < 0 || i >= g.Rows || j < 0 || j >= g.Columns || network[i][j] {
+					continue
+				}
+				if (g.Grid[i][j].IsLand && int(territory[i][j]) == player) || transports[i][j] {
+					floodFill(i, j)
+				}
+			}
+		}
+	}
+	for _, city := range g.Cities {
+		if int(city.OccupyingPlayer) == player && !network[city.PositionX][city.PositionY] {
+			floodFill(city.PositionX, city.PositionY)
+		}
+	}
+	return network
+}
+
+// isLandUnit checks if the unit fights on land, and so needs supply.
+func isLandUnit(unit *Unit) bool {
+	return unit.CanMoveOnLand && !unit.CanFly
+}
+
+// isSupplied checks if the unit draws supply from the network, which reaches its cell or the
+// next one.
+func isSupplied(unit *Unit, network [][]bool) bool {
+	for i := unit.PositionX - 1; i <= unit.PositionX+1; i++ {
+		for j := unit.PositionY - 1; j <= unit.PositionY+1; j++ {
+			if i >= 0 && i < len(network) && j >= 0 && j < len(network[i]) && network[i][j] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// applyAttrition takes a point of strength from every land unit out of its player's supply,
+// destroying those with none left.
+func (g *GameBoard) applyAttrition() {
+	var unsupplied []int // IDs of the land units out of supply
+	for player := 1; player <= 2; player++ {
+		network := g.getSupplyNetwork(player)
+		for i := range g.Units {
+			unit := &g.Units[i]
+			if unit.Player == player && isLandUnit(unit) && !isSupplied(unit, network) {
+				unsupplied = append(unsupplied, unit.ID)
+			}
+		}
+	}
+	g.damageUnits(unsupplied, "is out of supply")
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestGetSupplyNetwork(t *testing.T) {
+	board := newTerrainBoard("LLLSSLLL")
+	addCity(board, Coordinate{0, 0}, 1)
+	addCity(board, Coordinate{0, 7}, 2)
+	board.addUnit(NewUnit(0, 3, Transport, 1))
+	network := board.getSupplyNetwork(1)
+	type test struct {
+		name       string
+		coordinate Coordinate
+		want       bool
+	}
+	tests := []test{
+		{name: "the city", coordinate: Coordinate{0, 0}, want: true},
+		{name: "own territory", coordinate: Coordinate{0, 2}, want: true},
+		{name: "a transport", coordinate: Coordinate{0, 3}, want: true},
+		{name: "open sea", coordinate: Coordinate{0, 4}, want: false},
+		{name: "enemy territory", coordinate: Coordinate{0, 5}, want: false},
+	}
+	for _, tc := range tests {
+		if got := network[tc.coordinate.PositionX][tc.coordinate.PositionY]; got != tc.want {
+			t.Errorf("getSupplyNetwork(), name:%s, got %t; want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApplyAttrition(t *testing.T) {
+	board := newTerrainBoard("LLLSSLLL")
+	useRuleSetForTest(t, board, "modern")
+	addCity(board, Coordinate{0, 0}, 1)
+	addCity(board, Coordinate{0, 7}, 2)
+	board.addUnit(NewUnit(0, 1, Tank, 1))    // supplied
+	board.addUnit(NewUnit(0, 5, Tank, 1))    // cut off in enemy territory
+	board.addUnit(NewUnit(0, 6, Fighter, 1)) // aircraft need no supply
+	board.NextDay()
+	type test struct {
+		name string
+		id   int
+		want int
+	}
+	tests := []test{
+		{name: "supplied tank", id: 1, want: GetNewUnitStrength(Tank)},
+		{name: "cut off tank", id: 2, want: GetNewUnitStrength(Tank) - 1},
+		{name: "fighter", id: 3, want: GetNewUnitStrength(Fighter)},
+	}
+	for _, tc := range tests {
+		if unit := board.getUnitByID(tc.id); unit == nil || unit.Strength != tc.want {
+			t.Errorf("NextDay() with supply, name:%s, got %v; want strength %d", tc.name, unit, tc.want)
+		}
+	}
+
+	// a transport next to the tank carries supply to it
+	board.addUnit(NewUnit(0, 4, Transport, 1))
+	board.addUnit(NewUnit(0, 3, Transport, 1))
+	board.NextDay()
+	if unit := board.getUnitByID(2); unit == nil || unit.Strength != GetNewUnitStrength(Tank)-1 {
+		t.Errorf("NextDay() with supply by transport left the tank %v; want it no weaker", unit)
+	}
+	board.Units = board.Units[:3]
+	board.NextDay()
+	if unit := board.getUnitByID(2); unit != nil {
+		t.Errorf("NextDay() with supply left the cut off tank %v; want it destroyed", unit)
+	}
+}
+
+func TestTUIRenderSupply(t *testing.T) {
+	board := newTerrainBoard("LLLSSLLL")
+	addCity(board, Coordinate{0, 0}, 1)
+	board.addUnit(NewUnit(0, 6, Tank, 1))
+	board.updateFogOfWarForPlayer(1)
+	var out bytes.Buffer
+	ui := NewTUI(board, strings.NewReader(""), &out)
+	ui.Player = 1
+	ui.Supply = true
+	ui.Render()
+	screen := out.String()
+	if !strings.Contains(screen, colourPlayer1+"m+") {
+		t.Errorf("Render() with the supply overlay does not show the network: %q", screen)
+	}
+	if !strings.Contains(screen, colourPlayer1+";"+colourNoSupply+"mT") {
+		t.Errorf("Render() with the supply overlay does not show the tank out of supply: %q", screen)
+	}
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// makeRawTerminal is not supported on this platform, so keys are read a line at a time.
+func makeRawTerminal(fd uintptr) (func(), error) {
+	return nil, errors.New("raw terminal mode is not supported on this platform")
+}
+
+// terminalSize is not known on this platform.
+func terminalSize(fd uintptr) (int, int, bool) {
+	return 0, 0, false
+}
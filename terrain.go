@@ -0,0 +1,220 @@
+package main
+
+import "strings"
+
+// Terrain is the kind of land or sea of a cell, beyond Cell.IsLand.
+type Terrain int
+
+const (
+	TerrainPlain     Terrain = iota // open land, or open sea
+	TerrainMountains                // land tanks cannot cross, but which favours its defenders
+	TerrainForest                   // land which slows tanks and hides them
+	TerrainShallows                 // sea too shallow for battleships and carriers
+	TerrainIce                      // polar sea no ship can cross
+)
+
+const (
+	forestMoveCost        = 2  // moves a tank spends entering a forest
+	mountainDefenceBonus  = 50 // percent added to the defender's odds on mountains
+	forestDefenceBonus    = 25 // percent added to the defender's odds in a forest
+	attackOddsPercent     = 50 // percent chance of an attack succeeding on open ground
+	mountainPercentOfLand = 10 // share of a generated map's land which is mountains
+	forestPercentOfLand   = 20 // share of a generated map's land which is forest
+	shallowsPercentOfSea  = 15 // share of a generated map's sea which is shallows
+)
+
+// terrainToString returns the name of the terrain of a cell.
+func terrainToString(isLand bool, terrain Terrain) string {
+	switch terrain {
+	case TerrainMountains:
+		return "mountains"
+	case TerrainForest:
+		return "forest"
+	case TerrainShallows:
+		return "shallows"
+	case TerrainIce:
+		return "ice"
+	}
+	if isLand {
+		return "land"
+	}
+	return "sea"
+}
+
+// terrainFromString returns the land terrain with the given name, as cities may stand on them.
+func terrainFromString(name string) (Terrain, bool) {
+	for _, terrain := range []Terrain{TerrainPlain, TerrainMountains, TerrainForest} {
+		if terrainToString(true, terrain) == name {
+			return terrain, true
+		}
+	}
+	return TerrainPlain, false
+}
+
+// terrainToSymbol returns the letter of a cell's terrain in saves, scenarios and player views:
+// 'L' land, 'S' sea, 'H' mountains (highlands), 'G' forest (greenwood), 'W' shallows and 'I' ice.
+// The letters of rough terrain are kept apart from the symbols of units, which share the map.
+func terrainToSymbol(cell Cell) byte {
+	switch cell.Terrain {
+	case TerrainMountains:
+		return 'H'
+	case TerrainForest:
+		return 'G'
+	case TerrainShallows:
+		return 'W'
+	case TerrainIce:
+		return 'I'
+	}
+	if cell.IsLand {
+		return 'L'
+	}
+	return 'S'
+}
+
+// terrainFromSymbol returns whether a terrain letter is land, and its terrain, or false if the
+// letter is not one of terrainToSymbol's.
+func terrainFromSymbol(c rune) (bool, Terrain, bool) {
+	switch c {
+	case 'L':
+		return true, TerrainPlain, true
+	case 'S':
+		return false, TerrainPlain, true
+	case 'H':
+		return true, TerrainMountains, true
+	case 'G':
+		return true, TerrainForest, true
+	case 'W':
+		return false, TerrainShallows, true
+	case 'I':
+		return false, TerrainIce, true
+	}
+	return false, TerrainPlain, false
+}
+
+// mapSymbols are the characters maps show for anything but units: cities neutral, own and enemy,
+// or held by player 1 or 2, fog and cells with several units.
+const mapSymbols = "COE12?*"
+
+// isMapSymbol checks if a unit's symbol, of either case, would be mistaken on a map for a
+// terrainToSymbol letter or one of the mapSymbols.
+func isMapSymbol(symbol string) bool {
+	for _, c := range strings.ToUpper(symbol) {
+		if _, _, ok := terrainFromSymbol(c); ok || strings.ContainsRune(mapSymbols, c) {
+			return true
+		}
+	}
+	return false
+}
+
+// isDeepDraft checks if a ship of the unit type runs aground in shallows.
+func (g *GameBoard) isDeepDraft(unitType UnitType) bool {
+	return g.unitDefinitionOf(unitType).DeepDraft
+}
+
+// canEnterTerrain checks if the terrain of the cell lets the unit onto it, leaving aside what
+// is already there. Aircraft fly over everything; land units may enter cities, even on mountains.
+func (g *GameBoard) canEnterTerrain(coordinate Coordinate, unit *Unit) bool {
+	cell := g.Grid[coordinate.PositionX][coordinate.PositionY]
+	switch {
+	case unit.CanFly:
+		return true
+	case cell.HasCity:
+		return unit.CanMoveOnLand
+	case cell.IsLand:
+		return unit.CanMoveOnLand && cell.Terrain != TerrainMountains
+	case cell.Terrain == TerrainIce:
+		return false
+	case cell.Terrain == TerrainShallows && (g.isDeepDraft(unit.Type) || g.isFrozen(coordinate)):
+		return false
+	default:
+		return unit.CanMoveOnWater
+	}
+}
+
+// getMoveCost returns the moves the unit spends entering the cell.
+func (g *GameBoard) getMoveCost(coordinate Coordinate, unit *Unit) int {
+	cell := g.Grid[coordinate.PositionX][coordinate.PositionY]
+	if cell.Terrain == TerrainForest && !cell.HasCity && !unit.CanFly {
+		return forestMoveCost
+	}
+	if (unit.CanFly || unit.CanMoveOnWater) && g.isStorm(coordinate) {
+		return stormMoveCost
+	}
+	return 1
+}
+
+// getTerrainDefenceBonus returns the percent added to the odds of a defender on the cell.
+func (g *GameBoard) getTerrainDefenceBonus(coordinate Coordinate) int {
+	switch g.Grid[coordinate.PositionX][coordinate.PositionY].Terrain {
+	case TerrainMountains:
+		return mountainDefenceBonus
+	case TerrainForest:
+		return forestDefenceBonus
+	default:
+		return 0
+	}
+}
+
+// getAttackOutcomeAt decides the outcome of an attack on a defender at the coordinate, the
+// terrain shifting the odds towards the defender.
+func (g *GameBoard) getAttackOutcomeAt(coordinate Coordinate) bool {
+	bonus := g.getTerrainDefenceBonus(coordinate)
+	if bonus == 0 {
+		return g.getAttackOutcome()
+	}
+	return g.random().Intn(100+bonus) < attackOddsPercent
+}
+
+// isHiddenByTerrain checks if the unit is hidden by the terrain it stands on: a land unit in a
+// forest can only be seen from next to it, and not from the air or from cities.
+func (g *GameBoard) isHiddenByTerrain(unit *Unit) bool {
+	cell := g.Grid[unit.PositionX][unit.PositionY]
+	return cell.Terrain == TerrainForest && !cell.HasCity && unit.CanMoveOnLand && !unit.CanFly
+}
+
+// isUnitInSightOfPlayer checks if the player can see the enemy unit: it is in sight of the
+// player's units or cities, or if it is hidden by a forest, next to one of the player's units
+// which is not an aircraft.
+func (g *GameBoard) isUnitInSightOfPlayer(player int, unit *Unit) bool {
+	coordinate := Coordinate{unit.PositionX, unit.PositionY}
+	if !g.isHiddenByTerrain(unit) {
+		return g.isInSightOfPlayer(player, coordinate)
+	}
+	for _, spotter := range g.Units {
+		if spotter.Player == player && !spotter.CanFly &&
+			abs(spotter.PositionX-coordinate.PositionX) <= 1 && abs(spotter.PositionY-coordinate.PositionY) <= 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// pathStep is a cell reached by FindPath, with the moves spent reaching it.
+type pathStep struct {
+	coordinate Coordinate
+	cost       int
+	order      int // steps reached at the same cost are explored in the order they were reached
+}
+
+// pathQueue is a priority queue of pathSteps, cheapest first.
+type pathQueue []pathStep
+
+func (q pathQueue) Len() int { return len(q) }
+
+func (q pathQueue) Less(a, b int) bool {
+	if q[a].cost != q[b].cost {
+		return q[a].cost < q[b].cost
+	}
+	return q[a].order < q[b].order
+}
+
+func (q pathQueue) Swap(a, b int) { q[a], q[b] = q[b], q[a] }
+
+func (q *pathQueue) Push(x any) { *q = append(*q, x.(pathStep)) }
+
+func (q *pathQueue) Pop() any {
+	old := *q
+	step := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return step
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newTerrainBoard returns a board from rows of terrainToSymbol letters, without cities.
+func newTerrainBoard(rows ...string) *GameBoard {
+	board := NewGameBoard(len(rows), len(rows[0]))
+	board.Output = io.Discard
+	for i, row := range rows {
+		for j, c := range row {
+			board.Grid[i][j].IsLand, board.Grid[i][j].Terrain, _ = terrainFromSymbol(c)
+		}
+	}
+	return board
+}
+
+func TestCanEnterTerrain(t *testing.T) {
+	board := newTerrainBoard("LHGSWI")
+	type test struct {
+		name     string
+		unitType UnitType
+		column   int
+		want     bool
+	}
+	tests := []test{
+		{name: "tank on land", unitType: Tank, column: 0, want: true},
+		{name: "tank on mountains", unitType: Tank, column: 1, want: false},
+		{name: "tank in forest", unitType: Tank, column: 2, want: true},
+		{name: "tank at sea", unitType: Tank, column: 3, want: false},
+		{name: "fighter over mountains", unitType: Fighter, column: 1, want: true},
+		{name: "fighter over ice", unitType: Fighter, column: 5, want: true},
+		{name: "destroyer in shallows", unitType: Destroyer, column: 4, want: true},
+		{name: "battleship at sea", unitType: Battleship, column: 3, want: true},
+		{name: "battleship in shallows", unitType: Battleship, column: 4, want: false},
+		{name: "carrier in shallows", unitType: Carrier, column: 4, want: false},
+		{name: "transport on ice", unitType: Transport, column: 5, want: false},
+	}
+	for _, tc := range tests {
+		unit := NewUnit(0, 0, tc.unitType, 1)
+		if got := board.canEnterTerrain(Coordinate{0, tc.column}, unit); got != tc.want {
+			t.Errorf("canEnterTerrain(), name:%s, got %t; want %t", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestMountainCity(t *testing.T) {
+	board := newTerrainBoard("LH")
+	board.Grid[0][1].HasCity = true
+	board.Cities = append(board.Cities, *NewCity(0, 1))
+	tank := NewUnit(0, 0, Tank, 1)
+	if got := board.determineAction(Coordinate{0, 1}, tank); got != ActionCityAttack {
+		t.Errorf("determineAction() of a tank at a city on mountains = %d; want ActionCityAttack", got)
+	}
+	if got := board.getTerrainDefenceBonus(Coordinate{0, 1}); got != mountainDefenceBonus {
+		t.Errorf("getTerrainDefenceBonus() of a city on mountains = %d; want %d", got, mountainDefenceBonus)
+	}
+}
+
+func TestDetermineActionTerrain(t *testing.T) {
+	board := newTerrainBoard("SWI", "LHG")
+	type test struct {
+		name string
+		unit *Unit
+		move Coordinate
+		want ActionType
+	}
+	tests := []test{
+		{name: "battleship into shallows", unit: NewUnit(0, 0, Battleship, 1), move: Coordinate{0, 1}, want: ActionIllegalMove},
+		{name: "submarine into shallows", unit: NewUnit(0, 0, Submarine, 1), move: Coordinate{0, 1}, want: ActionMove},
+		{name: "submarine onto ice", unit: NewUnit(0, 1, Submarine, 1), move: Coordinate{0, 2}, want: ActionIllegalMove},
+		{name: "tank onto mountains", unit: NewUnit(1, 0, Tank, 1), move: Coordinate{1, 1}, want: ActionIllegalMove},
+		{name: "tank into forest", unit: NewUnit(1, 1, Tank, 1), move: Coordinate{1, 2}, want: ActionMove},
+		{name: "bomber over ice", unit: NewUnit(1, 2, Bomber, 1), move: Coordinate{0, 2}, want: ActionMove},
+	}
+	for _, tc := range tests {
+		if got := board.determineAction(tc.move, tc.unit); got != tc.want {
+			t.Errorf("determineAction(), name:%s, got %d; want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFindPathTerrain(t *testing.T) {
+	// a mountain range with a pass through the forest, and a way round on open land below
+	board := newTerrainBoard(
+		"LHLLL",
+		"LGHLL",
+		"LLLLL",
+	)
+	tank := NewUnit(0, 0, Tank, 1)
+	path := board.FindPath(Coordinate{0, 2}, tank)
+	if len(path) == 0 || path[0] != (Coordinate{0, 0}) || path[len(path)-1] != (Coordinate{0, 2}) {
+		t.Fatalf("FindPath() round the mountains = %v; want a path from (0, 0) to (0, 2)", path)
+	}
+	for _, c := range path {
+		if board.Grid[c.PositionX][c.PositionY].Terrain == TerrainMountains {
+			t.Errorf("FindPath() = %v crosses the mountains at %v", path, c)
+		}
+	}
+
+	// the forest costs a tank more moves than going round it on open land
+	board = newTerrainBoard(
+		"LGGL",
+		"LLLL",
+	)
+	path = board.FindPath(Coordinate{0, 3}, NewUnit(0, 0, Tank, 1))
+	if len(path) == 0 {
+		t.Fatalf("FindPath() round the forest found no path")
+	}
+	for _, c := range path {
+		if board.Grid[c.PositionX][c.PositionY].Terrain == TerrainForest {
+			t.Errorf("FindPath() = %v goes through the forest rather than round it", path)
+		}
+	}
+	// aircraft fly straight over it
+	if path := board.FindPath(Coordinate{0, 3}, NewUnit(0, 0, Fighter, 1)); len(path) != 4 {
+		t.Errorf("FindPath() of a fighter = %v; want the straight path over the forest", path)
+	}
+
+	// mountains across the whole island leave no path
+	board = newTerrainBoard("LHL", "LHL")
+	if path := board.FindPath(Coordinate{0, 2}, NewUnit(0, 0, Tank, 1)); path != nil {
+		t.Errorf("FindPath() across a mountain range = %v; want nil", path)
+	}
+}
+
+func TestGetRandomMovesTerrain(t *testing.T) {
+	board := newTerrainBoard(
+		"HHH",
+		"HLG",
+		"HHH",
+	)
+	moves := board.getRandomMoves(NewUnit(1, 1, Tank, 1))
+	if want := []Coordinate{{1, 2}}; !reflect.DeepEqual(moves, want) {
+		t.Errorf("getRandomMoves() of a tank among mountains = %v; want %v", moves, want)
+	}
+}
+
+func TestForestMoveCost(t *testing.T) {
+	board := newTerrainBoard("LGL")
+	tank := NewUnit(0, 0, Tank, 1)
+	tank.MovesLeftThisDay = 3
+	board.attemptMoveTo(Coordinate{0, 1}, tank)
+	if tank.PositionY != 1 || tank.MovesLeftThisDay != 3-forestMoveCost {
+		t.Errorf("attemptMoveTo() into a forest, tank at column %d with %d moves left; want column 1 with %d",
+			tank.PositionY, tank.MovesLeftThisDay, 3-forestMoveCost)
+	}
+	tank.MovesLeftThisDay = 1
+	board.attemptMoveTo(Coordinate{0, 0}, tank)
+	board.attemptMoveTo(Coordinate{0, 1}, tank)
+	if tank.MovesLeftThisDay != 0 {
+		t.Errorf("attemptMoveTo() into a forest with too few moves left %d moves; want 0", tank.MovesLeftThisDay)
+	}
+}
+
+func TestForestHidesTanks(t *testing.T) {
+	board := newTerrainBoard("LLGLL")
+	board.clearFogOfWarForPlayer(1, Coordinate{0, 2}, 2)
+	board.addUnit(NewUnit(0, 2, Tank, 2))
+	board.addUnit(NewUnit(0, 1, Fighter, 1))
+	if units := board.visibleUnitsAt(1, Coordinate{0, 2}); len(units) != 0 {
+		t.Errorf("visibleUnitsAt() of a tank in a forest seen from a fighter = %v; want none", units)
+	}
+	board.addUnit(NewUnit(0, 3, Tank, 1))
+	if units := board.visibleUnitsAt(1, Coordinate{0, 2}); len(units) != 1 {
+		t.Errorf("visibleUnitsAt() of a tank in a forest next to a tank = %v; want the tank", units)
+	}
+}
+
+func TestTerrainDefence(t *testing.T) {
+	board := newTerrainBoard("LH")
+	wins := 0
+	for i := 0; i < 3000; i++ {
+		if board.getAttackOutcomeAt(Coordinate{0, 1}) {
+			wins++
+		}
+	}
+	// an attack on mountains succeeds a third of the time, rather than half
+	if wins < 850 || wins > 1150 {
+		t.Errorf("getAttackOutcomeAt() on mountains succeeded %d times in 3000; want about 1000", wins)
+	}
+}
+
+func TestTerrainSaveAndScenario(t *testing.T) {
+	const scenario = `{"map": ["LHG1", "SWIC"], "cities": [{"x": 1, "y": 3, "terrain": "mountains"}]}`
+	board, err := LoadScenario(strings.NewReader(scenario), 1)
+	if err != nil {
+		t.Fatalf("LoadScenario() error = %v", err)
+	}
+	if board.Grid[0][1].Terrain != TerrainMountains || board.Grid[1][1].Terrain != TerrainShallows ||
+		board.Grid[1][2].Terrain != TerrainIce || board.Grid[1][3].Terrain != TerrainMountains {
+		t.Errorf("LoadScenario() did not load the terrain of the map")
+	}
+
+	if got := board.newScenario(); strings.Join(got.Map, ",") != "LHG1,SWIC" || got.Cities[0].Terrain != "mountains" {
+		t.Errorf("newScenario() = %+v; want the terrain written back", got)
+	}
+
+	var buf bytes.Buffer
+	if err := board.SaveGame(&buf); err != nil {
+		t.Fatalf("SaveGame() error = %v", err)
+	}
+	loaded, err := LoadGame(&buf)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if loaded.StateHash() != board.StateHash() || loaded.Grid[1][2].Terrain != TerrainIce {
+		t.Errorf("LoadGame() of a saved game lost the terrain")
+	}
+
+	if _, err := LoadScenario(strings.NewReader(`{"map": ["1"], "cities": [{"x": 0, "y": 0, "terrain": "ice"}]}`), 1); err == nil {
+		t.Errorf("LoadScenario() of a city on ice, got no error; want an error")
+	}
+}
+
+func TestGenerateMapTerrain(t *testing.T) {
+	for seed := int64(1); seed <= 10; seed++ {
+		options := NewMapOptions(4, 8)
+		options.Terrain = true
+		board, err := NewGeneratedGameBoard(14, 30, options, seed)
+		if err != nil {
+			t.Fatalf("NewGeneratedGameBoard(), seed %d, error = %v", seed, err)
+		}
+		counts := map[Terrain]int{}
+		board.IterateGrid(func(row, col int, cell *Cell) {
+			counts[cell.Terrain]++
+			if (row == 0 || row == board.Rows-1) && !cell.IsLand && cell.Terrain != TerrainIce {
+				t.Errorf("NewGeneratedGameBoard(), seed %d, polar sea at (%d, %d) is not ice", seed, row, col)
+			}
+			if cell.IsLand != (cell.Terrain != TerrainShallows && cell.Terrain != TerrainIce) && cell.Terrain != TerrainPlain {
+				t.Errorf("NewGeneratedGameBoard(), seed %d, terrain %s at (%d, %d) is on the wrong side of the coast",
+					seed, terrainToString(cell.IsLand, cell.Terrain), row, col)
+			}
+		})
+		for _, terrain := range []Terrain{TerrainMountains, TerrainForest, TerrainShallows, TerrainIce} {
+			if counts[terrain] == 0 {
+				t.Errorf("NewGeneratedGameBoard(), seed %d, has no %s", seed, terrainToString(false, terrain))
+			}
+		}
+	}
+}
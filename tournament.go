@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// TournamentConfig configures a tournament of AI-vs-AI games.
+type TournamentConfig struct {
+	Variants []*AIVariant
+	Games    int   // games played by each ordered pair of variants
+	Seed     int64 // seed of the first game, each further game uses the next seed
+	MaxDays  int   // a game still running after this many days is a draw
+	Workers  int   // games played at the same time
+	Rows     int
+	Columns  int
+	Islands  int
+	Cities   int
+	Rules    *RuleSet // rule set the games are played under, the classic rules when nil
+	// NewBoard returns the board of the game played with the seed, a map generated from the
+	// fields above when nil.
+	NewBoard func(seed int64) (*GameBoard, error)
+}
+
+// GameResult is the outcome of one tournament game.
+type GameResult struct {
+	Seed           int64             `json:"seed"`
+	Variants       [2]string         `json:"variants"` // AI variant of player 1 and player 2
+	Winner         int               `json:"winner"`   // 0 for a draw
+	Days           int               `json:"days"`
+	Produced       [2]map[string]int `json:"produced"` // units produced by each player, by type
+	CitiesCaptured [2]int            `json:"citiesCaptured"`
+	Error          string            `json:"error,omitempty"` // why the game could not be played
+}
+
+// VariantStats summarises the results of one AI variant in a tournament.
+type VariantStats struct {
+	Name           string         `json:"name"`
+	Games          int            `json:"games"`
+	Wins           int            `json:"wins"`
+	Losses         int            `json:"losses"`
+	Draws          int            `json:"draws"`
+	WinRate        float64        `json:"winRate"`
+	Elo            float64        `json:"elo"`
+	Produced       map[string]int `json:"produced"`
+	CitiesCaptured int            `json:"citiesCaptured"`
+}
+
+// TournamentReport is the result of a tournament.
+type TournamentReport struct {
+	Variants             []VariantStats `json:"variants"`
+	AverageDays          float64        `json:"averageDays"`
+	CitiesCapturedPerDay float64        `json:"citiesCapturedPerDay"`
+	Games                []GameResult   `json:"games"`
+	UnitTypes            []string       `json:"unitTypes"`          // names of the unit types of the rules played, in order
+	Unplayed             int            `json:"unplayed,omitempty"` // games whose map could not be generated
+}
+
+const (
+	initialElo = 1500
+	eloK       = 32
+)
+
+// runTournamentCommand runs the "tournament" command with its command line arguments.
+func runTournamentCommand(args []string) error {
+	flags := flag.NewFlagSet("tournament", flag.ContinueOnError)
+	config := TournamentConfig{}
+	variantNames := flags.String("variants", "default,land,naval,air", "comma separated AI variants to play against each other")
+	flags.IntVar(&config.Games, "games", 100, "games played by each pair of variants in each seating")
+	flags.Int64Var(&config.Seed, "seed", 1, "seed of the first game")
+	flags.IntVar(&config.MaxDays, "maxDays", 300, "days after which a game is a draw")
+	flags.IntVar(&config.Workers, "workers", runtime.NumCPU(), "games played in parallel")
+	csvPath := flags.String("csv", "", "write the result of every game to this CSV file")
+	jsonPath := flags.String("json", "", "write the report to this JSON file")
+	mapFlags := addMapFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var err error
+	if config.Rules, err = loadRuleSet(*mapFlags.rules, *mapFlags.units); err != nil {
+		return err
+	}
+	config.NewBoard = mapFlags.newGameBoard
+	if _, err := config.newBoard(config.Seed); err != nil {
+		return err
+	}
+	for _, name := range strings.Split(*variantNames, ",") {
+		variant, err := getAIVariant(strings.TrimSpace(name))
+		if err != nil {
+			return err
+		}
+		config.Variants = append(config.Variants, variant)
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	report := runTournament(config)
+	printTournamentReport(os.Stdout, report)
+	if *csvPath != "" {
+		if err := writeFile(*csvPath, func(w io.Writer) error { return writeTournamentCSV(w, report) }); err != nil {
+			return err
+		}
+	}
+	if *jsonPath != "" {
+		if err := writeFile(*jsonPath, func(w io.Writer) error { return writeTournamentJSON(w, report) }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the configuration can be played, with variants whose names tell them apart in
+// the report.
+func (c TournamentConfig) Validate() error {
+	if len(c.Variants) == 0 {
+		return errors.New("no AI variants to play")
+	}
+	names := make(map[string]bool)
+	for _, variant := range c.Variants {
+		if names[variant.Name] {
+			return fmt.Errorf("AI variant %q is named more than once", variant.Name)
+		}
+		names[variant.Name] = true
+		if err := variant.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile creates the file at path and writes it with write.
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runTournament plays every ordered pair of variants against each other config.Games times,
+// spreading the games over config.Workers goroutines.
+func runTournament(config TournamentConfig) TournamentReport {
+	type pairing struct {
+		seed     int64
+		variants [2]*AIVariant
+	}
+	var pairings []pairing
+	for i, first := range config.Variants {
+		for j, second := range config.Variants {
+			if i == j && len(config.Variants) > 1 {
+				continue
+			}
+			for n := 0; n < config.Games; n++ {
+				pairings = append(pairings, pairing{
+					seed:     config.Seed + int64(len(pairings)),
+					variants: [2]*AIVariant{first, second},
+				})
+			}
+		}
+	}
+
+	workers := config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	results := make([]GameResult, len(pairings))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = playTournamentGame(config, pairings[i].seed, pairings[i].variants)
+			}
+		}()
+	}
+	for i := range pairings {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	report := newTournamentReport(config.Variants, results)
+	units := defaultRules.unitRules
+	if config.Rules != nil {
+		units = config.Rules.unitRules
+	}
+	for _, unitType := range units.unitTypes() {
+		report.UnitTypes = append(report.UnitTypes, units.typeToString(unitType))
+	}
+	return report
+}
+
+// newBoard returns the board of the tournament's game played with the seed.
+func (c TournamentConfig) newBoard(seed int64) (*GameBoard, error) {
+	if c.NewBoard != nil {
+		return c.NewBoard(seed)
+	}
+	options := NewMapOptions(c.Islands, c.Cities)
+	options.Rules = c.Rules
+	return NewGeneratedGameBoard(c.Rows, c.Columns, options, seed)
+}
+
+// playTournamentGame plays one AI-vs-AI game on its own board.
+func playTournamentGame(config TournamentConfig, seed int64, variants [2]*AIVariant) GameResult {
+	result := GameResult{
+		Seed:     seed,
+		Variants: [2]string{variants[0].Name, variants[1].Name},
+		Produced: [2]map[string]int{{}, {}},
+	}
+
+	board, err := config.newBoard(seed)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	board.Output = io.Discard
+	board.Player1 = &Player{Name: "player 1", IsAI: true, Controller: &AIController{Variant: variants[0]}}
+	board.Player2 = &Player{Name: "player 2", IsAI: true, Controller: &AIController{Variant: variants[1]}}
+	board.AddEventListener(func(event Event) {
+		if event.Player != 1 && event.Player != 2 {
+			return
+		}
+		switch event.Type {
+		case EventUnitProduced:
+			result.Produced[event.Player-1][board.unitTypeToString(event.UnitType)]++
+		case EventCityCaptured:
+			result.CitiesCaptured[event.Player-1]++
+		}
+	})
+	board.DayZero()
+
+	result.Winner = board.PlayGame(config.MaxDays)
+	result.Days = board.Day
+	return result
+}
+
+// newTournamentReport summarises the games of a tournament.
+func newTournamentReport(variants []*AIVariant, results []GameResult) TournamentReport {
+	report := TournamentReport{Games: results}
+	stats := make(map[string]*VariantStats)
+	for _, variant := range variants {
+		report.Variants = append(report.Variants, VariantStats{Name: variant.Name, Elo: initialElo, Produced: map[string]int{}})
+	}
+	for i := range report.Variants {
+		stats[report.Variants[i].Name] = &report.Variants[i]
+	}
+
+	totalDays, totalCaptured := 0, 0
+	for _, result := range results {
+		if result.Error != "" {
+			report.Unplayed++
+			continue
+		}
+		totalDays += result.Days
+		first, second := stats[result.Variants[0]], stats[result.Variants[1]]
+		for player, s := range []*VariantStats{first, second} {
+			s.Games++
+			s.CitiesCaptured += result.CitiesCaptured[player]
+			totalCaptured += result.CitiesCaptured[player]
+			for unitType, count := range result.Produced[player] {
+				s.Produced[unitType] += count
+			}
+			switch result.Winner {
+			case 0:
+				s.Draws++
+			case player + 1:
+				s.Wins++
+			default:
+				s.Losses++
+			}
+		}
+		if first != second {
+			score := 0.5
+			if result.Winner == 1 {
+				score = 1
+			} else if result.Winner == 2 {
+				score = 0
+			}
+			first.Elo, second.Elo = updateElo(first.Elo, second.Elo, score)
+		}
+	}
+	for i := range report.Variants {
+		s := &report.Variants[i]
+		if s.Games > 0 {
+			s.WinRate = float64(s.Wins) / float64(s.Games)
+		}
+	}
+	if played := len(results) - report.Unplayed; played > 0 {
+		report.AverageDays = float64(totalDays) / float64(played)
+	}
+	if totalDays > 0 {
+		report.CitiesCapturedPerDay = float64(totalCaptured) / float64(totalDays)
+	}
+	return report
+}
+
+// updateElo returns the new ratings of two players after a game, where score is
+// 1 if the first player won, 0 if the second player won and 0.5 for a draw.
+func updateElo(first, second, score float64) (float64, float64) {
+	expected := 1 / (1 + math.Pow(10, (second-first)/400))
+	change := eloK * (score - expected)
+	return first + change, second - change
+}
+
+// printTournamentReport writes the report as a table.
+func printTournamentReport(w io.Writer, report TournamentReport) {
+	fmt.Fprintf(w, "games: %d, average length: %.1f days, cities captured per day: %.2f\n\n",
+		len(report.Games), report.AverageDays, report.CitiesCapturedPerDay)
+	if report.Unplayed > 0 {
+		fmt.Fprintf(w, "%d games not played, as their maps could not be generated\n\n", report.Unplayed)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "variant\tgames\twins\tlosses\tdraws\twin rate\telo\tcities captured")
+	for _, name := range report.UnitTypes {
+		fmt.Fprintf(tw, "\t%s", name)
+	}
+	fmt.Fprintln(tw)
+	for _, s := range report.Variants {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1f%%\t%.0f\t%d", s.Name, s.Games, s.Wins, s.Losses, s.Draws, 100*s.WinRate, s.Elo, s.CitiesCaptured)
+		for _, name := range report.UnitTypes {
+			fmt.Fprintf(tw, "\t%d", s.Produced[name])
+		}
+		fmt.Fprintln(tw)
+	}
+	tw.Flush()
+}
+
+// writeTournamentCSV writes one row per game.
+func writeTournamentCSV(w io.Writer, report TournamentReport) error {
+	cw := csv.NewWriter(w)
+	header := []string{"seed", "variant1", "variant2", "winner", "days", "citiesCaptured1", "citiesCaptured2"}
+	for _, name := range report.UnitTypes {
+		header = append(header, "produced"+name+"1", "produced"+name+"2")
+	}
+	header = append(header, "error")
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, result := range report.Games {
+		record := []string{
+			strconv.FormatInt(result.Seed, 10),
+			result.Variants[0],
+			result.Variants[1],
+			strconv.Itoa(result.Winner),
+			strconv.Itoa(result.Days),
+			strconv.Itoa(result.CitiesCaptured[0]),
+			strconv.Itoa(result.CitiesCaptured[1]),
+		}
+		for _, name := range report.UnitTypes {
+			record = append(record, strconv.Itoa(result.Produced[0][name]), strconv.Itoa(result.Produced[1][name]))
+		}
+		record = append(record, result.Error)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTournamentJSON writes the whole report as JSON.
+func writeTournamentJSON(w io.Writer, report TournamentReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
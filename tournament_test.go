@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestRunTournament(t *testing.T) {
+	config := TournamentConfig{
+		Variants: []*AIVariant{DefaultAIVariant, AIVariants[1]},
+		Games:    2,
+		Seed:     42,
+		MaxDays:  30,
+		Workers:  3,
+		Rows:     10,
+		Columns:  20,
+		Islands:  4,
+		Cities:   6,
+	}
+	report := runTournament(config)
+
+	if len(report.Games) != 4 {
+		t.Fatalf("runTournament() played %d games; want 4", len(report.Games))
+	}
+	for _, s := range report.Variants {
+		if s.Games != 4 {
+			t.Errorf("runTournament() variant %s played %d games; want 4", s.Name, s.Games)
+		}
+		if s.Wins+s.Losses+s.Draws != s.Games {
+			t.Errorf("runTournament() variant %s results do not add up: %+v", s.Name, s)
+		}
+	}
+
+	// the same seeds replay the same games
+	again := runTournament(config)
+	if !reflect.DeepEqual(report.Games, again.Games) {
+		t.Errorf("runTournament() is not deterministic: %+v, then %+v", report.Games, again.Games)
+	}
+
+	var buf bytes.Buffer
+	if err := writeTournamentCSV(&buf, report); err != nil {
+		t.Fatalf("writeTournamentCSV() error = %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("reading CSV: %v", err)
+	}
+	if len(records) != 5 {
+		t.Errorf("writeTournamentCSV() wrote %d records; want a header and 4 games", len(records))
+	}
+}
+
+func TestUpdateElo(t *testing.T) {
+	type test struct {
+		name                 string
+		first, second, score float64
+		wantFirst            float64
+	}
+	tests := []test{
+		{name: "equal ratings, first wins", first: 1500, second: 1500, score: 1, wantFirst: 1516},
+		{name: "equal ratings, draw", first: 1500, second: 1500, score: 0.5, wantFirst: 1500},
+		{name: "stronger player loses", first: 1700, second: 1500, score: 0, wantFirst: 1675.69},
+	}
+	for _, tc := range tests {
+		gotFirst, gotSecond := updateElo(tc.first, tc.second, tc.score)
+		if math.Abs(gotFirst-tc.wantFirst) > 0.01 {
+			t.Errorf("updateElo(), name:%s, first = %.2f; want %.2f", tc.name, gotFirst, tc.wantFirst)
+		}
+		if math.Abs(gotFirst+gotSecond-tc.first-tc.second) > 0.0001 {
+			t.Errorf("updateElo(), name:%s, ratings are not conserved", tc.name)
+		}
+	}
+}
+
+func TestTournamentConfigValidate(t *testing.T) {
+	type test struct {
+		name     string
+		variants []*AIVariant
+		wantErr  bool
+	}
+	tests := []test{
+		{name: "distinct variants", variants: []*AIVariant{DefaultAIVariant, AIVariants[1]}, wantErr: false},
+		{name: "every built-in variant", variants: AIVariants, wantErr: false},
+		{name: "no variants", variants: nil, wantErr: true},
+		{name: "duplicate variant", variants: []*AIVariant{DefaultAIVariant, AIVariants[1], DefaultAIVariant}, wantErr: true},
+		{name: "variant building nothing", variants: []*AIVariant{DefaultAIVariant, {Name: "idle"}}, wantErr: true},
+		{name: "negative weight", variants: []*AIVariant{{Name: "negative", ConqueredCoastal: []unitWeight{{Tank, 1}, {Fighter, -1}},
+			ConqueredInlandManyTanks: []unitWeight{{Tank, 1}}, ConqueredInland: []unitWeight{{Tank, 1}},
+			CoastalManyTanks: []unitWeight{{Tank, 1}}, Coastal: []unitWeight{{Tank, 1}}, Inland: []unitWeight{{Tank, 1}}}}, wantErr: true},
+	}
+	for _, tc := range tests {
+		err := TournamentConfig{Variants: tc.variants}.Validate()
+		if got := err != nil; got != tc.wantErr {
+			t.Errorf("Validate(), name:%s, error = %v; want error %v", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestRunTournamentCommandMapFlags(t *testing.T) {
+	args := []string{"-variants", "default,land", "-games", "1", "-maxDays", "5", "-workers", "1",
+		"-rows", "20", "-columns", "30", "-map", "continents", "-terrain"}
+	if err := runTournamentCommand(args); err != nil {
+		t.Errorf("runTournamentCommand(%v) error = %v", args, err)
+	}
+}
+
+func TestRunTournamentUnplayableMap(t *testing.T) {
+	config := TournamentConfig{
+		Variants: []*AIVariant{DefaultAIVariant, AIVariants[1]},
+		Games:    1,
+		Seed:     42,
+		MaxDays:  30,
+		Workers:  2,
+		Rows:     10,
+		Columns:  20,
+		Islands:  4,
+		Cities:   150, // more cities than the land can hold, whatever the seed
+	}
+	report := runTournament(config)
+
+	if report.Unplayed != 2 {
+		t.Errorf("runTournament() unplayed = %d; want 2", report.Unplayed)
+	}
+	for _, result := range report.Games {
+		if result.Error == "" {
+			t.Errorf("runTournament() game %d error is empty; want the map error", result.Seed)
+		}
+	}
+	for _, s := range report.Variants {
+		if s.Games != 0 {
+			t.Errorf("runTournament() variant %s played %d games; want 0", s.Name, s.Games)
+		}
+	}
+}
@@ -0,0 +1,635 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// ANSI escape sequences used by the terminal UI.
+const (
+	ansiReset      = "\x1b[0m"
+	ansiHome       = "\x1b[H"
+	ansiClear      = "\x1b[2J"
+	ansiClearLine  = "\x1b[K"
+	ansiClearBelow = "\x1b[J"
+	ansiHideCursor = "\x1b[?25l"
+	ansiShowCursor = "\x1b[?25h"
+	ansiAltScreen  = "\x1b[?1049h"
+	ansiMainScreen = "\x1b[?1049l"
+)
+
+// Colours of the terminal UI, as ANSI SGR parameters.
+const (
+	colourSea      = "44;36"  // cyan on blue
+	colourLand     = "42;32"  // green on green
+	colourFog      = "100;37" // white on grey
+	colourMountain = "43;30"  // black on brown
+	colourForest   = "42;30"  // black on green
+	colourShallows = "46;34"  // blue on cyan
+	colourIce      = "47;36"  // cyan on white
+	colourNeutral  = "1;97"   // bold bright white
+	colourPlayer1  = "1;93"   // bold bright yellow
+	colourPlayer2  = "1;95"   // bold bright magenta
+	colourCursor   = "7"      // reverse video
+	colourStack    = "4"      // underlined, for cells with more than one unit
+	colourVeteran  = "3"      // italic, for veteran and elite units
+	colourNoSupply = "5"      // blinking, for land units out of supply in the supply overlay
+	colourWeather  = "97"     // bright white, for storms and fog banks
+)
+
+const (
+	tuiSidebarWidth = 28 // columns of the sidebar, right of the map
+	tuiLogLines     = 3  // lines of game messages below the map
+	tuiMaxLog       = 100
+)
+
+// errTUIQuit is returned by a TUIController when the player quits the game.
+var errTUIQuit = errors.New("player quit")
+
+// tuiLookKeys maps keys to moves of the cursor.
+var tuiLookKeys = map[string]Coordinate{
+	"up": {-1, 0}, "down": {1, 0}, "left": {0, -1}, "right": {0, 1},
+	"k": {-1, 0}, "j": {1, 0}, "h": {0, -1}, "l": {0, 1},
+}
+
+// TUI is a full-screen terminal user interface showing the board from one player's side.
+// The map scrolls to keep the cursor in view, and a sidebar describes the cell under the cursor.
+type TUI struct {
+	Board      *GameBoard
+	Player     int        // the player whose fog of war is shown, 0 to show the whole board
+	Cursor     Coordinate // the cell described in the sidebar
+	SelectedID int        // ID of the unit being given orders, 0 for none
+	Width      int        // columns of the terminal
+	Height     int        // rows of the terminal
+	Prompt     string     // shown on the last line
+	Help       []string   // sidebar lines explaining the keys, the game's keys when nil
+	Supply     bool       // overlay the shown player's supply network
+
+	top, left int      // cell shown in the top left corner of the map
+	network   [][]bool // the supply network of the overlay, nil when not shown
+	log       []string
+	in        io.Reader
+	keys      *bufio.Reader
+	out       io.Writer
+	restore   func()
+	signals   chan os.Signal // interrupts which stop the UI before the process exits
+	stopping  sync.Mutex
+}
+
+// NewTUI creates a terminal UI for the board, reading keys from in and drawing to out.
+func NewTUI(board *GameBoard, in io.Reader, out io.Writer) *TUI {
+	t := &TUI{
+		Board:  board,
+		Width:  80,
+		Height: 24,
+		in:     in,
+		keys:   bufio.NewReader(in),
+		out:    out,
+	}
+	if f, ok := out.(*os.File); ok {
+		if width, height, ok := terminalSize(f.Fd()); ok {
+			t.Width, t.Height = width, height
+		}
+	}
+	return t
+}
+
+// Start switches the terminal to the full-screen UI, reading keys as they are pressed where
+// the platform allows it.
+func (t *TUI) Start() {
+	if f, ok := t.in.(*os.File); ok {
+		if restore, err := makeRawTerminal(f.Fd()); err == nil {
+			t.restore = restore
+		}
+	}
+	fmt.Fprint(t.out, ansiAltScreen+ansiHideCursor+ansiClear)
+	// Ctrl+C or a kill would otherwise leave the terminal in raw mode on the alternate screen
+	t.signals = make(chan os.Signal, 1)
+	signal.Notify(t.signals, os.Interrupt, syscall.SIGTERM)
+	go func(signals chan os.Signal) {
+		if _, ok := <-signals; ok {
+			t.Stop()
+			os.Exit(1)
+		}
+	}(t.signals)
+}
+
+// Stop restores the terminal.
+func (t *TUI) Stop() {
+	t.stopping.Lock()
+	defer t.stopping.Unlock()
+	if t.signals != nil {
+		signal.Stop(t.signals)
+		close(t.signals)
+		t.signals = nil
+	}
+	fmt.Fprint(t.out, ansiReset+ansiShowCursor+ansiMainScreen)
+	if t.restore != nil {
+		t.restore()
+		t.restore = nil
+	}
+}
+
+// Write implements io.Writer, adding game messages to the log below the map.
+func (t *TUI) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			t.log = append(t.log, line)
+		}
+	}
+	if len(t.log) > tuiMaxLog {
+		t.log = t.log[len(t.log)-tuiMaxLog:]
+	}
+	return len(p), nil
+}
+
+// viewSize returns the rows and columns of the board shown at once.
+func (t *TUI) viewSize() (int, int) {
+	rows := t.Height - tuiLogLines - 1
+	columns := t.Width - tuiSidebarWidth - 1
+	if rows > t.Board.Rows {
+		rows = t.Board.Rows
+	}
+	if columns > t.Board.Columns {
+		columns = t.Board.Columns
+	}
+	if rows < 1 {
+		rows = 1
+	}
+	if columns < 1 {
+		columns = 1
+	}
+	return rows, columns
+}
+
+// scrollToCursor moves the viewport so that the cursor is in view.
+func (t *TUI) scrollToCursor() {
+	rows, columns := t.viewSize()
+	t.top = scrollToShow(t.top, t.Cursor.PositionX, rows, t.Board.Rows)
+	t.left = scrollToShow(t.left, t.Cursor.PositionY, columns, t.Board.Columns)
+}
+
+// scrollToShow returns the first index of a window of size cells out of total cells,
+// moved as little as possible from first to show index.
+func scrollToShow(first, index, size, total int) int {
+	if index < first {
+		first = index
+	} else if index >= first+size {
+		first = index - size + 1
+	}
+	if first > total-size {
+		first = total - size
+	}
+	if first < 0 {
+		first = 0
+	}
+	return first
+}
+
+// moveCursor moves the cursor, keeping it on the board.
+func (t *TUI) moveCursor(direction Coordinate) {
+	x := t.Cursor.PositionX + direction.PositionX
+	y := t.Cursor.PositionY + direction.PositionY
+	if x >= 0 && x < t.Board.Rows && y >= 0 && y < t.Board.Columns {
+		t.Cursor = Coordinate{x, y}
+	}
+}
+
+// Render draws the screen.
+func (t *TUI) Render() {
+	t.scrollToCursor()
+	rows, columns := t.viewSize()
+	sidebar := t.sidebar()
+	t.network = nil
+	if t.Supply && t.Player != 0 {
+		t.network = t.Board.getSupplyNetwork(t.Player)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(ansiHome)
+	for r := 0; r < t.Height-tuiLogLines-1; r++ {
+		for c := 0; c < columns; c++ {
+			x, y := t.top+r, t.left+c
+			if r < rows && x < t.Board.Rows && y < t.Board.Columns {
+				sb.WriteString(t.renderCell(x, y))
+			} else {
+				sb.WriteString(ansiReset + " ")
+			}
+		}
+		sb.WriteString(ansiReset + " ")
+		if r < len(sidebar) {
+			sb.WriteString(sidebar[r])
+		}
+		sb.WriteString(ansiClearLine + "\n")
+	}
+	for i := len(t.log) - tuiLogLines; i < len(t.log); i++ {
+		if i >= 0 {
+			sb.WriteString(truncate(t.log[i], t.Width))
+		}
+		sb.WriteString(ansiClearLine + "\n")
+	}
+	sb.WriteString(truncate(t.Prompt, t.Width) + ansiClearLine + ansiClearBelow)
+	fmt.Fprint(t.out, sb.String())
+}
+
+// renderCell returns the coloured symbol of a cell.
+func (t *TUI) renderCell(x, y int) string {
+	cell := t.Board.Grid[x][y]
+	style, symbol := colourSea, "~"
+	if t.isFog(x, y) {
+		style, symbol = colourFog, "?"
+	} else {
+		switch {
+		case cell.Terrain == TerrainMountains:
+			style, symbol = colourMountain, "^"
+		case cell.Terrain == TerrainForest:
+			style, symbol = colourForest, "%"
+		case cell.Terrain == TerrainShallows:
+			style, symbol = colourShallows, "~"
+		case cell.Terrain == TerrainIce:
+			style, symbol = colourIce, "*"
+		case cell.IsLand:
+			style, symbol = colourLand, " "
+		}
+		background := strings.Split(style, ";")[0]
+		if unit := t.visibleUnitAt(Coordinate{x, y}); unit != nil {
+			style, symbol = background+";"+playerColour(unit.Player), t.Board.unitRules().symbol(unit.Type)
+			if unit.Rank() > RankRecruit {
+				style += ";" + colourVeteran
+			}
+			if t.network != nil && unit.Player == t.Player && isLandUnit(unit) && !isSupplied(unit, t.network) {
+				style += ";" + colourNoSupply
+			}
+		} else if city := t.Board.getCityAtCoordinates(Coordinate{x, y}); city != nil {
+			style, symbol = background+";"+playerColour(int(city.OccupyingPlayer)), "C"
+		} else if t.network != nil && t.network[x][y] {
+			style, symbol = background+";"+playerColour(t.Player), "+"
+		} else if t.Board.isStorm(Coordinate{x, y}) {
+			style, symbol = background+";"+colourWeather, "/"
+		} else if t.Board.isFogBank(Coordinate{x, y}) {
+			style, symbol = background+";"+colourWeather, ":"
+		}
+	}
+	if len(t.visibleUnitsAt(Coordinate{x, y})) > 1 {
+		style += ";" + colourStack
+	}
+	if t.Cursor == (Coordinate{x, y}) {
+		style += ";" + colourCursor
+	}
+	return "\x1b[" + style + "m" + symbol
+}
+
+// playerColour returns the colour of a player's units and cities, or of neutral cities.
+func playerColour(player int) string {
+	switch player {
+	case 1:
+		return colourPlayer1
+	case 2:
+		return colourPlayer2
+	default:
+		return colourNeutral
+	}
+}
+
+// isFog checks if the cell is hidden from the player shown.
+func (t *TUI) isFog(x, y int) bool {
+	return t.Player != 0 && t.Board.isFogForPlayer(t.Player, x, y)
+}
+
+// visibleUnitsAt returns the units at the coordinate the shown player can see.
+func (t *TUI) visibleUnitsAt(coordinate Coordinate) []Unit {
+	return t.Board.visibleUnitsAt(t.Player, coordinate)
+}
+
+// visibleUnitAt returns the unit shown at the coordinate: the selected unit, else the shown
+// player's own unit, else an enemy unit in sight.
+func (t *TUI) visibleUnitAt(coordinate Coordinate) *Unit {
+	var shown *Unit
+	for i := range t.Board.Units {
+		unit := &t.Board.Units[i]
+		if unit.PositionX != coordinate.PositionX || unit.PositionY != coordinate.PositionY {
+			continue
+		}
+		if unit.ID != 0 && unit.ID == t.SelectedID {
+			return unit
+		}
+		if t.Player == 0 || unit.Player == t.Player {
+			if shown == nil || shown.Player != t.Player {
+				shown = unit
+			}
+		} else if shown == nil && t.Board.isUnitInSightOfPlayer(t.Player, unit) {
+			shown = unit
+		}
+	}
+	return shown
+}
+
+// sidebar returns the lines of the sidebar, describing the selected unit and the cell under the cursor.
+func (t *TUI) sidebar() []string {
+	g := t.Board
+	lines := []string{fmt.Sprintf("Day %d", g.Day)}
+	if t.Player != 0 {
+		lines[0] += fmt.Sprintf(", player %d", t.Player)
+	}
+	if g.rules().Weather {
+		lines = append(lines, g.getWeather().describe())
+	}
+	if selected := g.getUnitByID(t.SelectedID); t.SelectedID != 0 && selected != nil {
+		lines = append(lines, "", "Selected:")
+		lines = append(lines, g.describeUnit(selected)...)
+	}
+
+	x, y := t.Cursor.PositionX, t.Cursor.PositionY
+	terrain := terrainToString(g.Grid[x][y].IsLand, g.Grid[x][y].Terrain)
+	if t.isFog(x, y) {
+		terrain = "unexplored"
+	}
+	lines = append(lines, "", fmt.Sprintf("(%d, %d) %s", x, y, terrain))
+	if !t.isFog(x, y) {
+		for _, unit := range t.visibleUnitsAt(t.Cursor) {
+			if unit.ID != t.SelectedID {
+				lines = append(lines, g.describeUnit(&unit)...)
+			}
+		}
+		if city := g.getCityAtCoordinates(t.Cursor); city != nil {
+			lines = append(lines, g.describeCity(city, t.Player)...)
+		}
+	}
+
+	help := t.Help
+	if help == nil {
+		help = []string{"arrows/hjkl look around", "qweadzxc move, s hold", "f find unit, Q quit", "v supply overlay", "underlined: several units", "italic: veterans"}
+	}
+	lines = append(append(lines, ""), help...)
+	for i, line := range lines {
+		lines[i] = fmt.Sprintf("%-*s", tuiSidebarWidth, truncate(line, tuiSidebarWidth))
+	}
+	return lines
+}
+
+// describeUnit returns sidebar lines describing a unit.
+func (g *GameBoard) describeUnit(unit *Unit) []string {
+	lines := []string{
+		fmt.Sprintf("%s #%d, player %d", g.unitTypeToString(unit.Type), unit.ID, unit.Player),
+		fmt.Sprintf("strength %d", unit.Strength),
+		fmt.Sprintf("moves %d/%d", unit.MovesLeftThisDay, g.getMovesPerDay(unit)),
+		fmt.Sprintf("%s, %d kills in %d battles", rankToString(unit.Rank()), unit.Kills, unit.Battles),
+	}
+	if unit.CanFly {
+		lines = append(lines, fmt.Sprintf("fuel %d", unit.Fuel))
+	}
+	return lines
+}
+
+// describeCity returns sidebar lines describing a city; production is only shown to its owner.
+func (g *GameBoard) describeCity(city *City, player int) []string {
+	owner := "neutral"
+	if city.OccupyingPlayer != Unoccupied {
+		owner = fmt.Sprintf("player %d", city.OccupyingPlayer)
+	}
+	lines := []string{fmt.Sprintf("City, %s", owner), fmt.Sprintf("strength %d", city.Strength)}
+	if city.OccupyingPlayer != Unoccupied && (player == 0 || int(city.OccupyingPlayer) == player) {
+		lines = append(lines, fmt.Sprintf("producing %s", g.unitTypeToString(city.ManufacturingUnit)))
+		if city.ManufacturingUnit != Blank {
+			lines = append(lines, fmt.Sprintf("ready in %d days", city.DaysUntilUnitReady))
+		}
+		for _, unit := range city.Queue {
+			lines = append(lines, fmt.Sprintf("then %s", g.unitTypeToString(unit)))
+		}
+		if city.BuildOnce {
+			lines = append(lines, "once")
+		}
+		if city.RallyPoint != nil {
+			lines = append(lines, fmt.Sprintf("rally at (%d, %d)", city.RallyPoint.PositionX, city.RallyPoint.PositionY))
+		}
+		lines = append(lines, fmt.Sprintf("level %d", city.Level()))
+	}
+	return lines
+}
+
+// truncate cuts s to at most width characters.
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+// readKey reads a key press: a character, or "up", "down", "left", "right", "esc" or "tab".
+// Line ends are skipped, so keys can also be typed followed by enter where the terminal
+// cannot be switched to raw mode.
+func (t *TUI) readKey() (string, error) {
+	for {
+		b, err := t.keys.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch b {
+		case '\r', '\n':
+			continue
+		case '\t':
+			return "tab", nil
+		case 0x1b:
+			if t.keys.Buffered() == 0 {
+				return "esc", nil
+			}
+			if next, _ := t.keys.ReadByte(); next != '[' && next != 'O' {
+				return "esc", nil
+			}
+			code, err := t.keys.ReadByte()
+			if err != nil {
+				return "", err
+			}
+			switch code {
+			case 'A':
+				return "up", nil
+			case 'B':
+				return "down", nil
+			case 'C':
+				return "right", nil
+			case 'D':
+				return "left", nil
+			}
+			return "esc", nil
+		default:
+			return string(b), nil
+		}
+	}
+}
+
+// Browse lets the player look around the board until they press q.
+func (t *TUI) Browse(prompt string) {
+	t.SelectedID = 0
+	t.Prompt = prompt
+	for {
+		t.Render()
+		key, err := t.readKey()
+		if err != nil || key == "q" || key == "Q" || key == "esc" {
+			return
+		}
+		if direction, ok := tuiLookKeys[key]; ok {
+			t.moveCursor(direction)
+		}
+	}
+}
+
+// TUIController is a Controller for a person playing in the full-screen terminal UI.
+type TUIController struct {
+	UI *TUI
+}
+
+// NewTUIController creates a controller asking for decisions in the terminal UI.
+func NewTUIController(ui *TUI) *TUIController {
+	return &TUIController{UI: ui}
+}
+
+// BeginTurn implements Controller.
+func (c *TUIController) BeginTurn(g *GameBoard, player int) error {
+	c.UI.Board = g
+	c.UI.Player = player
+	g.updateFogOfWarForPlayer(player)
+	fmt.Fprintf(c.UI, "Day %d, player %d's turn\n", g.Day, player)
+	return nil
+}
+
+// ChooseProduction implements Controller.
+func (c *TUIController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	ui := c.UI
+	ui.Board = g
+	ui.SelectedID = 0
+	ui.Cursor = Coordinate{city.PositionX, city.PositionY}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "City (%d, %d) builds:", city.PositionX, city.PositionY)
+	for _, unitType := range g.getProductionChoices(city) {
+		fmt.Fprintf(&sb, " %s %s", g.unitRules().definition(unitType).Symbol, g.unitTypeToString(unitType))
+	}
+	ui.Prompt = sb.String()
+	for {
+		ui.Render()
+		key, err := ui.readKey()
+		if err != nil {
+			return Blank
+		}
+		if len(key) == 1 && key[0] >= '1' && key[0] <= '9' && g.canCityBuild(city, UnitType(key[0]-'0')) {
+			return UnitType(key[0] - '0')
+		}
+		if unitType, ok := g.unitRules().typeFromSymbol(key); ok && g.canCityBuild(city, unitType) {
+			return unitType
+		}
+		if direction, ok := tuiLookKeys[key]; ok {
+			ui.moveCursor(direction)
+		}
+	}
+}
+
+// ChooseMove implements Controller.
+func (c *TUIController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	ui := c.UI
+	ui.Board = g
+	ui.SelectedID = unit.ID
+	ui.Cursor = Coordinate{unit.PositionX, unit.PositionY}
+	prompt := fmt.Sprintf("%s #%d at (%d, %d): qweadzxc move, s hold, p production, r rally point",
+		g.unitTypeToString(unit.Type), unit.ID, unit.PositionX, unit.PositionY)
+	for {
+		ui.Prompt = prompt
+		ui.Render()
+		key, err := ui.readKey()
+		if err != nil {
+			return Coordinate{}, false, err
+		}
+		if direction, ok := humanDirections[key]; ok {
+			move := Coordinate{unit.PositionX + direction.PositionX, unit.PositionY + direction.PositionY}
+			if g.isLegalMove(move, unit) {
+				return move, true, nil
+			}
+			fmt.Fprintf(ui, "%s cannot move to (%d, %d)\n", g.unitTypeToString(unit.Type), move.PositionX, move.PositionY)
+			continue
+		}
+		if direction, ok := tuiLookKeys[key]; ok {
+			ui.moveCursor(direction)
+			continue
+		}
+		switch key {
+		case "s", " ":
+			return Coordinate{}, false, nil
+		case "f":
+			ui.Cursor = Coordinate{unit.PositionX, unit.PositionY}
+		case "v":
+			ui.Supply = !ui.Supply
+		case "p":
+			if err := c.changeProduction(g, unit.Player); err != nil {
+				fmt.Fprintln(ui, err)
+			}
+		case "r":
+			if err := c.changeRallyPoint(g, unit.Player); err != nil {
+				fmt.Fprintln(ui, err)
+			}
+		case "Q":
+			return Coordinate{}, false, errTUIQuit
+		}
+	}
+}
+
+// changeProduction asks what the player's city under the cursor should manufacture, and
+// whether once or over and over.
+func (c *TUIController) changeProduction(g *GameBoard, player int) error {
+	ui := c.UI
+	coordinate := ui.Cursor
+	city, err := g.getPlayersCity(player, coordinate)
+	if err != nil {
+		return err
+	}
+	selectedID := ui.SelectedID
+	defer func() { ui.SelectedID = selectedID }()
+	unitType := c.ChooseProduction(g, city)
+	ui.Cursor = coordinate
+	ui.Prompt = fmt.Sprintf("City (%d, %d) builds %s: r over and over, o once", coordinate.PositionX, coordinate.PositionY, g.unitTypeToString(unitType))
+	for {
+		ui.Render()
+		key, err := ui.readKey()
+		if err != nil {
+			return err
+		}
+		if key == "r" || key == "o" {
+			return g.SetCityProduction(player, coordinate, unitType, key == "o")
+		}
+	}
+}
+
+// changeRallyPoint lets the player move the cursor from their city under it to where the units
+// the city produces should head.
+func (c *TUIController) changeRallyPoint(g *GameBoard, player int) error {
+	ui := c.UI
+	coordinate := ui.Cursor
+	if _, err := g.getPlayersCity(player, coordinate); err != nil {
+		return err
+	}
+	ui.Prompt = fmt.Sprintf("City (%d, %d) rally point: move the cursor, r set, x clear, esc cancel", coordinate.PositionX, coordinate.PositionY)
+	for {
+		ui.Render()
+		key, err := ui.readKey()
+		if err != nil {
+			return err
+		}
+		if direction, ok := tuiLookKeys[key]; ok {
+			ui.moveCursor(direction)
+			continue
+		}
+		switch key {
+		case "r":
+			rallyPoint := ui.Cursor
+			return g.SetRallyPoint(player, coordinate, &rallyPoint)
+		case "x":
+			return g.SetRallyPoint(player, coordinate, nil)
+		case "esc":
+			return nil
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScrollToShow(t *testing.T) {
+	type test struct {
+		name                      string
+		first, index, size, total int
+		want                      int
+	}
+	tests := []test{
+		{name: "in view", first: 0, index: 3, size: 5, total: 20, want: 0},
+		{name: "past the end of the view", first: 0, index: 7, size: 5, total: 20, want: 3},
+		{name: "before the view", first: 10, index: 4, size: 5, total: 20, want: 4},
+		{name: "last cell", first: 0, index: 19, size: 5, total: 20, want: 15},
+		{name: "board smaller than the view", first: 3, index: 2, size: 10, total: 4, want: 0},
+	}
+	for _, tc := range tests {
+		if got := scrollToShow(tc.first, tc.index, tc.size, tc.total); got != tc.want {
+			t.Errorf("scrollToShow(), name:%s, got %d; want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestTUIRender(t *testing.T) {
+	board := newLandBoard(20, 40)
+	board.addUnit(NewUnit(19, 38, Tank, 2))
+	var out bytes.Buffer
+	ui := NewTUI(board, strings.NewReader(""), &out)
+	ui.Width, ui.Height = 40, 10
+	ui.Cursor = Coordinate{19, 39}
+	ui.Render()
+
+	rows, columns := ui.viewSize()
+	if rows != 6 || columns != 11 {
+		t.Fatalf("viewSize() = %d, %d; want 6, 11", rows, columns)
+	}
+	if ui.top != 14 || ui.left != 29 {
+		t.Errorf("Render() viewport at (%d, %d); want (14, 29) to show the cursor", ui.top, ui.left)
+	}
+	screen := out.String()
+	if !strings.Contains(screen, "\x1b[42;"+colourPlayer2+"mT") {
+		t.Errorf("Render() does not show player 2's tank in player 2's colour: %q", screen)
+	}
+	if !strings.Contains(screen, "\x1b[42;"+colourPlayer2+";"+colourCursor+"mC") {
+		t.Errorf("Render() does not show player 2's city under the cursor: %q", screen)
+	}
+	if !strings.Contains(screen, "City, player 2") {
+		t.Errorf("Render() sidebar does not describe the city under the cursor: %q", screen)
+	}
+	if strings.Count(screen, "\n") != ui.Height-1 {
+		t.Errorf("Render() drew %d lines; want %d", strings.Count(screen, "\n")+1, ui.Height)
+	}
+}
+
+func TestTUIRenderFog(t *testing.T) {
+	board := newLandBoard(3, 3)
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(2, 0, Tank, 2))
+	board.updateFogOfWarForPlayer(1)
+	ui := NewTUI(board, strings.NewReader(""), io.Discard)
+	ui.Player = 1
+
+	if ui.isFog(0, 1) {
+		t.Errorf("isFog(0, 1) = true; want the cell next to player 1's tank to be seen")
+	}
+	if unit := ui.visibleUnitAt(Coordinate{2, 0}); unit != nil {
+		t.Errorf("visibleUnitAt(2, 0) = %+v; want the enemy tank out of sight", unit)
+	}
+	if unit := ui.visibleUnitAt(Coordinate{0, 0}); unit == nil || unit.Player != 1 {
+		t.Errorf("visibleUnitAt(0, 0) = %+v; want player 1's tank", unit)
+	}
+}
+
+func TestTUIReadKey(t *testing.T) {
+	ui := NewTUI(newLandBoard(2, 2), strings.NewReader("\x1b[Ax\n\x1b[D\t"), io.Discard)
+	var got []string
+	for {
+		key, err := ui.readKey()
+		if err != nil {
+			break
+		}
+		got = append(got, key)
+	}
+	want := []string{"up", "x", "left", "tab"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("readKey() = %v; want %v", got, want)
+	}
+}
+
+func TestTUIControllerChooseMove(t *testing.T) {
+	type test struct {
+		name     string
+		input    string
+		wantMove Coordinate
+		wantOK   bool
+		wantErr  bool
+	}
+	tests := []test{
+		{name: "look around then move east", input: "jjld", wantMove: Coordinate{1, 2}, wantOK: true},
+		{name: "illegal move then move north", input: "zw", wantMove: Coordinate{0, 1}, wantOK: true},
+		{name: "hold", input: "s", wantOK: false},
+		{name: "quit", input: "Q", wantErr: true},
+		{name: "end of input", input: "", wantErr: true},
+	}
+	for _, tc := range tests {
+		board := newLandBoard(3, 3)
+		board.Grid[2][0].IsLand = false
+		board.addUnit(NewUnit(1, 1, Tank, 1))
+		ui := NewTUI(board, strings.NewReader(tc.input), io.Discard)
+		controller := NewTUIController(ui)
+		move, ok, err := controller.ChooseMove(board, &board.Units[0])
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ChooseMove(), name:%s, error = %v; want error %t", tc.name, err, tc.wantErr)
+			continue
+		}
+		if ok != tc.wantOK || (ok && move != tc.wantMove) {
+			t.Errorf("ChooseMove(), name:%s, got %v, %t; want %v, %t", tc.name, move, ok, tc.wantMove, tc.wantOK)
+		}
+	}
+}
+
+func TestTUIControllerChooseProduction(t *testing.T) {
+	board := newLandBoard(3, 3)
+	ui := NewTUI(board, strings.NewReader("x03"), io.Discard)
+	controller := NewTUIController(ui)
+	if got := controller.ChooseProduction(board, &board.Cities[0]); got != Bomber {
+		t.Errorf("ChooseProduction() = %s; want Bomber", unitTypeToString(got))
+	}
+	// unit types beyond 9 are chosen by their symbols
+	ui = NewTUI(board, strings.NewReader("xM"), io.Discard)
+	controller = NewTUIController(ui)
+	if got := controller.ChooseProduction(board, &board.Cities[0]); got != CruiseMissile {
+		t.Errorf("ChooseProduction() by symbol = %s; want CruiseMissile", unitTypeToString(got))
+	}
+}
+
+func TestTUIStop(t *testing.T) {
+	var out bytes.Buffer
+	ui := NewTUI(newLandBoard(4, 4), strings.NewReader(""), &out)
+	ui.Start()
+	ui.Stop()
+	ui.Stop() // stopping again, as after an interrupt, must do no harm
+	if ui.signals != nil {
+		t.Errorf("Stop() left the interrupt handler installed")
+	}
+	if got := out.String(); !strings.HasSuffix(got, ansiMainScreen) {
+		t.Errorf("Stop() output = %q; want it to end on the main screen", got)
+	}
+}
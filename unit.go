@@ -1,6 +1,7 @@
 package main
 
 // UnitType represents the type of units that can be manufactured in a city.
+// The built-in types are below; a rules file may add more after CruiseMissile.
 type UnitType int
 
 const (
@@ -13,10 +14,15 @@ const (
 	Submarine
 	Carrier
 	Battleship
+	Artillery
+	Paratrooper
+	AntiAircraft
+	CruiseMissile
 )
 
 // Unit struct represents a game unit in the game.
 type Unit struct {
+	ID                 int
 	PositionX          int
 	PositionY          int
 	Type               UnitType
@@ -32,193 +38,70 @@ type Unit struct {
 	AttackRange        int
 	AttacksLeftThisDay int
 	CanCaptureCity     bool
+	Destination        *Coordinate // a rally point the unit heads to before taking orders, or nil
+	Kills              int         // enemy units the unit has destroyed
+	Battles            int         // battles the unit has survived
 }
 
+// NewUnit creates a unit of the type as the classic rules define it.
 func NewUnit(positionX, positionY int, unitType UnitType, player int) *Unit {
-	return &Unit{
-		PositionX:          positionX,
-		PositionY:          positionY,
-		Type:               unitType,
-		Player:             player,
-		Strength:           GetNewUnitStrength(unitType),
-		MovesLeftThisDay:   GetMovesPerDay(unitType),
-		Fuel:               GetFuelPerDay(unitType),
-		CanMoveOnLand:      GetCanMoveOnLand(unitType),
-		CanMoveOnWater:     GetCanMoveOnWater(unitType),
-		CanFly:             GetCanFly(unitType),
-		AttackRange:        GetAttackRange(unitType),
-		AttacksLeftThisDay: GetAttacksPerDay(unitType),
-		CanCaptureCity:     GetCanCaptureCity(unitType),
-	}
+	return defaultRules.unitRules.newUnit(positionX, positionY, unitType, player)
+}
+
+// unitDefinitionOf returns the definition of the unit type under the classic rules, empty for
+// Blank and unknown types.
+func unitDefinitionOf(unitType UnitType) UnitDefinition {
+	return defaultRules.unitRules.definitionOf(unitType)
 }
 
 // GetCanCaptureCity returns whether or not the unit type can capture a city.
 func GetCanCaptureCity(unitType UnitType) bool {
-	switch unitType {
-	case Tank:
-		return true
-	case Fighter, Bomber, Transport, Destroyer, Submarine, Carrier, Battleship:
-		return false
-	default:
-		return false
-	}
+	return unitDefinitionOf(unitType).CanCaptureCity
 }
 
-// GetAttacksPerDay returns the attack range of the unit type.
+// GetAttacksPerDay returns the attacks per day of the unit type.
 func GetAttacksPerDay(unitType UnitType) int {
-	switch unitType {
-	case Tank, Fighter, Bomber, Transport, Destroyer, Submarine, Carrier, Battleship:
-		return 2
-	default:
-		return 2
-	}
+	return unitDefinitionOf(unitType).AttacksPerDay
 }
 
 // GetAttackRange returns the attack range of the unit type.
 func GetAttackRange(unitType UnitType) int {
-	switch unitType {
-	case Tank, Fighter, Bomber, Transport, Destroyer, Submarine, Carrier:
-		return 1
-	case Battleship:
-		return 4
-	default:
-		return 1
-	}
+	return unitDefinitionOf(unitType).AttackRange
 }
 
 // GetCanFly returns whether of not the unit type can fly.
 func GetCanFly(unitType UnitType) bool {
-	switch unitType {
-	case Tank, Transport, Destroyer, Submarine, Carrier, Battleship:
-		return false
-	case Fighter, Bomber:
-		return true
-	default:
-		return false
-	}
+	return unitDefinitionOf(unitType).CanFly
 }
 
 // GetCanMoveOnWater returns whether of not the unit type can move on water.
 func GetCanMoveOnWater(unitType UnitType) bool {
-	switch unitType {
-	case Tank:
-		return false
-	case Fighter:
-		return true
-	case Bomber:
-		return true
-	case Transport:
-		return true
-	case Destroyer:
-		return true
-	case Submarine:
-		return true
-	case Carrier:
-		return true
-	case Battleship:
-		return true
-	default:
-		return false
-	}
+	return unitDefinitionOf(unitType).CanMoveOnWater
 }
 
 // GetCanMoveOnLand returns whether of not the unit type can move on land.
 func GetCanMoveOnLand(unitType UnitType) bool {
-	switch unitType {
-	case Tank:
-		return true
-	case Fighter:
-		return true
-	case Bomber:
-		return true
-	case Transport:
-		return false
-	case Destroyer:
-		return false
-	case Submarine:
-		return false
-	case Carrier:
-		return false
-	case Battleship:
-		return false
-	default:
-		return false
-	}
+	return unitDefinitionOf(unitType).CanMoveOnLand
 }
 
 // GetMovesPerDay returns the number of moves per day for a given unit type.
 func GetMovesPerDay(unitType UnitType) int {
-	switch unitType {
-	case Tank:
-		return 2
-	case Fighter:
-		return 20
-	case Bomber:
-		return 10
-	case Transport, Submarine, Carrier, Battleship:
-		return 3
-	case Destroyer:
-		return 4
-	}
-	return 0
+	return unitDefinitionOf(unitType).MovesPerDay
 }
 
 // GetFuelPerDay returns the amount of fuel per day for a given unit type.
 func GetFuelPerDay(unitType UnitType) int {
-	switch unitType {
-	case Fighter:
-		return 20
-	case Bomber:
-		return 30
-	default:
-		return 0
-	}
+	return unitDefinitionOf(unitType).Fuel
 }
 
 // GetNewUnitStrength gets the strength of a new unit.
 func GetNewUnitStrength(unitType UnitType) int {
-	switch unitType {
-	case Tank:
-		return 2
-	case Fighter:
-		return 1
-	case Bomber:
-		return 1
-	case Transport:
-		return 3
-	case Destroyer:
-		return 3
-	case Submarine:
-		return 3
-	case Carrier:
-		return 12
-	case Battleship:
-		return 18
-	}
-	return 0
+	return unitDefinitionOf(unitType).Strength
 }
 
 // GetDaysToProduceUnit gets the number of days to produce a unit.
 func GetDaysToProduceUnit(unitType UnitType) int {
-	switch unitType {
-	case Tank:
-		return 4
-	case Fighter:
-		return 6
-	case Bomber:
-		return 25
-	case Transport:
-		return 8
-	case Destroyer:
-		return 8
-	case Submarine:
-		return 8
-	case Carrier:
-		return 10
-	case Battleship:
-		return 20
-	}
-	return 0
+	return unitDefinitionOf(unitType).DaysToProduce
 }
 
 // MoveTo updates the unit's position on the board, reduces MovesLeftThisDay, and if applicable, reduces Fuel
@@ -232,51 +115,16 @@ func (u *Unit) MoveTo(coordinate Coordinate) {
 	}
 }
 
-// Symbol returns a character depending on the unit type
+// Symbol returns a character depending on the unit type, as the classic rules define it
 func (u *Unit) Symbol() string {
-	switch u.Type {
-	case Tank:
-		return "T"
-	case Fighter:
-		return "F"
-	case Bomber:
-		return "B"
-	case Transport:
-		return "R"
-	case Destroyer:
-		return "D"
-	case Submarine:
-		return "S"
-	case Carrier:
-		return "C"
-	case Battleship:
-		return "L"
-	default:
-		return "?"
-	}
+	return defaultRules.unitRules.symbol(u.Type)
 }
 
 func unitTypeToString(unitType UnitType) string {
-	switch unitType {
-	case Blank:
-		return "Blank"
-	case Tank:
-		return "Tank"
-	case Fighter:
-		return "Fighter"
-	case Bomber:
-		return "Bomber"
-	case Transport:
-		return "Transport"
-	case Destroyer:
-		return "Destroyer"
-	case Submarine:
-		return "Submarine"
-	case Carrier:
-		return "Carrier"
-	case Battleship:
-		return "Battleship"
-	default:
-		return "Unknown"
-	}
+	return defaultRules.unitRules.typeToString(unitType)
+}
+
+// unitTypeFromString returns the unit type with the given name under the classic rules.
+func unitTypeFromString(name string) (UnitType, bool) {
+	return defaultRules.unitRules.typeFromString(name)
 }
@@ -0,0 +1,320 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// defaultUnitRulesJSON is the rules file defining the standard unit types.
+//
+//go:embed units.json
+var defaultUnitRulesJSON []byte
+
+// UnitDefinition describes a unit type: how strong it is, how it moves and fights, and how
+// long a city takes to manufacture it.
+type UnitDefinition struct {
+	Name           string `json:"name"`
+	Symbol         string `json:"symbol"` // one character, shown on maps
+	Strength       int    `json:"strength"`
+	MovesPerDay    int    `json:"movesPerDay"`
+	Fuel           int    `json:"fuel,omitempty"` // moves an aircraft can make before it must land, 0 for other units
+	DaysToProduce  int    `json:"daysToProduce"`
+	CanMoveOnLand  bool   `json:"canMoveOnLand,omitempty"`
+	CanMoveOnWater bool   `json:"canMoveOnWater,omitempty"`
+	CanFly         bool   `json:"canFly,omitempty"`
+	CanCaptureCity bool   `json:"canCaptureCity,omitempty"`
+	DeepDraft      bool   `json:"deepDraft,omitempty"` // a ship which runs aground in shallows
+	AttackRange    int    `json:"attackRange"`
+	AttacksPerDay  int    `json:"attacksPerDay"`
+
+	RangedFire        bool `json:"rangedFire,omitempty"`        // fires from its attack range under every rule set
+	DropRange         int  `json:"dropRange,omitempty"`         // cells a bomber drops the unit from one of its player's cities, 0 if it cannot be dropped
+	AntiAircraftBonus int  `json:"antiAircraftBonus,omitempty"` // percentage the odds shift its way in fights with aircraft
+	SingleUse         bool `json:"singleUse,omitempty"`         // spent by its first attack on a unit, which does damage of its whole strength
+	BlastRadius       int  `json:"blastRadius,omitempty"`       // cells round its target a bombing run hits, 0 for no bombing runs
+}
+
+// UnitRules is the registry of unit types. The definition of a UnitType is at index
+// UnitType-1, so the built-in types Tank to CruiseMissile come first, in order, and types a
+// rules file adds follow them.
+type UnitRules struct {
+	Units []UnitDefinition `json:"units"`
+}
+
+// mustParseDefaultUnitRules parses the embedded rules file, which must be valid.
+func mustParseDefaultUnitRules() *UnitRules {
+	rules, err := ParseUnitRules(nil, defaultUnitRulesJSON)
+	if err != nil {
+		panic(fmt.Sprintf("default unit rules: %v", err))
+	}
+	return rules
+}
+
+// DefaultUnitRules returns the rules of the standard unit types.
+func DefaultUnitRules() *UnitRules {
+	return mustParseDefaultUnitRules()
+}
+
+// ParseUnitRules parses a rules file on top of base, or from scratch if base is nil. A unit
+// named in base is overridden, only in the fields the file gives; other units are added as new
+// unit types. The rules are validated as a whole.
+func ParseUnitRules(base *UnitRules, data []byte) (*UnitRules, error) {
+	var file struct {
+		Units []json.RawMessage `json:"units"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return overrideUnitRules(base, file.Units)
+}
+
+// overrideUnitRules applies the unit definitions of a rules file to a copy of base, as
+// ParseUnitRules does, and validates the result.
+func overrideUnitRules(base *UnitRules, units []json.RawMessage) (*UnitRules, error) {
+	rules := &UnitRules{}
+	if base != nil {
+		rules.Units = append(rules.Units, base.Units...)
+	}
+	for i, raw := range units {
+		var named struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &named); err != nil {
+			return nil, fmt.Errorf("unit %d: %w", i+1, err)
+		}
+		index := rules.indexOf(named.Name)
+		if index < 0 {
+			rules.Units = append(rules.Units, UnitDefinition{})
+			index = len(rules.Units) - 1
+		}
+		if err := json.Unmarshal(raw, &rules.Units[index]); err != nil {
+			return nil, fmt.Errorf("unit %q: %w", named.Name, err)
+		}
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// LoadUnitRulesFile reads a rules file overriding the unit types of base, which are those of
+// the rule set the file is played with.
+func LoadUnitRulesFile(base *UnitRules, path string) (*UnitRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := ParseUnitRules(base, data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// WriteUnitRules writes the rules as a rules file.
+func (r *UnitRules) WriteUnitRules(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// indexOf returns the index of the named unit, or -1.
+func (r *UnitRules) indexOf(name string) int {
+	for i, unit := range r.Units {
+		if unit.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// builtInUnitNames are the names of the unit types the game refers to by constant, Tank to
+// CruiseMissile, which every rules file must keep in their places.
+var builtInUnitNames = []string{"Tank", "Fighter", "Bomber", "Transport", "Destroyer", "Submarine", "Carrier", "Battleship",
+	"Artillery", "Paratrooper", "AntiAircraft", "CruiseMissile"}
+
+// Validate checks the definitions are complete and consistent.
+func (r *UnitRules) Validate() error {
+	if len(r.Units) < len(builtInUnitNames) {
+		return fmt.Errorf("rules define %d unit types; want at least the %d built-in types", len(r.Units), len(builtInUnitNames))
+	}
+	names := map[string]bool{}
+	symbols := map[string]string{}
+	for i, unit := range r.Units {
+		if i < len(builtInUnitNames) && unit.Name != builtInUnitNames[i] {
+			return fmt.Errorf("unit type %d is %q; want %q", i+1, unit.Name, builtInUnitNames[i])
+		}
+		if err := unit.validate(); err != nil {
+			return fmt.Errorf("unit %q: %w", unit.Name, err)
+		}
+		if names[unit.Name] {
+			return fmt.Errorf("unit %q is defined twice", unit.Name)
+		}
+		names[unit.Name] = true
+		// enemy units are shown in lower case, so symbols differing only in case are the same
+		symbol := strings.ToUpper(unit.Symbol)
+		if other, ok := symbols[symbol]; ok {
+			return fmt.Errorf("units %q and %q have the same symbol %q", other, unit.Name, unit.Symbol)
+		}
+		symbols[symbol] = unit.Name
+	}
+	return nil
+}
+
+// validate checks a definition is complete and consistent in itself.
+func (d UnitDefinition) validate() error {
+	switch {
+	case d.Name == "" || d.Name == "Blank" || d.Name == "Unknown":
+		return errors.New("a unit type needs a name other than Blank or Unknown")
+	case len(d.Symbol) != 1 || d.Symbol == " ":
+		return fmt.Errorf("symbol %q is not one printable character", d.Symbol)
+	case isMapSymbol(d.Symbol):
+		return fmt.Errorf("symbol %q is shown on maps for terrain, cities or fog", d.Symbol)
+	case d.Strength <= 0:
+		return fmt.Errorf("strength %d is not positive", d.Strength)
+	case d.MovesPerDay <= 0:
+		return fmt.Errorf("moves per day %d is not positive", d.MovesPerDay)
+	case d.DaysToProduce <= 0:
+		return fmt.Errorf("days to produce %d is not positive", d.DaysToProduce)
+	case d.AttackRange <= 0:
+		return fmt.Errorf("attack range %d is not positive", d.AttackRange)
+	case d.AttacksPerDay < 0:
+		return fmt.Errorf("attacks per day %d is negative", d.AttacksPerDay)
+	case !d.CanMoveOnLand && !d.CanMoveOnWater && !d.CanFly:
+		return errors.New("the unit cannot move on land, on water or in the air")
+	case d.CanFly && (!d.CanMoveOnLand || !d.CanMoveOnWater):
+		return errors.New("an aircraft flies over both land and water")
+	case d.CanFly && d.Fuel <= 0:
+		return errors.New("an aircraft needs fuel")
+	case !d.CanFly && d.Fuel != 0:
+		return errors.New("only aircraft use fuel")
+	case d.CanCaptureCity && (!d.CanMoveOnLand || d.CanFly):
+		return errors.New("only land units can capture cities")
+	case d.DeepDraft && (!d.CanMoveOnWater || d.CanFly):
+		return errors.New("only ships can run aground")
+	case d.RangedFire && d.AttackRange <= 1:
+		return errors.New("a unit firing from range needs an attack range above 1")
+	case d.DropRange < 0:
+		return fmt.Errorf("drop range %d is negative", d.DropRange)
+	case d.DropRange > 0 && (!d.CanMoveOnLand || d.CanMoveOnWater || d.CanFly):
+		return errors.New("only land units can be dropped by bombers")
+	case d.AntiAircraftBonus < 0:
+		return fmt.Errorf("anti-aircraft bonus %d is negative", d.AntiAircraftBonus)
+	case d.BlastRadius < 0:
+		return fmt.Errorf("blast radius %d is negative", d.BlastRadius)
+	case d.BlastRadius > 0 && !d.CanFly:
+		return errors.New("only aircraft make bombing runs")
+	}
+	return nil
+}
+
+// definition returns the definition of the unit type, or nil for Blank and unknown types.
+func (r *UnitRules) definition(unitType UnitType) *UnitDefinition {
+	if unitType <= Blank || int(unitType) > len(r.Units) {
+		return nil
+	}
+	return &r.Units[unitType-1]
+}
+
+// definitionOf returns the definition of the unit type, empty for Blank and unknown types.
+func (r *UnitRules) definitionOf(unitType UnitType) UnitDefinition {
+	if definition := r.definition(unitType); definition != nil {
+		return *definition
+	}
+	return UnitDefinition{}
+}
+
+// next returns the unit type after the given one, going round to Tank after the last.
+func (r *UnitRules) next(unitType UnitType) UnitType {
+	return unitType%UnitType(len(r.Units)) + 1
+}
+
+// unitTypes returns every unit type a city can manufacture.
+func (r *UnitRules) unitTypes() []UnitType {
+	unitTypes := make([]UnitType, len(r.Units))
+	for i := range unitTypes {
+		unitTypes[i] = UnitType(i + 1)
+	}
+	return unitTypes
+}
+
+// typeToString returns the name of the unit type.
+func (r *UnitRules) typeToString(unitType UnitType) string {
+	if unitType == Blank {
+		return "Blank"
+	}
+	if definition := r.definition(unitType); definition != nil {
+		return definition.Name
+	}
+	return "Unknown"
+}
+
+// typeFromString returns the unit type with the given name.
+func (r *UnitRules) typeFromString(name string) (UnitType, bool) {
+	if name == "Blank" {
+		return Blank, true
+	}
+	if index := r.indexOf(name); index >= 0 {
+		return UnitType(index + 1), true
+	}
+	return Blank, false
+}
+
+// typeFromSymbol returns the unit type shown on maps by the symbol.
+func (r *UnitRules) typeFromSymbol(symbol string) (UnitType, bool) {
+	for i, unit := range r.Units {
+		if unit.Symbol == symbol {
+			return UnitType(i + 1), true
+		}
+	}
+	return Blank, false
+}
+
+// symbol returns the character the unit type is shown by on maps, ? for unknown types.
+func (r *UnitRules) symbol(unitType UnitType) string {
+	if definition := r.definition(unitType); definition != nil {
+		return definition.Symbol
+	}
+	return "?"
+}
+
+// newUnit creates a unit of the type, at full strength and with a day's moves and fuel.
+func (r *UnitRules) newUnit(positionX, positionY int, unitType UnitType, player int) *Unit {
+	definition := r.definitionOf(unitType)
+	return &Unit{
+		PositionX:          positionX,
+		PositionY:          positionY,
+		Type:               unitType,
+		Player:             player,
+		Strength:           definition.Strength,
+		MovesLeftThisDay:   definition.MovesPerDay,
+		Fuel:               definition.Fuel,
+		CanMoveOnLand:      definition.CanMoveOnLand,
+		CanMoveOnWater:     definition.CanMoveOnWater,
+		CanFly:             definition.CanFly,
+		AttackRange:        definition.AttackRange,
+		AttacksLeftThisDay: definition.AttacksPerDay,
+		CanCaptureCity:     definition.CanCaptureCity,
+	}
+}
+
+// unitDefinitionOf returns the definition of the unit type under the board's rules, empty for
+// Blank and unknown types.
+func (g *GameBoard) unitDefinitionOf(unitType UnitType) UnitDefinition {
+	return g.unitRules().definitionOf(unitType)
+}
+
+// unitTypeToString returns the name of the unit type under the board's rules.
+func (g *GameBoard) unitTypeToString(unitType UnitType) string {
+	return g.unitRules().typeToString(unitType)
+}
+
+// newUnit creates a unit of the type as the board's rules define it.
+func (g *GameBoard) newUnit(positionX, positionY int, unitType UnitType, player int) *Unit {
+	return g.unitRules().newUnit(positionX, positionY, unitType, player)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDefaultUnitRules(t *testing.T) {
+	rules := DefaultUnitRules()
+	if err := rules.Validate(); err != nil {
+		t.Fatalf("DefaultUnitRules().Validate() error = %v", err)
+	}
+	if got := rules.unitTypes(); len(got) != len(builtInUnitNames) || got[0] != Tank || got[len(got)-1] != CruiseMissile {
+		t.Errorf("unitTypes() = %v; want Tank to CruiseMissile", got)
+	}
+	board := NewGameBoard(1, 1)
+	if !board.isDeepDraft(Battleship) || !board.isDeepDraft(Carrier) || board.isDeepDraft(Destroyer) {
+		t.Errorf("isDeepDraft() does not match the default rules")
+	}
+
+	var buf bytes.Buffer
+	if err := rules.WriteUnitRules(&buf); err != nil {
+		t.Fatalf("WriteUnitRules() error = %v", err)
+	}
+	written, err := ParseUnitRules(nil, buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParseUnitRules() of written rules error = %v", err)
+	}
+	if !reflect.DeepEqual(written, rules) {
+		t.Errorf("ParseUnitRules() of written rules = %+v; want %+v", written, rules)
+	}
+}
+
+func TestParseUnitRulesOverride(t *testing.T) {
+	const file = `{"units": [
+		{"name": "Tank", "strength": 3},
+		{"name": "Hovercraft", "symbol": "V", "strength": 2, "movesPerDay": 3, "daysToProduce": 6,
+		 "canMoveOnLand": true, "canMoveOnWater": true, "attackRange": 1, "attacksPerDay": 1}
+	]}`
+	rules, err := ParseUnitRules(DefaultUnitRules(), []byte(file))
+	if err != nil {
+		t.Fatalf("ParseUnitRules() error = %v", err)
+	}
+	board := NewGameBoard(1, 1)
+	board.Rules = defaultRules.withUnitRules(rules)
+
+	if got := board.unitDefinitionOf(Tank).Strength; got != 3 {
+		t.Errorf("unitDefinitionOf(Tank) after the override has strength %d; want 3", got)
+	}
+	if got := board.unitDefinitionOf(Tank).MovesPerDay; got != 2 {
+		t.Errorf("unitDefinitionOf(Tank) after overriding its strength has %d moves; want 2 as before", got)
+	}
+	hovercraft, ok := rules.typeFromString("Hovercraft")
+	if !ok || hovercraft != CruiseMissile+1 {
+		t.Fatalf("typeFromString(Hovercraft) = %d, %t; want the type after CruiseMissile", hovercraft, ok)
+	}
+	unit := board.newUnit(0, 0, hovercraft, 1)
+	if rules.symbol(unit.Type) != "V" || !unit.CanMoveOnLand || !unit.CanMoveOnWater || unit.Strength != 2 {
+		t.Errorf("newUnit() of a Hovercraft = %+v; want the added definition", unit)
+	}
+	if got := rules.next(hovercraft); got != Tank {
+		t.Errorf("next() of the last type = %s; want Tank", rules.typeToString(got))
+	}
+	// the override is only played on the board it was given to
+	if _, ok := unitTypeFromString("Hovercraft"); ok || GetNewUnitStrength(Tank) == 3 {
+		t.Errorf("ParseUnitRules() changed the unit types of other boards")
+	}
+}
+
+func TestParseUnitRulesInvalid(t *testing.T) {
+	type test struct {
+		name string
+		file string
+	}
+	tests := []test{
+		{name: "not JSON", file: `{"units": [`},
+		{name: "no strength", file: `{"units": [{"name": "Tank", "strength": 0}]}`},
+		{name: "no moves", file: `{"units": [{"name": "Fighter", "movesPerDay": -1}]}`},
+		{name: "aircraft without fuel", file: `{"units": [{"name": "Bomber", "fuel": 0}]}`},
+		{name: "ship with fuel", file: `{"units": [{"name": "Destroyer", "fuel": 5}]}`},
+		{name: "flying tank capturing cities", file: `{"units": [{"name": "Tank", "canFly": true, "canMoveOnWater": true, "fuel": 5}]}`},
+		{name: "grounded aircraft", file: `{"units": [{"name": "Fighter", "canMoveOnWater": false}]}`},
+		{name: "tank running aground", file: `{"units": [{"name": "Tank", "deepDraft": true}]}`},
+		{name: "shared symbol", file: `{"units": [{"name": "Submarine", "symbol": "D"}]}`},
+		{name: "terrain symbol", file: `{"units": [{"name": "Carrier", "symbol": "G"}]}`},
+		{name: "sea symbol", file: `{"units": [{"name": "Carrier", "symbol": "S"}]}`},
+		{name: "city symbol", file: `{"units": [{"name": "Carrier", "symbol": "c"}]}`},
+		{name: "stack symbol", file: `{"units": [{"name": "Carrier", "symbol": "*"}]}`},
+		{name: "symbol shared in another case", file: `{"units": [{"name": "Submarine", "symbol": "d"}]}`},
+		{name: "long symbol", file: `{"units": [{"name": "Carrier", "symbol": "CV"}]}`},
+		{name: "immobile unit", file: `{"units": [{"name": "Fort", "symbol": "Z", "strength": 5, "movesPerDay": 1, "daysToProduce": 5, "attackRange": 1}]}`},
+		{name: "artillery without range", file: `{"units": [{"name": "Artillery", "attackRange": 1}]}`},
+		{name: "dropped ship", file: `{"units": [{"name": "Destroyer", "dropRange": 5}]}`},
+		{name: "negative drop range", file: `{"units": [{"name": "Paratrooper", "dropRange": -1}]}`},
+		{name: "negative anti-aircraft bonus", file: `{"units": [{"name": "AntiAircraft", "antiAircraftBonus": -10}]}`},
+		{name: "unnamed unit", file: `{"units": [{"symbol": "Z", "strength": 5, "movesPerDay": 1, "daysToProduce": 5, "canMoveOnLand": true, "attackRange": 1}]}`},
+	}
+	for _, tc := range tests {
+		if _, err := ParseUnitRules(DefaultUnitRules(), []byte(tc.file)); err == nil {
+			t.Errorf("ParseUnitRules(), name:%s, got no error; want an error", tc.name)
+		}
+	}
+
+	if _, err := ParseUnitRules(nil, []byte(`{"units": [{"name": "Fighter"}]}`)); err == nil {
+		t.Errorf("ParseUnitRules() without the built-in types, got no error; want an error")
+	}
+}
+
+func TestLoadUnitRulesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "units.json")
+	if err := os.WriteFile(path, []byte(`{"units": [{"name": "Bomber", "daysToProduce": 12}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := loadRuleSet("modern", path)
+	if err != nil {
+		t.Fatalf("loadRuleSet() error = %v", err)
+	}
+	if got := rules.unitRules.definitionOf(Bomber).DaysToProduce; got != 12 {
+		t.Errorf("loadRuleSet() with the rules file, Bomber takes %d days to produce; want 12", got)
+	}
+	if got := rules.unitRules.definitionOf(Battleship).AttackRange; got != 3 {
+		t.Errorf("loadRuleSet() with the rules file, Battleship has attack range %d; want 3 of the modern rules", got)
+	}
+	if preset, _ := getRuleSet("modern"); preset.unitRules.definitionOf(Bomber).DaysToProduce == 12 {
+		t.Errorf("loadRuleSet() changed the preset modern rules")
+	}
+
+	if err := os.WriteFile(path, []byte(`{"units": [{"name": "Bomber", "daysToProduce": 0}]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRuleSet("", path); err == nil || !strings.Contains(err.Error(), "Bomber") {
+		t.Errorf("loadRuleSet() of an invalid file, error = %v; want an error naming the Bomber", err)
+	}
+}
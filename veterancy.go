@@ -0,0 +1,113 @@
+package main
+
+// Rank is how experienced a unit is.
+type Rank int
+
+const (
+	RankRecruit Rank = iota
+	RankVeteran
+	RankElite
+)
+
+const (
+	// veteranKills and veteranBattles are the kills or battles which make a unit a veteran.
+	veteranKills   = 2
+	veteranBattles = 4
+	// eliteKills and eliteBattles are the kills or battles which make a unit elite.
+	eliteKills   = 5
+	eliteBattles = 10
+	// rankCombatBonus is the percentage bonus each rank gives a unit attacking or defending.
+	rankCombatBonus = 20
+)
+
+// Rank returns the rank the unit has earned by its kills and battles.
+func (u *Unit) Rank() Rank {
+	switch {
+	case u.Kills >= eliteKills || u.Battles >= eliteBattles:
+		return RankElite
+	case u.Kills >= veteranKills || u.Battles >= veteranBattles:
+		return RankVeteran
+	}
+	return RankRecruit
+}
+
+func rankToString(rank Rank) string {
+	switch rank {
+	case RankVeteran:
+		return "veteran"
+	case RankElite:
+		return "elite"
+	default:
+		return "recruit"
+	}
+}
+
+// getMovesPerDay returns the moves the unit has each day: those of its type, and one more once
+// it is elite.
+func (g *GameBoard) getMovesPerDay(unit *Unit) int {
+	moves := g.unitDefinitionOf(unit.Type).MovesPerDay
+	if unit.Rank() >= RankElite {
+		moves++
+	}
+	return moves
+}
+
+// getRankBonuses returns the percentage bonuses of the attacker and of the enemy unit it attacks
+// at the coordinate for their ranks.
+func (g *GameBoard) getRankBonuses(attacker *Unit, coordinate Coordinate) (attack, defence int) {
+	attack = rankCombatBonus * int(attacker.Rank())
+	if defender := g.getUnitAtCoordinates(coordinate, attacker.Player); defender != nil {
+		defence = rankCombatBonus * int(defender.Rank())
+	}
+	return attack, defence
+}
+
+// recordBattle counts a battle the unit survived, and a kill if it destroyed its enemy, and
+// emits EventUnitPromoted if the unit rises a rank.
+func (g *GameBoard) recordBattle(unit *Unit, kill bool) {
+	rank := unit.Rank()
+	unit.Battles++
+	if kill {
+		unit.Kills++
+	}
+	if unit.Rank() > rank {
+		g.printf("%s %d is promoted to %s\n", g.unitTypeToString(unit.Type), unit.ID, rankToString(unit.Rank()))
+		g.emit(EventUnitPromoted, unit.Player, unit.Type, Coordinate{unit.PositionX, unit.PositionY})
+	}
+}
+
+// getVeteranRetreatMoves returns the AI's moves to preserve a veteran which has lost half its
+// strength or more under rules where units repair: a step towards the nearest city of its player,
+// or none to hold the unit there until it is repaired. It returns false when the unit need not or
+// cannot retreat.
+func (g *GameBoard) getVeteranRetreatMoves(unit *Unit) ([]Coordinate, bool) {
+	if !g.rules().Repair || unit.Rank() < RankVeteran || unit.Strength*2 > g.unitDefinitionOf(unit.Type).Strength {
+		return nil, false
+	}
+	var nearest *City
+	distance := 0
+	for i := range g.Cities {
+		city := &g.Cities[i]
+		if int(city.OccupyingPlayer) != unit.Player {
+			continue
+		}
+		d := maxInt(abs(city.PositionX-unit.PositionX), abs(city.PositionY-unit.PositionY))
+		if nearest == nil || d < distance {
+			nearest, distance = city, d
+		}
+	}
+	if nearest == nil {
+		return nil, false
+	}
+	if distance == 0 {
+		return []Coordinate{}, true
+	}
+	target := Coordinate{nearest.PositionX, nearest.PositionY}
+	if g.isAdjacentMove(target, unit) {
+		return []Coordinate{target}, true
+	}
+	if step := getSecondCoordinate(g.FindPath(target, unit)); step != nil && g.determineAction(*step, unit) == ActionMove {
+		return []Coordinate{*step}, true
+	}
+	return nil, false
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRank(t *testing.T) {
+	type test struct {
+		name    string
+		kills   int
+		battles int
+		want    Rank
+	}
+	tests := []test{
+		{name: "new unit", want: RankRecruit},
+		{name: "a kill", kills: 1, battles: 1, want: RankRecruit},
+		{name: "veteran by kills", kills: veteranKills, battles: veteranKills, want: RankVeteran},
+		{name: "veteran by battles", battles: veteranBattles, want: RankVeteran},
+		{name: "elite by kills", kills: eliteKills, battles: eliteKills, want: RankElite},
+		{name: "elite by battles", kills: 1, battles: eliteBattles, want: RankElite},
+	}
+	for _, tc := range tests {
+		unit := NewUnit(0, 0, Tank, 1)
+		unit.Kills, unit.Battles = tc.kills, tc.battles
+		if got := unit.Rank(); got != tc.want {
+			t.Errorf("Rank(), name:%s, got %s; want %s", tc.name, rankToString(got), rankToString(tc.want))
+		}
+	}
+	unit := NewUnit(0, 0, Tank, 1)
+	unit.Battles = eliteBattles
+	if got := NewGameBoard(1, 1).getMovesPerDay(unit); got != GetMovesPerDay(Tank)+1 {
+		t.Errorf("getMovesPerDay() of an elite tank = %d; want %d", got, GetMovesPerDay(Tank)+1)
+	}
+}
+
+func TestResolveUnitAttackExperience(t *testing.T) {
+	board := newTerrainBoard("LLL")
+	var promoted []Event
+	board.AddEventListener(func(event Event) {
+		if event.Type == EventUnitPromoted {
+			promoted = append(promoted, event)
+		}
+	})
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.addUnit(NewUnit(0, 1, Tank, 2))
+	board.addUnit(NewUnit(0, 2, Fighter, 2))
+	board.Units[0].Kills = veteranKills - 1
+	board.Units[0].Battles = veteranKills - 1
+
+	// a hit which the defender survives counts a battle for both
+	board.resolveUnitAttack(&board.Units[0], &board.Units[1], true)
+	if tank := board.getUnitByID(2); tank.Battles != 1 || tank.Kills != 0 {
+		t.Errorf("resolveUnitAttack() left the defender with %d kills in %d battles; want 0 in 1", tank.Kills, tank.Battles)
+	}
+	// destroying the defender counts a kill, which promotes the attacker
+	board.resolveUnitAttack(&board.Units[0], &board.Units[1], true)
+	attacker := board.getUnitByID(1)
+	if attacker.Kills != veteranKills || attacker.Battles != veteranKills+1 || attacker.Rank() != RankVeteran {
+		t.Errorf("resolveUnitAttack() left the attacker a %s with %d kills in %d battles; want a veteran with %d in %d",
+			rankToString(attacker.Rank()), attacker.Kills, attacker.Battles, veteranKills, veteranKills+1)
+	}
+	if len(promoted) != 1 || promoted[0].Player != 1 || promoted[0].UnitType != Tank {
+		t.Errorf("resolveUnitAttack() emitted promotions %v; want the attacking tank's", promoted)
+	}
+	// a defender which destroys its attacker counts a kill
+	board.resolveUnitAttack(board.getUnitByID(3), attacker, false)
+	if fighter := board.getUnitByID(3); fighter != nil {
+		t.Errorf("resolveUnitAttack() left the fighter which lost; want it destroyed")
+	}
+	if tank := board.getUnitByID(1); tank.Kills != veteranKills+1 {
+		t.Errorf("resolveUnitAttack() left the defending tank with %d kills; want %d", tank.Kills, veteranKills+1)
+	}
+}
+
+func TestVeteranCombatBonus(t *testing.T) {
+	board := newTerrainBoard("LL")
+	board.addUnit(NewUnit(0, 1, Tank, 2))
+	attacker := NewUnit(0, 0, Tank, 1)
+	attacker.Battles = eliteBattles
+	wins := 0
+	for i := 0; i < 4000; i++ {
+		if board.getCombatOutcome(attacker, Coordinate{0, 1}) {
+			wins++
+		}
+	}
+	// half the fights, with the elite's 40% bonus: 70 in 100
+	if wins < 2650 || wins > 2950 {
+		t.Errorf("getCombatOutcome() of an elite tank, got %d wins in 4000; want about 2800", wins)
+	}
+}
+
+func TestGetVeteranRetreatMoves(t *testing.T) {
+	type test struct {
+		name     string
+		battles  int
+		strength int
+		at       Coordinate
+		ruleSet  string
+		want     []Coordinate
+		wantOK   bool
+	}
+	tests := []test{
+		{name: "recruit", strength: 1, at: Coordinate{0, 3}, ruleSet: "modern", wantOK: false},
+		{name: "unharmed veteran", battles: veteranBattles, strength: 2, at: Coordinate{0, 3}, ruleSet: "modern", wantOK: false},
+		{name: "damaged veteran", battles: veteranBattles, strength: 1, at: Coordinate{0, 3}, ruleSet: "modern", want: []Coordinate{{0, 2}}, wantOK: true},
+		{name: "damaged veteran in its city", battles: veteranBattles, strength: 1, at: Coordinate{0, 0}, ruleSet: "modern", want: []Coordinate{}, wantOK: true},
+		{name: "damaged veteran without repair", battles: veteranBattles, strength: 1, at: Coordinate{0, 3}, ruleSet: "classic", wantOK: false},
+		{name: "damaged veteran in its city without repair", battles: veteranBattles, strength: 1, at: Coordinate{0, 0}, ruleSet: "classic", wantOK: false},
+	}
+	for _, tc := range tests {
+		board := newTerrainBoard("LLLLL")
+		useRuleSetForTest(t, board, tc.ruleSet)
+		addCity(board, Coordinate{0, 0}, 1)
+		board.addUnit(NewUnit(tc.at.PositionX, tc.at.PositionY, Tank, 1))
+		unit := &board.Units[0]
+		unit.Battles, unit.Strength = tc.battles, tc.strength
+		got, ok := board.getVeteranRetreatMoves(unit)
+		if ok != tc.wantOK || (ok && !reflect.DeepEqual(got, tc.want)) {
+			t.Errorf("getVeteranRetreatMoves(), name:%s, got %v, %t; want %v, %t", tc.name, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
+
+func TestSaveKeepsExperience(t *testing.T) {
+	board := newTerrainBoard("LL")
+	board.addUnit(NewUnit(0, 0, Tank, 1))
+	board.Units[0].Kills, board.Units[0].Battles = 3, 7
+	var buf bytes.Buffer
+	if err := board.SaveGame(&buf); err != nil {
+		t.Fatalf("SaveGame() error = %v", err)
+	}
+	loaded, err := LoadGame(&buf)
+	if err != nil {
+		t.Fatalf("LoadGame() error = %v", err)
+	}
+	if unit := loaded.Units[0]; unit.Kills != 3 || unit.Battles != 7 {
+		t.Errorf("LoadGame() of a saved veteran = %d kills in %d battles; want 3 in 7", unit.Kills, unit.Battles)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+// PlayerFog holds the fog of war of each player, true where the player has not yet seen the cell.
+// Cell.IsFog is the combined fog of both players.
+type PlayerFog [2][][]bool
+
+// PlayerView is the part of the game state visible to one player.
+type PlayerView struct {
+	Day     int          `json:"day"`
+	Player  int          `json:"player"`
+	Rows    int          `json:"rows"`
+	Columns int          `json:"columns"`
+	Grid    []string     `json:"grid"`              // one string per row: '?' fog, 'C' city, otherwise the terrainToSymbol letter
+	Units   []remoteUnit `json:"units"`             // the player's own units
+	Enemies []remoteUnit `json:"enemies"`           // enemy units in sight of the player's units and cities
+	Cities  []viewCity   `json:"cities"`            // cities outside the fog of war
+	Weather *Weather     `json:"weather,omitempty"` // the day's weather, when the rules in play have weather
+}
+
+// viewCity describes a city in a PlayerView.
+type viewCity struct {
+	PositionX  int    `json:"x"`
+	PositionY  int    `json:"y"`
+	Owner      int    `json:"owner"`                // 0 when unoccupied, otherwise the occupying player
+	Production string `json:"production,omitempty"` // only shown for the player's own cities
+	DaysLeft   int    `json:"daysLeft,omitempty"`
+}
+
+// isFogForPlayer checks if the cell is hidden from the player by the fog of war.
+func (g *GameBoard) isFogForPlayer(player, row, col int) bool {
+	fog := g.Fog[player-1]
+	if fog == nil {
+		return true
+	}
+	return fog[row][col]
+}
+
+// clearFogOfWarForPlayer clears the fog of war of a player around the specified coordinates within a given radius.
+func (g *GameBoard) clearFogOfWarForPlayer(player int, coordinate Coordinate, radius int) {
+	if player != 1 && player != 2 {
+		return
+	}
+	if g.Fog[player-1] == nil {
+		fog := make([][]bool, g.Rows)
+		for i := range fog {
+			fog[i] = make([]bool, g.Columns)
+			for j := range fog[i] {
+				fog[i][j] = true
+			}
+		}
+		g.Fog[player-1] = fog
+	}
+	for i := coordinate.PositionX - radius; i <= coordinate.PositionX+radius; i++ {
+		for j := coordinate.PositionY - radius; j <= coordinate.PositionY+radius; j++ {
+			if i >= 0 && i < g.Rows && j >= 0 && j < g.Columns {
+				g.Fog[player-1][i][j] = false
+			}
+		}
+	}
+}
+
+// updateFogOfWarForPlayer clears the fog of war of a player around all of the player's units and cities.
+func (g *GameBoard) updateFogOfWarForPlayer(player int) {
+	for _, unit := range g.Units {
+		if unit.Player == player {
+			coordinate := Coordinate{unit.PositionX, unit.PositionY}
+			g.clearFogOfWarForPlayer(player, coordinate, g.getVisionRadius(coordinate, g.getCityAtCoordinates(coordinate) != nil))
+		}
+	}
+	for _, city := range g.Cities {
+		if int(city.OccupyingPlayer) == player {
+			coordinate := Coordinate{city.PositionX, city.PositionY}
+			g.clearFogOfWarForPlayer(player, coordinate, g.getVisionRadius(coordinate, true))
+		}
+	}
+}
+
+// isInSightOfPlayer checks if the coordinate is within the vision radius of one of the player's
+// units or cities, which is the next cell unless the weather says otherwise.
+func (g *GameBoard) isInSightOfPlayer(player int, coordinate Coordinate) bool {
+	isNear := func(x, y int, isCity bool) bool {
+		radius := g.getVisionRadius(Coordinate{x, y}, isCity)
+		return x >= coordinate.PositionX-radius && x <= coordinate.PositionX+radius &&
+			y >= coordinate.PositionY-radius && y <= coordinate.PositionY+radius
+	}
+	for _, unit := range g.Units {
+		if unit.Player == player && isNear(unit.PositionX, unit.PositionY, g.Grid[unit.PositionX][unit.PositionY].HasCity) {
+			return true
+		}
+	}
+	for _, city := range g.Cities {
+		if int(city.OccupyingPlayer) == player && isNear(city.PositionX, city.PositionY, true) {
+			return true
+		}
+	}
+	return false
+}
+
+// visibleUnitsAt returns the units at the coordinate the player can see: outside the player's fog
+// of war, their own units and enemy units in sight. Without a player (0), every unit is visible.
+func (g *GameBoard) visibleUnitsAt(player int, coordinate Coordinate) []Unit {
+	if player != 0 && g.isFogForPlayer(player, coordinate.PositionX, coordinate.PositionY) {
+		return nil
+	}
+	var units []Unit
+	for _, unit := range g.Units {
+		if unit.PositionX != coordinate.PositionX || unit.PositionY != coordinate.PositionY {
+			continue
+		}
+		if player == 0 || unit.Player == player || g.isUnitInSightOfPlayer(player, &unit) {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+// getPlayerView returns the game state as seen by the player through their fog of war.
+// Without a player (0), the whole board is visible and Units holds every unit.
+func (g *GameBoard) getPlayerView(player int) PlayerView {
+	g.updateFogOfWarForPlayer(player)
+	view := PlayerView{
+		Day:     g.Day,
+		Player:  player,
+		Rows:    g.Rows,
+		Columns: g.Columns,
+		Units:   []remoteUnit{},
+		Enemies: []remoteUnit{},
+		Cities:  []viewCity{},
+	}
+	if g.rules().Weather {
+		view.Weather = g.getWeather()
+	}
+	for i := 0; i < g.Rows; i++ {
+		row := make([]byte, g.Columns)
+		for j := 0; j < g.Columns; j++ {
+			switch {
+			case player != 0 && g.isFogForPlayer(player, i, j):
+				row[j] = '?'
+			case g.Grid[i][j].HasCity:
+				row[j] = 'C'
+			default:
+				row[j] = terrainToSymbol(g.Grid[i][j])
+			}
+		}
+		view.Grid = append(view.Grid, string(row))
+	}
+	for i := range g.Units {
+		unit := &g.Units[i]
+		if player == 0 || unit.Player == player {
+			view.Units = append(view.Units, *g.newRemoteUnit(unit))
+		} else if g.isUnitInSightOfPlayer(player, unit) {
+			view.Enemies = append(view.Enemies, *g.newRemoteUnit(unit))
+		}
+	}
+	for _, city := range g.Cities {
+		if player != 0 && g.isFogForPlayer(player, city.PositionX, city.PositionY) {
+			continue
+		}
+		c := viewCity{PositionX: city.PositionX, PositionY: city.PositionY, Owner: int(city.OccupyingPlayer)}
+		if c.Owner == player {
+			c.Production = g.unitTypeToString(city.ManufacturingUnit)
+			c.DaysLeft = city.DaysUntilUnitReady
+		}
+		view.Cities = append(view.Cities, c)
+	}
+	return view
+}
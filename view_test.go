@@ -0,0 +1,34 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetPlayerView(t *testing.T) {
+	board := newLandBoard(3, 5)
+	board.Grid[0][0].IsLand = false
+	board.addUnit(NewUnit(1, 1, Tank, 1))
+	board.addUnit(NewUnit(1, 2, Tank, 2)) // in sight of player 1's tank
+	board.addUnit(NewUnit(2, 4, Tank, 2)) // in player 2's city, out of sight
+
+	view := board.getPlayerView(1)
+
+	wantGrid := []string{
+		"SLLLC",
+		"LLLLL",
+		"LLL??",
+	}
+	if !reflect.DeepEqual(view.Grid, wantGrid) {
+		t.Errorf("getPlayerView() grid = %v; want %v", view.Grid, wantGrid)
+	}
+	if len(view.Units) != 1 || view.Units[0].ID != 1 {
+		t.Errorf("getPlayerView() units = %+v; want unit 1", view.Units)
+	}
+	if len(view.Enemies) != 1 || view.Enemies[0].ID != 2 {
+		t.Errorf("getPlayerView() enemies = %+v; want unit 2", view.Enemies)
+	}
+	if len(view.Cities) != 1 || view.Cities[0].Owner != 1 {
+		t.Errorf("getPlayerView() cities = %+v; want player 1's city", view.Cities)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+const (
+	// dayNightCycle is how many days make a cycle of day and night; the last day of each is a night.
+	dayNightCycle = 4
+	// seasonDays is how many days a season lasts; winter is the last of the four.
+	seasonDays = 30
+	// maxStorms and maxFogBanks are the most storms and fog banks there are on a day.
+	maxStorms   = 2
+	maxFogBanks = 2
+	// stormRadius and fogBankRadius are how far from their centres storms and fog banks reach.
+	stormRadius   = 2
+	fogBankRadius = 2
+	// stormMoveCost is the moves a ship or an aircraft spends entering a storm.
+	stormMoveCost = 2
+	// weatherSeedFactor spreads the days' weather seeds apart from the board's seed.
+	weatherSeedFactor = 7919
+)
+
+// Weather is the weather of a day, generated from the board's seed and the day, so it is the
+// same in every game played from the same seed and takes nothing from the game's random numbers.
+type Weather struct {
+	Night    bool         `json:"night,omitempty"`    // units outside cities see only their own cells
+	Winter   bool         `json:"winter,omitempty"`   // shallows freeze, and ships cannot enter them
+	Storms   []Coordinate `json:"storms,omitempty"`   // centres of storms, which slow ships and aircraft at sea and ground aircraft
+	FogBanks []Coordinate `json:"fogBanks,omitempty"` // centres of fog banks, in which units see only their own cells
+}
+
+// clearWeather is the weather of every day when the rules in play have no weather.
+var clearWeather = &Weather{}
+
+// getWeather returns the weather of the day, which is clear when the rules in play have none.
+func (g *GameBoard) getWeather() *Weather {
+	if !g.rules().Weather {
+		return clearWeather
+	}
+	if g.weather == nil || g.weatherDay != g.Day || g.weatherSeed != g.Seed {
+		g.weather = g.newWeather(g.Day)
+		g.weatherDay, g.weatherSeed = g.Day, g.Seed
+	}
+	return g.weather
+}
+
+// newWeather generates the weather of a day.
+func (g *GameBoard) newWeather(day int) *Weather {
+	r := rand.New(rand.NewSource(g.Seed*weatherSeedFactor + int64(day)))
+	weather := &Weather{
+		Night:  day%dayNightCycle == dayNightCycle-1,
+		Winter: (day/seasonDays)%4 == 3,
+	}
+	for i := r.Intn(maxStorms + 1); i > 0; i-- {
+		weather.Storms = append(weather.Storms, Coordinate{r.Intn(g.Rows), r.Intn(g.Columns)})
+	}
+	for i := r.Intn(maxFogBanks + 1); i > 0; i-- {
+		weather.FogBanks = append(weather.FogBanks, Coordinate{r.Intn(g.Rows), r.Intn(g.Columns)})
+	}
+	return weather
+}
+
+// isWithin checks if the coordinate is within the radius of any of the centres.
+func isWithin(coordinate Coordinate, centres []Coordinate, radius int) bool {
+	for _, centre := range centres {
+		if abs(centre.PositionX-coordinate.PositionX) <= radius && abs(centre.PositionY-coordinate.PositionY) <= radius {
+			return true
+		}
+	}
+	return false
+}
+
+// isStorm checks if a storm rages over the cell, which must be at sea.
+func (g *GameBoard) isStorm(coordinate Coordinate) bool {
+	cell := g.Grid[coordinate.PositionX][coordinate.PositionY]
+	return !cell.IsLand && !cell.HasCity && isWithin(coordinate, g.getWeather().Storms, stormRadius)
+}
+
+// isFogBank checks if a fog bank lies over the cell.
+func (g *GameBoard) isFogBank(coordinate Coordinate) bool {
+	return isWithin(coordinate, g.getWeather().FogBanks, fogBankRadius)
+}
+
+// isFrozen checks if the cell is shallows frozen over in winter.
+func (g *GameBoard) isFrozen(coordinate Coordinate) bool {
+	return g.Grid[coordinate.PositionX][coordinate.PositionY].Terrain == TerrainShallows && g.getWeather().Winter
+}
+
+// getVisionRadius returns how far the player sees from the cell of one of their units or cities:
+// a cell, or only the cell itself in a fog bank, or at night outside a city.
+func (g *GameBoard) getVisionRadius(coordinate Coordinate, isCity bool) int {
+	if g.isFogBank(coordinate) || (g.getWeather().Night && !isCity) {
+		return 0
+	}
+	return 1
+}
+
+// groundAircraft takes the moves of the day from the aircraft in storms.
+func (g *GameBoard) groundAircraft() {
+	for i := range g.Units {
+		unit := &g.Units[i]
+		if unit.CanFly && g.isStorm(Coordinate{unit.PositionX, unit.PositionY}) {
+			g.printf("%s %d is grounded by a storm\n", g.unitTypeToString(unit.Type), unit.ID)
+			unit.MovesLeftThisDay = 0
+		}
+	}
+}
+
+// describe returns the weather in a few words, or "clear".
+func (w *Weather) describe() string {
+	var parts []string
+	if w.Night {
+		parts = append(parts, "night")
+	}
+	if w.Winter {
+		parts = append(parts, "winter")
+	}
+	if n := len(w.Storms); n > 0 {
+		parts = append(parts, countOf(n, "storm"))
+	}
+	if n := len(w.FogBanks); n > 0 {
+		parts = append(parts, countOf(n, "fog bank"))
+	}
+	if len(parts) == 0 {
+		return "clear"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// countOf returns the number of things, such as "a storm" or "2 storms".
+func countOf(n int, thing string) string {
+	if n == 1 {
+		return "a " + thing
+	}
+	return fmt.Sprintf("%d %ss", n, thing)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// setWeatherForTest makes the weather of the board's day the given weather.
+func setWeatherForTest(board *GameBoard, day int, weather *Weather) {
+	if board.Seed == 0 {
+		board.Seed = 1
+	}
+	board.weather, board.weatherDay, board.weatherSeed = weather, day, board.Seed
+}
+
+func TestGetWeather(t *testing.T) {
+	board := newTerrainBoard("SSSS", "SSSS")
+	board.Seed = 42
+	if got := board.getWeather(); !reflect.DeepEqual(got, &Weather{}) {
+		t.Errorf("getWeather() under the classic rules = %+v; want clear weather", got)
+	}
+
+	useRuleSetForTest(t, board, "campaign")
+	other := newTerrainBoard("SSSS", "SSSS")
+	other.Seed = 42
+	useRuleSetForTest(t, other, "campaign")
+	different := false
+	for day := 0; day < seasonDays*4; day++ {
+		board.Day, other.Day = day, day
+		weather := board.getWeather()
+		if !reflect.DeepEqual(weather, other.getWeather()) {
+			t.Fatalf("getWeather() on day %d differs between boards with the same seed", day)
+		}
+		if weather.Night != (day%dayNightCycle == dayNightCycle-1) || weather.Winter != (day >= seasonDays*3) {
+			t.Errorf("getWeather() on day %d = night %t, winter %t; want night every %d days and winter in the last season",
+				day, weather.Night, weather.Winter, dayNightCycle)
+		}
+		if len(weather.Storms) > maxStorms || len(weather.FogBanks) > maxFogBanks {
+			t.Errorf("getWeather() on day %d = %+v; want at most %d storms and %d fog banks", day, weather, maxStorms, maxFogBanks)
+		}
+		other.Seed = 43
+		if !reflect.DeepEqual(weather, other.getWeather()) {
+			different = true
+		}
+		other.Seed = 42
+	}
+	if !different {
+		t.Errorf("getWeather() is the same for every seed; want it generated from the seed")
+	}
+}
+
+func TestStorms(t *testing.T) {
+	board := newTerrainBoard(
+		"SSSSSSSL",
+		"SSSSSSSL",
+	)
+	useRuleSetForTest(t, board, "campaign")
+	board.addUnit(NewUnit(0, 0, Destroyer, 1))
+	board.addUnit(NewUnit(1, 1, Fighter, 1))
+	board.addUnit(NewUnit(1, 6, Fighter, 1))
+	setWeatherForTest(board, 1, &Weather{Storms: []Coordinate{{0, 1}}})
+	board.NextDay()
+
+	type test struct {
+		name       string
+		coordinate Coordinate
+		unit       *Unit
+		want       int
+	}
+	tests := []test{
+		{name: "ship into a storm", coordinate: Coordinate{0, 3}, unit: &board.Units[0], want: stormMoveCost},
+		{name: "ship in clear weather", coordinate: Coordinate{0, 4}, unit: &board.Units[0], want: 1},
+		{name: "aircraft into a storm", coordinate: Coordinate{0, 2}, unit: &board.Units[1], want: stormMoveCost},
+		{name: "storms stay at sea", coordinate: Coordinate{0, 7}, unit: &board.Units[1], want: 1},
+	}
+	for _, tc := range tests {
+		if got := board.getMoveCost(tc.coordinate, tc.unit); got != tc.want {
+			t.Errorf("getMoveCost(), name:%s, got %d; want %d", tc.name, got, tc.want)
+		}
+	}
+	if board.Units[1].MovesLeftThisDay != 0 {
+		t.Errorf("NextDay() left a fighter in a storm %d moves; want it grounded", board.Units[1].MovesLeftThisDay)
+	}
+	if board.Units[2].MovesLeftThisDay != GetMovesPerDay(Fighter) {
+		t.Errorf("NextDay() left a fighter outside the storm %d moves; want %d", board.Units[2].MovesLeftThisDay, GetMovesPerDay(Fighter))
+	}
+}
+
+func TestWinterIce(t *testing.T) {
+	board := newTerrainBoard("SWS")
+	useRuleSetForTest(t, board, "campaign")
+	destroyer := NewUnit(0, 0, Destroyer, 1)
+	setWeatherForTest(board, 0, &Weather{})
+	if !board.canEnterTerrain(Coordinate{0, 1}, destroyer) {
+		t.Errorf("canEnterTerrain() of a destroyer into shallows in summer = false; want true")
+	}
+	setWeatherForTest(board, 0, &Weather{Winter: true})
+	if board.canEnterTerrain(Coordinate{0, 1}, destroyer) {
+		t.Errorf("canEnterTerrain() of a destroyer into shallows in winter = true; want false")
+	}
+	if path := board.FindPath(Coordinate{0, 2}, destroyer); len(path) != 0 {
+		t.Errorf("FindPath() across frozen shallows = %v; want none", path)
+	}
+}
+
+func TestWeatherVision(t *testing.T) {
+	type test struct {
+		name    string
+		weather *Weather
+		want    bool // the tank sees the enemy next to it
+	}
+	tests := []test{
+		{name: "clear day", weather: &Weather{}, want: true},
+		{name: "night", weather: &Weather{Night: true}, want: false},
+		{name: "fog bank", weather: &Weather{FogBanks: []Coordinate{{0, 0}}}, want: false},
+	}
+	for _, tc := range tests {
+		board := newTerrainBoard("LLLLLLLL")
+		useRuleSetForTest(t, board, "campaign")
+		board.addUnit(NewUnit(0, 0, Tank, 1))
+		setWeatherForTest(board, 0, tc.weather)
+		if got := board.isInSightOfPlayer(1, Coordinate{0, 1}); got != tc.want {
+			t.Errorf("isInSightOfPlayer(), name:%s, got %t; want %t", tc.name, got, tc.want)
+		}
+	}
+	// cities see a cell at night
+	board := newTerrainBoard("LLLLLLLL")
+	useRuleSetForTest(t, board, "campaign")
+	addCity(board, Coordinate{0, 6}, 1)
+	setWeatherForTest(board, 0, &Weather{Night: true})
+	if !board.isInSightOfPlayer(1, Coordinate{0, 7}) {
+		t.Errorf("isInSightOfPlayer() next to a city at night = false; want true")
+	}
+}
@@ -0,0 +1,473 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WebServer serves a game to browsers: a page drawing the board on a canvas, a JSON API
+// exposing the game state and accepting the human player's orders, and a stream of
+// server-sent events telling browsers when the game changes.
+type WebServer struct {
+	Board     *GameBoard
+	Human     int           // the player played in the browser, 0 to only spectate
+	MaxDays   int           // the game is a draw after this many days, unlimited when 0
+	TurnDelay time.Duration // pause after each AI turn so spectators can follow the game
+
+	mu          sync.Mutex // guards the board, which the game changes while no order is awaited
+	waiting     *webWaiting
+	orders      chan webOrder
+	subscribers map[chan webEvent]bool
+	over        bool
+	winner      int
+}
+
+// webWaiting describes the decision the game is waiting for from the browser.
+type webWaiting struct {
+	Type    string      `json:"type"` // "move" or "production"
+	Unit    *remoteUnit `json:"unit,omitempty"`
+	City    *Coordinate `json:"city,omitempty"`
+	Choices []string    `json:"choices,omitempty"` // the units a city can manufacture
+}
+
+// webOrder is the browser's answer to a webWaiting.
+type webOrder struct {
+	Move     *Coordinate // nil to hold the unit for the rest of the day
+	UnitType UnitType
+}
+
+// webState is the game state sent to browsers.
+type webState struct {
+	PlayerView
+	Human   int         `json:"human"`
+	Waiting *webWaiting `json:"waiting,omitempty"`
+	Over    bool        `json:"over"`
+	Winner  int         `json:"winner"`
+}
+
+// webEvent is a game event sent to browsers.
+type webEvent struct {
+	Type       string      `json:"type"`
+	Day        int         `json:"day"`
+	Player     int         `json:"player,omitempty"`
+	Unit       string      `json:"unit,omitempty"`
+	Coordinate *Coordinate `json:"coordinate,omitempty"`
+}
+
+// webMoveRequest orders the unit waiting for orders to move, or to hold without a move.
+type webMoveRequest struct {
+	Unit int         `json:"unit"`
+	Move *Coordinate `json:"move"`
+}
+
+// webProductionRequest chooses what the city waiting for production manufactures.
+type webProductionRequest struct {
+	City Coordinate `json:"city"`
+	Unit string     `json:"unit"`
+}
+
+// webController is the Controller of the player played in the browser.
+type webController struct {
+	server *WebServer
+}
+
+// runWebCommand runs the "web" command with its command line arguments.
+func runWebCommand(args []string) error {
+	flags := flag.NewFlagSet("web", flag.ContinueOnError)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	mapFlags := addMapFlags(flags)
+	seed := flags.Int64("seed", time.Now().UnixNano(), "seed of the game")
+	spectate := flags.Bool("spectate", false, "watch the AI play both players")
+	turnDelay := flags.Duration("turnDelay", 500*time.Millisecond, "pause after each AI turn")
+	maxDays := flags.Int("maxDays", 0, "days after which the game is a draw, unlimited when 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	board, err := mapFlags.newGameBoard(*seed)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+	board.Output = io.Discard
+	server := &WebServer{Board: board, Human: 1, MaxDays: *maxDays, TurnDelay: *turnDelay}
+	if *spectate {
+		server.Human = 0
+	}
+	server.Start()
+	fmt.Printf("open http://%s in a browser\n", listener.Addr())
+	return http.Serve(listener, server.Handler())
+}
+
+// Start plays the game in the background, the human player's decisions coming from the browser.
+func (s *WebServer) Start() {
+	s.orders = make(chan webOrder)
+	s.subscribers = make(map[chan webEvent]bool)
+	if s.Human != 0 {
+		p := &Player{Name: "browser", Controller: &webController{server: s}}
+		if s.Human == 1 {
+			s.Board.Player1 = p
+		} else {
+			s.Board.Player2 = p
+		}
+	}
+	s.Board.AddEventListener(func(event Event) {
+		s.publish(s.newWebEvent(event))
+		if event.Type == EventTurnEnded && event.Player != s.Human && s.TurnDelay > 0 {
+			s.mu.Unlock()
+			time.Sleep(s.TurnDelay)
+			s.mu.Lock()
+		}
+	})
+
+	s.mu.Lock()
+	go func() {
+		defer s.mu.Unlock()
+		s.Board.DayZero()
+		s.winner = s.Board.PlayGame(s.MaxDays)
+		s.over = true
+		s.publish(webEvent{Type: "GameOver", Day: s.Board.Day, Player: s.winner})
+	}()
+}
+
+// Handler returns the handler serving the page and the API.
+func (s *WebServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handlePage)
+	mux.HandleFunc("/api/state", s.handleState)
+	mux.HandleFunc("/api/move", s.handleMove)
+	mux.HandleFunc("/api/production", s.handleProduction)
+	mux.HandleFunc("/api/events", s.handleEvents)
+	return mux
+}
+
+// handlePage serves the web page.
+func (s *WebServer) handlePage(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, webPage)
+}
+
+// handleState serves the game state, as seen by the human player or, when spectating, all of it.
+func (s *WebServer) handleState(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	state := webState{
+		PlayerView: s.Board.getPlayerView(s.Human),
+		Human:      s.Human,
+		Waiting:    s.waiting,
+		Over:       s.over,
+		Winner:     s.winner,
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, state)
+}
+
+// handleMove accepts the order for the unit waiting for orders. The move is checked with the
+// same rules the game plays it with.
+func (s *WebServer) handleMove(w http.ResponseWriter, r *http.Request) {
+	var request webMoveRequest
+	if !s.decodeRequest(w, r, &request) {
+		return
+	}
+	s.mu.Lock()
+	if s.waiting == nil || s.waiting.Type != "move" || s.waiting.Unit.ID != request.Unit {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("unit %d is not waiting for orders", request.Unit))
+		return
+	}
+	unit := s.Board.getUnitByID(request.Unit)
+	if request.Move != nil && !s.Board.isLegalMove(*request.Move, unit) {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("%s cannot move to (%d, %d)",
+			s.Board.unitTypeToString(unit.Type), request.Move.PositionX, request.Move.PositionY))
+		return
+	}
+	s.waiting = nil // no other request can answer the same decision
+	s.mu.Unlock()
+	s.orders <- webOrder{Move: request.Move}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleProduction accepts the production of the city waiting for production.
+func (s *WebServer) handleProduction(w http.ResponseWriter, r *http.Request) {
+	var request webProductionRequest
+	if !s.decodeRequest(w, r, &request) {
+		return
+	}
+	s.mu.Lock()
+	if s.waiting == nil || s.waiting.Type != "production" || *s.waiting.City != request.City {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, fmt.Sprintf("city at (%d, %d) is not waiting for production",
+			request.City.PositionX, request.City.PositionY))
+		return
+	}
+	unitType, ok := s.Board.unitRules().typeFromString(request.Unit)
+	if !ok || unitType == Blank {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("unknown unit type %q", request.Unit))
+		return
+	}
+	choice := false
+	for _, name := range s.waiting.Choices {
+		choice = choice || name == s.Board.unitTypeToString(unitType)
+	}
+	if !choice {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("city at (%d, %d) cannot build %s",
+			request.City.PositionX, request.City.PositionY, request.Unit))
+		return
+	}
+	s.waiting = nil
+	s.mu.Unlock()
+	s.orders <- webOrder{UnitType: unitType}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRequest decodes the JSON body of a POST request, answering with an error if it cannot.
+func (s *WebServer) decodeRequest(w http.ResponseWriter, r *http.Request, request interface{}) bool {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "use POST")
+		return false
+	}
+	if s.Human == 0 {
+		writeError(w, http.StatusForbidden, "spectators cannot give orders")
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(request); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// handleEvents streams game events to the browser as server-sent events until it disconnects.
+func (s *WebServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "streaming is not supported")
+		return
+	}
+	events := make(chan webEvent, 64)
+	s.mu.Lock()
+	s.subscribers[events] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subscribers, events)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case event := <-events:
+			data, _ := json.Marshal(event)
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// publish sends an event to every browser listening, dropping it for browsers too slow to
+// keep up, which refetch the whole state on their next event anyway. The caller holds s.mu.
+func (s *WebServer) publish(event webEvent) {
+	for events := range s.subscribers {
+		select {
+		case events <- event:
+		default:
+		}
+	}
+}
+
+// newWebEvent describes a game event for browsers, hiding where enemy events happen out of
+// the human player's sight.
+func (s *WebServer) newWebEvent(event Event) webEvent {
+	e := webEvent{Type: eventTypeToString(event.Type), Day: event.Day, Player: event.Player}
+	if event.Type == EventDayStarted || event.Type == EventTurnEnded {
+		return e
+	}
+	if s.Human != 0 && event.Player != s.Human && !s.Board.isInSightOfPlayer(s.Human, event.Coordinate) {
+		return e
+	}
+	coordinate := event.Coordinate
+	e.Coordinate = &coordinate
+	e.Unit = s.Board.unitTypeToString(event.UnitType)
+	return e
+}
+
+// wait tells browsers what the game is waiting for and waits for the answer, letting the
+// API use the board meanwhile. The caller holds s.mu.
+func (s *WebServer) wait(waiting *webWaiting) webOrder {
+	s.waiting = waiting
+	s.publish(webEvent{Type: "Waiting", Day: s.Board.Day, Player: s.Human})
+	s.mu.Unlock()
+	order := <-s.orders
+	s.mu.Lock()
+	return order
+}
+
+// BeginTurn implements Controller.
+func (c *webController) BeginTurn(g *GameBoard, player int) error {
+	g.updateFogOfWarForPlayer(player)
+	return nil
+}
+
+// ChooseProduction implements Controller.
+func (c *webController) ChooseProduction(g *GameBoard, city *City) UnitType {
+	waiting := &webWaiting{Type: "production", City: &Coordinate{city.PositionX, city.PositionY}}
+	for _, unitType := range g.getProductionChoices(city) {
+		waiting.Choices = append(waiting.Choices, g.unitTypeToString(unitType))
+	}
+	return c.server.wait(waiting).UnitType
+}
+
+// ChooseMove implements Controller.
+func (c *webController) ChooseMove(g *GameBoard, unit *Unit) (Coordinate, bool, error) {
+	order := c.server.wait(&webWaiting{Type: "move", Unit: g.newRemoteUnit(unit)})
+	if order.Move == nil {
+		return Coordinate{}, false, nil
+	}
+	return *order.Move, true, nil
+}
+
+// writeJSON answers a request with a JSON value.
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// writeError answers a request with a JSON error message.
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// webPage draws the board on a canvas and refetches the state on every event. Clicking a cell
+// next to the unit waiting for orders moves it there.
+const webPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>StratConClone-Go</title>
+<style>
+body { background: #222; color: #ddd; font-family: sans-serif; }
+canvas { display: block; margin: 8px 0; cursor: pointer; }
+#error { color: #f66; }
+</style>
+</head>
+<body>
+<div id="status">connecting...</div>
+<canvas id="board"></canvas>
+<div id="controls">
+<button id="hold" hidden>Hold</button>
+<select id="production" hidden></select> <button id="manufacture" hidden>Manufacture</button>
+</div>
+<div id="error"></div>
+<script>
+const cell = 24;
+const terrain = {S: "#1e4fa0", L: "#3a8a3a", C: "#3a8a3a", M: "#8a6e4b", F: "#1e5a28", W: "#4a9cd0", I: "#e8f0f8", "?": "#555"};
+const players = ["#ccc", "#f0c020", "#d040d0"];
+const canvas = document.getElementById("board");
+let state = null;
+
+async function refresh() {
+  state = await (await fetch("/api/state")).json();
+  draw();
+}
+
+function draw() {
+  canvas.width = state.columns * cell;
+  canvas.height = state.rows * cell;
+  const ctx = canvas.getContext("2d");
+  state.grid.forEach((row, x) => [...row].forEach((c, y) => {
+    ctx.fillStyle = terrain[c];
+    ctx.fillRect(y * cell, x * cell, cell, cell);
+  }));
+  for (const city of state.cities) {
+    ctx.fillStyle = players[city.owner];
+    ctx.fillRect(city.y * cell + 3, city.x * cell + 3, cell - 6, cell - 6);
+  }
+  const waiting = state.waiting;
+  for (const unit of state.units.concat(state.enemies)) {
+    ctx.fillStyle = players[unit.player];
+    ctx.beginPath();
+    ctx.arc(unit.y * cell + cell / 2, unit.x * cell + cell / 2, cell / 3, 0, 2 * Math.PI);
+    ctx.fill();
+    ctx.fillStyle = "#000";
+    ctx.font = "bold 12px monospace";
+    ctx.textAlign = "center";
+    ctx.textBaseline = "middle";
+    ctx.fillText(unit.type[0], unit.y * cell + cell / 2, unit.x * cell + cell / 2);
+  }
+  const selected = waiting && (waiting.unit || {x: waiting.city.PositionX, y: waiting.city.PositionY});
+  if (selected) {
+    ctx.strokeStyle = "#fff";
+    ctx.lineWidth = 2;
+    ctx.strokeRect(selected.y * cell + 1, selected.x * cell + 1, cell - 2, cell - 2);
+  }
+
+  let status = "Day " + state.day;
+  if (state.over) {
+    status += ": game over, " + (state.winner ? "player " + state.winner + " has won" : "a draw");
+  } else if (waiting && waiting.type == "move") {
+    status += ": " + waiting.unit.type + " at (" + waiting.unit.x + ", " + waiting.unit.y + "), moves left " + waiting.unit.movesLeft + ", click a cell next to it";
+  } else if (waiting) {
+    status += ": choose what the city manufactures";
+  } else if (state.human) {
+    status += ": waiting for the opponent";
+  }
+  document.getElementById("status").textContent = status;
+  document.getElementById("hold").hidden = !(waiting && waiting.type == "move");
+  const production = document.getElementById("production");
+  production.hidden = document.getElementById("manufacture").hidden = !(waiting && waiting.type == "production");
+  if (waiting && waiting.choices && production.options.length == 0) {
+    for (const choice of waiting.choices) {
+      production.add(new Option(choice, choice));
+    }
+  }
+}
+
+async function post(path, body) {
+  const response = await fetch(path, {method: "POST", body: JSON.stringify(body)});
+  document.getElementById("error").textContent = response.ok ? "" : (await response.json()).error;
+}
+
+canvas.addEventListener("click", (e) => {
+  if (state && state.waiting && state.waiting.type == "move") {
+    const move = {PositionX: Math.floor(e.offsetY / cell), PositionY: Math.floor(e.offsetX / cell)};
+    post("/api/move", {unit: state.waiting.unit.id, move: move});
+  }
+});
+document.getElementById("hold").addEventListener("click", () => {
+  post("/api/move", {unit: state.waiting.unit.id});
+});
+document.getElementById("manufacture").addEventListener("click", () => {
+  post("/api/production", {city: state.waiting.city, unit: document.getElementById("production").value});
+});
+
+new EventSource("/api/events").onmessage = refresh;
+refresh();
+</script>
+</body>
+</html>
+`
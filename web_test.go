@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// getWebState fetches the game state from a web server.
+func getWebState(t *testing.T, url string) webState {
+	response, err := http.Get(url + "/api/state")
+	if err != nil {
+		t.Fatalf("GET /api/state error = %v", err)
+	}
+	defer response.Body.Close()
+	var state webState
+	if err := json.NewDecoder(response.Body).Decode(&state); err != nil {
+		t.Fatalf("GET /api/state returned invalid JSON: %v", err)
+	}
+	return state
+}
+
+// waitForWebState polls the game state until the condition holds.
+func waitForWebState(t *testing.T, url string, condition func(webState) bool) webState {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		state := getWebState(t, url)
+		if condition(state) {
+			return state
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("game state did not change in time: %+v", state)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// postWeb posts a JSON request to a web server and returns the status code.
+func postWeb(t *testing.T, url, path, body string) int {
+	response, err := http.Post(url+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s error = %v", path, err)
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, response.Body)
+	return response.StatusCode
+}
+
+func TestWebServerPlay(t *testing.T) {
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	server := &WebServer{Board: board, Human: 1, MaxDays: 20}
+	server.Start()
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	state := waitForWebState(t, ts.URL, func(state webState) bool { return state.Waiting != nil })
+	if state.Waiting.Type != "production" || len(state.Waiting.Choices) == 0 {
+		t.Fatalf("waiting = %+v; want the starting city's production", state.Waiting)
+	}
+	if !strings.Contains(strings.Join(state.Grid, ""), "?") {
+		t.Errorf("GET /api/state shows the whole board; want the human player's fog of war")
+	}
+
+	events, err := http.Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatalf("GET /api/events error = %v", err)
+	}
+	defer events.Body.Close()
+
+	city := state.Waiting.City
+	type test struct {
+		name string
+		path string
+		body string
+		want int
+	}
+	tests := []test{
+		{name: "unknown unit", path: "/api/production", body: fmt.Sprintf(`{"city":{"PositionX":%d,"PositionY":%d},"unit":"Spaceship"}`, city.PositionX, city.PositionY), want: http.StatusBadRequest},
+		{name: "another city", path: "/api/production", body: `{"city":{"PositionX":-1,"PositionY":0},"unit":"Tank"}`, want: http.StatusConflict},
+		{name: "no unit is waiting", path: "/api/move", body: `{"unit":1}`, want: http.StatusConflict},
+		{name: "invalid JSON", path: "/api/move", body: `{`, want: http.StatusBadRequest},
+		{name: "tank", path: "/api/production", body: fmt.Sprintf(`{"city":{"PositionX":%d,"PositionY":%d},"unit":"Tank"}`, city.PositionX, city.PositionY), want: http.StatusNoContent},
+	}
+	for _, tc := range tests {
+		if got := postWeb(t, ts.URL, tc.path, tc.body); got != tc.want {
+			t.Errorf("POST %s, name:%s, got status %d; want %d", tc.path, tc.name, got, tc.want)
+		}
+	}
+
+	// the game plays on until the tank is ready and waits for its orders
+	seen := map[string]bool{}
+	scanner := bufio.NewScanner(events.Body)
+	for !seen["Waiting"] && scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var event webEvent
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+			t.Fatalf("GET /api/events sent invalid JSON %q: %v", line, err)
+		}
+		seen[event.Type] = true
+	}
+	for _, eventType := range []string{"DayStarted", "UnitProduced", "TurnEnded", "Waiting"} {
+		if !seen[eventType] {
+			t.Errorf("GET /api/events did not send a %s event, got %v", eventType, seen)
+		}
+	}
+
+	state = getWebState(t, ts.URL)
+	if state.Waiting == nil || state.Waiting.Type != "move" || state.Waiting.Unit.Type != "Tank" {
+		t.Fatalf("waiting = %+v; want the tank's orders", state.Waiting)
+	}
+	unit := state.Waiting.Unit
+	far := fmt.Sprintf(`{"unit":%d,"move":{"PositionX":%d,"PositionY":%d}}`, unit.ID, unit.PositionX+5, unit.PositionY)
+	if got := postWeb(t, ts.URL, "/api/move", far); got != http.StatusBadRequest {
+		t.Errorf("POST /api/move to a cell out of reach, got status %d; want %d", got, http.StatusBadRequest)
+	}
+	other := fmt.Sprintf(`{"unit":%d}`, unit.ID+100)
+	if got := postWeb(t, ts.URL, "/api/move", other); got != http.StatusConflict {
+		t.Errorf("POST /api/move for another unit, got status %d; want %d", got, http.StatusConflict)
+	}
+	hold := fmt.Sprintf(`{"unit":%d}`, unit.ID)
+	if got := postWeb(t, ts.URL, "/api/move", hold); got != http.StatusNoContent {
+		t.Errorf("POST /api/move to hold the tank, got status %d; want %d", got, http.StatusNoContent)
+	}
+}
+
+func TestWebServerSpectate(t *testing.T) {
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	server := &WebServer{Board: board, MaxDays: 3}
+	server.Start()
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	state := waitForWebState(t, ts.URL, func(state webState) bool { return state.Over })
+	if state.Day != 3 || state.Waiting != nil {
+		t.Errorf("GET /api/state after the game, day %d, waiting %+v; want day 3 and nothing waiting", state.Day, state.Waiting)
+	}
+	if strings.Contains(strings.Join(state.Grid, ""), "?") {
+		t.Errorf("GET /api/state hides part of the board from spectators: %v", state.Grid)
+	}
+	if got := postWeb(t, ts.URL, "/api/move", `{"unit":1}`); got != http.StatusForbidden {
+		t.Errorf("POST /api/move as a spectator, got status %d; want %d", got, http.StatusForbidden)
+	}
+
+	response, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("GET / error = %v", err)
+	}
+	defer response.Body.Close()
+	page, _ := io.ReadAll(response.Body)
+	if !strings.Contains(string(page), "<canvas") {
+		t.Errorf("GET / does not serve the page with the board's canvas")
+	}
+}
+
+func TestWebServerProductionWhilePlaying(t *testing.T) {
+	board := NewRandomGameBoard(10, 20, 4, 6, 2)
+	board.Output = io.Discard
+	server := &WebServer{Board: board, Human: 2, MaxDays: 20}
+	server.Start()
+	ts := httptest.NewServer(server.Handler())
+	defer ts.Close()
+
+	// orders sent while player 1's turn is played are turned away, without touching the board
+	for state := getWebState(t, ts.URL); state.Waiting == nil && !state.Over; state = getWebState(t, ts.URL) {
+		if got := postWeb(t, ts.URL, "/api/production", `{"city":{"PositionX":-1,"PositionY":0},"unit":"Tank"}`); got != http.StatusConflict {
+			t.Fatalf("POST /api/production while the game plays, got status %d; want %d", got, http.StatusConflict)
+		}
+	}
+}
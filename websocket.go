@@ -0,0 +1,334 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// websocketGUID is the key suffix a WebSocket server hashes to accept a handshake (RFC 6455).
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessage is the largest message a WebSocket connection accepts, as much as a bot's
+// line of JSON may be.
+const maxWebSocketMessage = 16 * 1024 * 1024
+
+// WebSocket frame opcodes.
+const (
+	websocketContinuation = 0x0
+	websocketText         = 0x1
+	websocketBinary       = 0x2
+	websocketClose        = 0x8
+	websocketPing         = 0x9
+	websocketPong         = 0xa
+)
+
+// websocketConn carries the line-delimited JSON protocol of the game server over a WebSocket:
+// each message is one line, without its newline.
+type websocketConn struct {
+	net.Conn
+	reader *bufio.Reader
+	client bool // a client masks the frames it sends
+
+	unread  []byte // the rest of the message being read, with its newline
+	writeMu sync.Mutex
+	line    []byte // the start of the line being written
+}
+
+// Read implements io.Reader, reading each message as a line.
+func (c *websocketConn) Read(p []byte) (int, error) {
+	for len(c.unread) == 0 {
+		message, err := c.readMessage()
+		if err != nil {
+			return 0, err
+		}
+		c.unread = append(message, '\n')
+	}
+	n := copy(p, c.unread)
+	c.unread = c.unread[n:]
+	return n, nil
+}
+
+// readMessage reads the frames of the next text or binary message, answering pings on the way.
+func (c *websocketConn) readMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch opcode {
+		case websocketContinuation, websocketText, websocketBinary:
+			if len(message)+len(payload) > maxWebSocketMessage {
+				return nil, fmt.Errorf("websocket message is longer than %d bytes", maxWebSocketMessage)
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		case websocketClose:
+			return nil, io.EOF
+		case websocketPing:
+			if err := c.writeFrame(websocketPong, payload); err != nil {
+				return nil, err
+			}
+		case websocketPong:
+		default:
+			return nil, fmt.Errorf("unknown websocket opcode %d", opcode)
+		}
+	}
+}
+
+// readFrame reads one frame, unmasking its payload.
+func (c *websocketConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.reader, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin, opcode = header[0]&0x80 != 0, header[0]&0x0f
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.reader, extended[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > maxWebSocketMessage {
+		return false, 0, nil, fmt.Errorf("websocket frame is longer than %d bytes", maxWebSocketMessage)
+	}
+	var mask [4]byte
+	masked := header[1]&0x80 != 0
+	if masked {
+		if _, err := io.ReadFull(c.reader, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// Write implements io.Writer, sending each complete line as a text message.
+func (c *websocketConn) Write(p []byte) (int, error) {
+	c.line = append(c.line, p...)
+	for {
+		end := bytes.IndexByte(c.line, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+		if err := c.writeFrame(websocketText, c.line[:end]); err != nil {
+			return 0, err
+		}
+		c.line = c.line[end+1:]
+	}
+}
+
+// writeFrame sends one final frame, masked when the connection is a client's.
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	frame := []byte{0x80 | opcode}
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xffff:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range frame[start:] {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+	_, err := c.Conn.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the connection.
+func (c *websocketConn) Close() error {
+	c.writeFrame(websocketClose, nil)
+	return c.Conn.Close()
+}
+
+// websocketAccept returns the Sec-WebSocket-Accept value answering the handshake key.
+func websocketAccept(key string) string {
+	hash := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// upgradeWebSocket answers a WebSocket handshake and returns the connection it opens.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (net.Conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" ||
+		!strings.Contains(strings.ToLower(r.Header.Get("Connection")), "upgrade") {
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return nil, errors.New("unsupported websocket version")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "cannot take over the connection", http.StatusInternalServerError)
+		return nil, errors.New("connection cannot be hijacked")
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(buffered, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", websocketAccept(key))
+	if err := buffered.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &websocketConn{Conn: conn, reader: buffered.Reader}, nil
+}
+
+// dialWebSocket opens a WebSocket connection to the ws:// address.
+func dialWebSocket(address string) (net.Conn, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "ws" {
+		return nil, fmt.Errorf("websocket address %q is not ws://", address)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host += ":80"
+	}
+	conn, err := net.Dial("tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	request, err := http.NewRequest(http.MethodGet, "http://"+u.Host+u.RequestURI(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	request.Header.Set("Upgrade", "websocket")
+	request.Header.Set("Connection", "Upgrade")
+	request.Header.Set("Sec-WebSocket-Key", key)
+	request.Header.Set("Sec-WebSocket-Version", "13")
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusSwitchingProtocols || response.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake with %s failed: %s", address, response.Status)
+	}
+	return &websocketConn{Conn: conn, reader: reader, client: true}, nil
+}
+
+// websocketListener is a net.Listener accepting WebSocket connections made to a path of an HTTP
+// server, so the game server can serve WebSocket clients as it does TCP clients.
+type websocketListener struct {
+	listener net.Listener
+	server   *http.Server
+	conns    chan net.Conn
+	done     chan struct{}
+	once     sync.Once
+}
+
+// newWebSocketListener serves HTTP on the listener, accepting WebSocket connections at the path.
+func newWebSocketListener(listener net.Listener, path string) *websocketListener {
+	l := &websocketListener{listener: listener, conns: make(chan net.Conn), done: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		select {
+		case l.conns <- conn:
+		case <-l.done:
+			conn.Close()
+		}
+	})
+	l.server = &http.Server{Handler: mux}
+	go l.server.Serve(listener)
+	return l
+}
+
+// Accept implements net.Listener.
+func (l *websocketListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener. Connections already accepted stay open.
+func (l *websocketListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		close(l.done)
+		err = l.server.Close()
+	})
+	return err
+}
+
+// Addr implements net.Listener.
+func (l *websocketListener) Addr() net.Addr {
+	return l.listener.Addr()
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestWebSocketAccept(t *testing.T) {
+	// the example handshake of RFC 6455
+	if got, want := websocketAccept("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("websocketAccept() = %q; want %q", got, want)
+	}
+}
+
+func TestWebSocketConnLines(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+	}{
+		{"short", `{"type":"join","name":"alice"}`},
+		{"16 bit length", strings.Repeat("x", 1000)},
+		{"64 bit length", strings.Repeat("y", 70000)},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientSide, serverSide := net.Pipe()
+			client := &websocketConn{Conn: clientSide, reader: bufio.NewReader(clientSide), client: true}
+			server := &websocketConn{Conn: serverSide, reader: bufio.NewReader(serverSide)}
+			defer clientSide.Close()
+			defer serverSide.Close()
+
+			for _, pair := range []struct{ from, to *websocketConn }{{client, server}, {server, client}} {
+				// the line is written in two pieces, as it would be by a buffered writer
+				go func(from *websocketConn) {
+					half := len(tt.line) / 2
+					from.Write([]byte(tt.line[:half]))
+					from.Write([]byte(tt.line[half:] + "\n"))
+				}(pair.from)
+				got, err := bufio.NewReader(pair.to).ReadString('\n')
+				if err != nil {
+					t.Fatalf("ReadString() error = %v", err)
+				}
+				if got != tt.line+"\n" {
+					t.Errorf("ReadString() = %d bytes; want the %d bytes of the line", len(got), len(tt.line)+1)
+				}
+			}
+		})
+	}
+}